@@ -3,6 +3,8 @@ package cfg
 import (
 	"flag"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/pkg/errors"
@@ -65,14 +67,66 @@ type AzureAIConfig struct {
 	ShowStats bool `yaml:"show_stats" json:"show_stats"`
 }
 
+// CheckpointConfig struct contains the configuration of the store in which
+// the scan of each repository periodically saves a checkpoint of its progress,
+// from which an interrupted scan is resumed.
+type CheckpointConfig struct {
+	// Path is the path of the database file when Store is
+	// CheckpointStoreSQLite. If empty, then the database file is created in
+	// the "checkpoints" subdirectory of the git work dir.
+	Path string `yaml:"path" json:"path"`
+	// S3 config, which is only used when Store is CheckpointStoreS3.
+	S3 CheckpointS3Config `yaml:"s3" json:"s3"`
+	// Store is the name of the backend used to store checkpoints:
+	//   - CheckpointStoreFile to write one file per repository to the
+	//     "checkpoints" subdirectory of the git work dir;
+	//   - CheckpointStoreS3 to write one object per repository to a bucket
+	//     of an S3-compatible object storage service;
+	//   - CheckpointStoreSQLite to store checkpoints in a SQLite database file.
+	//
+	// Store default is defined in DefaultCheckpointStore const.
+	Store string `yaml:"store" json:"store"`
+}
+
+// CheckpointS3Config struct contains the configuration used to connect to an
+// S3-compatible object storage service (e.g. AWS S3 or MinIO) for storing
+// checkpoints.
+type CheckpointS3Config struct {
+	AccessKeyID string `yaml:"access_key_id" json:"access_key_id"`
+	// Anonymous allows requests to be sent without signing them when the
+	// AccessKeyID and SecretAccessKey are empty, which must be turned on
+	// explicitly because checkpoints may contain the state of a scan of
+	// repositories with PHI/PII.
+	Anonymous bool   `yaml:"anonymous" json:"anonymous"`
+	Bucket    string `yaml:"bucket" json:"bucket"`
+	// Endpoint is the URL of the service, where objects are addressed with
+	// path-style URLs, i.e. "<endpoint>/<bucket>/<key>". If empty, then the
+	// AWS S3 endpoint of the Region is used.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Prefix is prepended to the key of each checkpoint object, e.g.
+	// "no-phi-ai/checkpoints/".
+	Prefix string `yaml:"prefix" json:"prefix"`
+	// Region default is defined in DefaultCheckpointS3Region const.
+	Region          string `yaml:"region" json:"region"`
+	SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key"`
+	// ServerSideEncryption is the server-side encryption that the service
+	// applies to each checkpoint object, i.e. CheckpointS3SSEAES256 or
+	// CheckpointS3SSEKMS.
+	//
+	// ServerSideEncryption default is defined in
+	// DefaultCheckpointS3ServerSideEncryption const.
+	ServerSideEncryption string `yaml:"server_side_encryption" json:"server_side_encryption"`
+}
+
 // CommandConfig struct contains the configuration used to run a command.
 // Only used when AppConfig.Mode == AppModeCLI.
 type CommandConfig struct {
 	// available commands include:
 	//   - "help" to print help text
-	//   - "list-org-repos" to list repos in an org (for testing) // TODO
-	//   - "scan-org" to scan an org for PHI // TODO
-	//   - "scan-repos" to scan a repo for PHI // TODO
+	//   - "list-org-repos" to list the repos of an org that would be scanned
+	//   - "report" to render the stored results of the scanned repos
+	//   - "scan-org" to scan all repos of an org for PHI
+	//   - "scan-repos" to scan the configured repos for PHI
 	//   - "version" to print the app version
 	Run string `yaml:"run" json:"run"`
 }
@@ -113,6 +167,18 @@ type GitConfig struct {
 // GitHubConfig struct contains the configuration used to create clients for
 // interacting with GitHub APIs (outbound) and webhook events (inbound).
 type GitHubConfig struct {
+	// Action determines what the app does to an issue comment or pull request
+	// review comment in which PHI/PII is detected, in addition to labeling the
+	// issue or pull request. Must be one of the following:
+	//   - GitHubActionLabel to only apply the label (default);
+	//   - GitHubActionMinimize to hide the comment as off-topic;
+	//   - GitHubActionRedact to replace each detected entity in the comment
+	//     with a placeholder for the category of the entity, and then hide
+	//     the comment as off-topic. GitHub keeps the original body of the
+	//     comment in its edit history, which remains visible to anyone with
+	//     read access to the repository until the edit history is deleted
+	//     manually or the comment itself is deleted.
+	Action string `yaml:"action" json:"action"`
 	// App configuration is required for conversion to githubapp.Config struct,
 	// which is required when running the app in "server" mode. Running a secure
 	// installation of a GitHub app requires prior setup of the values used for
@@ -158,6 +224,24 @@ func (c *GitHubConfig) GetGitHubAppConfig() *githubapp.Config {
 // GitScanConfig struct contains the configuration used to setup a PHI scan
 // for some organization and/or set of repositories.
 type GitScanConfig struct {
+	// Branch is the branch (or any other ref) whose history is scanned when
+	// Mode is ScanModeBranch, or the tip of the history scanned when Mode is
+	// ScanModeSince. If empty, then HEAD is used.
+	Branch string `yaml:"branch" json:"branch"`
+
+	// ErrorPolicy determines whether the scan of a repository continues
+	// after a recoverable error, e.g. a file that failed to chunk:
+	//   - ErrorPolicyContinue to continue after any recoverable error;
+	//   - ErrorPolicyFailFast to stop the scan at the first error;
+	//   - "max-errors=N" to stop the scan after more than N recoverable
+	//     errors.
+	//
+	// Fatal errors, e.g. a repository that cannot be read, always stop the
+	// scan of the repository.
+	//
+	// ErrorPolicy default is defined in DefaultErrorPolicy const.
+	ErrorPolicy string `yaml:"error_policy" json:"error_policy"`
+
 	// Extensions is a list of file extensions to include in the scan, where
 	// each entry is a string in the format ".<ext>". If this list empty,
 	// then the DefaultScanFileExtensions list will be used.
@@ -173,13 +257,46 @@ type GitScanConfig struct {
 	// the Repositories list.
 	IgnoreRepositories []string `yaml:"ignore_repositories" json:"ignore_repositories"`
 
+	// IgnoreTopics is a list of GitHub repository topics, where any repository
+	// in the Organization that has at least one of the topics is excluded from
+	// the scan. Values in this list take precedence over values in Topics.
+	IgnoreTopics []string `yaml:"ignore_topics" json:"ignore_topics"`
+
+	// IncludeArchived controls whether archived repositories in the
+	// Organization are included in the scan. Default is false.
+	IncludeArchived bool `yaml:"include_archived" json:"include_archived"`
+
+	// IncludeForks controls whether forked repositories in the Organization
+	// are included in the scan. Default is false.
+	IncludeForks bool `yaml:"include_forks" json:"include_forks"`
+
 	// Limits config
 	Limits GitScanLimitsConfig `yaml:"limits" json:"limits"`
 
+	// Mode determines which commits of each repository are scanned:
+	//   - ScanModeAll to scan every commit object in the repository,
+	//     including unreachable commits;
+	//   - ScanModeBranch to scan the commits reachable from Branch;
+	//   - ScanModeHead to scan only the tree of the HEAD commit;
+	//   - ScanModeRange to scan the commits in Range;
+	//   - ScanModeSince to scan the commits reachable from Branch (or HEAD)
+	//     that are not reachable from Since.
+	//
+	// If empty, Mode is inferred from Branch, Range, and Since, and is
+	// otherwise ScanModeAll. Files that exist unchanged in the tree of the
+	// base commit of ScanModeRange or ScanModeSince are not scanned.
+	Mode string `yaml:"mode" json:"mode"`
+
 	// Organization is the URL of the GitHub organization to scan, where the
 	// app will query the GitHub API for a list of repositories to scan.
 	Organization string `yaml:"organization" json:"organization"`
 
+	// Range is the range of commits scanned when Mode is ScanModeRange, in
+	// the format "<from>..<to>", where the commits reachable from <to> but
+	// not from <from> are scanned. Both ends can be any revision, such as a
+	// commit hash, branch, or tag.
+	Range string `yaml:"range" json:"range"`
+
 	// Repositories is a list of GitHub repositories to scan, where each entry
 	// is a string in the format "<org>/<repo>" or "<user>/<repo>".
 	//
@@ -188,11 +305,195 @@ type GitScanConfig struct {
 	// listed in Repositories, minus any duplicates and minus any repositories
 	// listed in the IgnoreRepositories list.
 	Repositories []string `yaml:"repositories" json:"repositories"`
+
+	// Resume determines how the scan of a repository resumes from the
+	// checkpoint of a previous scan, when the checkpoint was created with a
+	// different config (e.g. Extensions or MaxRequestChunkSize), detector,
+	// or tip commit than the scan:
+	//   - ResumeDiscard to discard the checkpoint and start the scan over;
+	//   - ResumeFail to fail the scan of the repository;
+	//   - ResumeForce to resume from the checkpoint anyway.
+	//
+	// Resume default is defined in DefaultResume const.
+	Resume string `yaml:"resume" json:"resume"`
+
+	// Since is the revision (e.g. commit hash) of the last scanned commit
+	// when Mode is ScanModeSince.
+	Since string `yaml:"since" json:"since"`
+
+	// Topics is a list of GitHub repository topics, where only repositories
+	// in the Organization that have at least one of the topics are included
+	// in the scan. If this list is empty, then repositories are not filtered
+	// by topic.
+	Topics []string `yaml:"topics" json:"topics"`
+}
+
+// ParseScanRange() function parses a range of commits in the format
+// "<from>..<to>" and returns the revisions at each end of the range.
+func ParseScanRange(r string) (from string, to string, e error) {
+	parts := strings.SplitN(r, ScanRangeSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.HasPrefix(parts[1], ".") {
+		e = errors.New("invalid config value: git.scan.range = " + r)
+		return
+	}
+	from, to = parts[0], parts[1]
+	return
+}
+
+// ParseErrorPolicy() function parses an error policy in the format
+// "continue", "fail-fast", or "max-errors=N" and returns the maximum number
+// of recoverable errors after which the scan continues, where a negative
+// value means that the scan always continues.
+func ParseErrorPolicy(policy string) (max_errors int, e error) {
+	switch policy {
+	case ErrorPolicyContinue:
+		max_errors = -1
+		return
+	case ErrorPolicyFailFast:
+		max_errors = 0
+		return
+	}
+	name, value, found := strings.Cut(policy, ErrorPolicySeparator)
+	if found && name == ErrorPolicyMaxErrors {
+		n, err := strconv.Atoi(value)
+		if err == nil && n >= 0 {
+			max_errors = n
+			return
+		}
+	}
+	e = errors.New("invalid config value: git.scan.error_policy = " + policy)
+	return
+}
+
+// flagOverride() method overrides the scan mode config values with the values
+// of command line flags, where any flag that is set replaces the Mode, such
+// that the Mode is inferred again from the flags.
+func (c *GitScanConfig) flagOverride(branch string, head_only bool, r string, since string) {
+	if branch == "" && !head_only && r == "" && since == "" {
+		return
+	}
+	c.Branch = branch
+	c.Mode = ""
+	c.Range = r
+	c.Since = since
+	if head_only {
+		c.Mode = ScanModeHead
+	}
+}
+
+// verifyMode() method sets the scan Mode if it is empty and returns an error
+// if the Mode is invalid or the values required by the Mode are not set.
+func (c *GitScanConfig) verifyMode() (e error) {
+	if c.Mode == "" {
+		switch {
+		case c.Range != "":
+			c.Mode = ScanModeRange
+		case c.Since != "":
+			c.Mode = ScanModeSince
+		case c.Branch != "":
+			c.Mode = ScanModeBranch
+		default:
+			c.Mode = ScanModeAll
+		}
+	}
+	switch c.Mode {
+	case ScanModeAll, ScanModeHead:
+		if c.Branch != "" || c.Range != "" || c.Since != "" {
+			e = errors.New("git.scan.branch, git.scan.range, and git.scan.since cannot be used with git.scan.mode = " + c.Mode)
+		}
+	case ScanModeBranch:
+		if c.Branch == "" {
+			e = errors.New("missing required config value: git.scan.branch")
+		} else if c.Range != "" || c.Since != "" {
+			e = errors.New("git.scan.range and git.scan.since cannot be used with git.scan.mode = " + c.Mode)
+		}
+	case ScanModeRange:
+		if c.Branch != "" || c.Since != "" {
+			e = errors.New("git.scan.branch and git.scan.since cannot be used with git.scan.mode = " + c.Mode)
+		} else {
+			_, _, e = ParseScanRange(c.Range)
+		}
+	case ScanModeSince:
+		if c.Since == "" {
+			e = errors.New("missing required config value: git.scan.since")
+		} else if c.Range != "" {
+			e = errors.New("git.scan.range cannot be used with git.scan.mode = " + c.Mode)
+		}
+	default:
+		e = errors.New("invalid config value: git.scan.mode = " + c.Mode)
+	}
+	return
 }
 
 type GitScanLimitsConfig struct {
-	MaxRequestChunkSize    int `yaml:"max_request_chunk_size" json:"max_request_chunk_size"`
+	// MaxConcurrentRepositories is the maximum number of repositories that
+	// are cloned and scanned in parallel by the "scan-org", "scan-repos",
+	// and "scan-test" commands.
+	//
+	// MaxConcurrentRepositories default is defined in
+	// DefaultMaxConcurrentRepositories const.
+	MaxConcurrentRepositories int `yaml:"max_concurrent_repositories" json:"max_concurrent_repositories"`
+	MaxRequestChunkSize       int `yaml:"max_request_chunk_size" json:"max_request_chunk_size"`
+	// MaxRequestsOutstanding is the maximum number of requests that the
+	// scanner of a single repository sends without receiving a response,
+	// where the scan is blocked from generating more requests while the
+	// limit is reached.
+	//
+	// MaxRequestsOutstanding default is defined in
+	// DefaultMaxRequestsOutstanding const.
 	MaxRequestsOutstanding int `yaml:"max_requests_outstanding" json:"max_requests_outstanding"`
+	// MaxRequestsGlobal is the maximum number of requests outstanding at
+	// the detector, which is shared by all repositories scanned in parallel.
+	//
+	// MaxRequestsGlobal default is defined in DefaultMaxRequestsGlobal const.
+	MaxRequestsGlobal int `yaml:"max_requests_global" json:"max_requests_global"`
+}
+
+// ReportConfig struct contains the configuration used to render reports of
+// the results found by a scan, which are written after each "scan-repos"
+// command and by the "report" command.
+type ReportConfig struct {
+	// Formats is the list of report formats to write, where each entry must
+	// be one of ReportFormatCSV, ReportFormatJSON, ReportFormatMarkdown, or
+	// ReportFormatSARIF.
+	//
+	// Formats default is defined in DefaultReportFormats var.
+	Formats []string `yaml:"formats" json:"formats"`
+	// OutputDir is the directory where reports are written, with one file
+	// per scanned repository and format. If empty, then reports are written
+	// to the "reports" subdirectory of the git work dir.
+	OutputDir string `yaml:"output_dir" json:"output_dir"`
+	// Unmask controls whether the detected text is included in reports as-is
+	// (true), or masked (false). Default is false.
+	Unmask bool `yaml:"unmask" json:"unmask"`
+}
+
+// RulesConfig struct contains the configuration of the local rules detector,
+// which detects PHI/PII with regular expressions and checksums instead of
+// sending requests to a remote service.
+type RulesConfig struct {
+	// Patterns is a list of user-defined patterns, which are applied in
+	// addition to the built-in rules of the local rules detector.
+	Patterns []RulePatternConfig `yaml:"patterns" json:"patterns"`
+}
+
+// RulePatternConfig struct contains the configuration of a single
+// user-defined pattern for the local rules detector.
+type RulePatternConfig struct {
+	// Category is the category of the results that match the pattern.
+	Category string `yaml:"category" json:"category"`
+	// ConfidenceScore is the confidence score of the results that match the
+	// pattern, which must be a value between 0 and 1.
+	//
+	// ConfidenceScore default is defined in DefaultRuleConfidenceScore const.
+	ConfidenceScore float64 `yaml:"confidence_score" json:"confidence_score"`
+	// Pattern is a regular expression in the syntax accepted by the Go regexp
+	// package. If the pattern contains a capturing group, then only the text
+	// matched by the first group is included in the result.
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// Subcategory is the (optional) subcategory of the results that match
+	// the pattern.
+	Subcategory string `yaml:"subcategory" json:"subcategory"`
 }
 
 // ServerConfig struct contains the configuration used to start the HTTP server.
@@ -205,12 +506,50 @@ type ServerConfig struct {
 
 // Config struct is the top-level configuration object for the app.
 type Config struct {
-	App     AppConfig     `yaml:"app" json:"app"`
-	AzureAI AzureAIConfig `yaml:"azure_ai" json:"azure_ai"`
-	Command CommandConfig `yaml:"command" json:"command"`
-	Git     GitConfig     `yaml:"git" json:"git"`
-	GitHub  GitHubConfig  `yaml:"github" json:"github"`
-	Server  ServerConfig  `yaml:"server" json:"server"`
+	App        AppConfig        `yaml:"app" json:"app"`
+	AzureAI    AzureAIConfig    `yaml:"azure_ai" json:"azure_ai"`
+	Checkpoint CheckpointConfig `yaml:"checkpoint" json:"checkpoint"`
+	Command    CommandConfig    `yaml:"command" json:"command"`
+	// Detector is the name of the detection backend used to scan
+	// repositories for PHI/PII, which must be registered in the
+	// detector package, e.g. DetectorAzure or DetectorDryRun.
+	//
+	// Detector default is defined in DefaultDetector const.
+	Detector string       `yaml:"detector" json:"detector"`
+	Git      GitConfig    `yaml:"git" json:"git"`
+	GitHub   GitHubConfig `yaml:"github" json:"github"`
+	// ResultStore is the name of the backend used to store scan results:
+	//   - ResultStoreJSONL to append results to a JSON-lines file;
+	//   - ResultStoreMemory to keep results in memory until the app exits;
+	//   - ResultStoreSQLite to store results in a SQLite database file.
+	//
+	// File-based stores are created in the "results" subdirectory of the
+	// git work dir, with one file per scanned repository.
+	//
+	// The results of ResultStoreMemory are copied into each checkpoint of a
+	// scan, such that an interrupted scan can be resumed, where the text of
+	// each result (i.e. the detected PHI/PII) is left out of the checkpoint
+	// and is read again from the repository when the scan is resumed.
+	//
+	// ResultStore default is defined in DefaultResultStore const.
+	ResultStore string       `yaml:"result_store" json:"result_store"`
+	Report      ReportConfig `yaml:"report" json:"report"`
+	Rules       RulesConfig  `yaml:"rules" json:"rules"`
+	Server      ServerConfig `yaml:"server" json:"server"`
+	// TrackerStore is the name of the backend used to track the state of the
+	// commits, files, and requests of a scan:
+	//   - TrackerStoreMemory to keep every key in memory, where each
+	//     checkpoint of the scan contains a copy of every key;
+	//   - TrackerStoreSQLite to keep the keys in a SQLite database file,
+	//     where each checkpoint of the scan only writes the keys that changed
+	//     since the previous checkpoint, which scales to repositories with
+	//     millions of files.
+	//
+	// The SQLite database files are created in the "trackers" subdirectory of
+	// the git work dir, with one file per scanned repository.
+	//
+	// TrackerStore default is defined in DefaultTrackerStore const.
+	TrackerStore string `yaml:"tracker_store" json:"tracker_store"`
 }
 
 // NewDefaultConfig() function returns a new Config object with default values
@@ -245,25 +584,58 @@ func (c *Config) defaultConfig() {
 	// default to true, so we set it here and force the user to override
 	// with env var NOPHI_AZURE_AI_SHOW_STATS=false
 	c.AzureAI.ShowStats = DefaultAzureAIShowStats
+	if c.Checkpoint.S3.Region == "" {
+		c.Checkpoint.S3.Region = DefaultCheckpointS3Region
+	}
+	if c.Checkpoint.S3.ServerSideEncryption == "" {
+		c.Checkpoint.S3.ServerSideEncryption = DefaultCheckpointS3ServerSideEncryption
+	}
+	if c.Checkpoint.Store == "" {
+		c.Checkpoint.Store = DefaultCheckpointStore
+	}
 	if c.Command.Run == "" {
 		c.Command.Run = DefaultCommandRun
 	}
+	if c.Detector == "" {
+		c.Detector = DefaultDetector
+	}
+	if c.Git.Scan.ErrorPolicy == "" {
+		c.Git.Scan.ErrorPolicy = DefaultErrorPolicy
+	}
+	if c.Git.Scan.Resume == "" {
+		c.Git.Scan.Resume = DefaultResume
+	}
 	if len(c.Git.Scan.Extensions) == 0 {
 		c.Git.Scan.Extensions = DefaultScanFileExtensions
 	}
+	if c.Git.Scan.Limits.MaxConcurrentRepositories == 0 {
+		c.Git.Scan.Limits.MaxConcurrentRepositories = DefaultMaxConcurrentRepositories
+	}
 	if c.Git.Scan.Limits.MaxRequestChunkSize == 0 {
 		c.Git.Scan.Limits.MaxRequestChunkSize = DefaultMaxRequestChunkSize
 	}
 	if c.Git.Scan.Limits.MaxRequestsOutstanding == 0 {
 		c.Git.Scan.Limits.MaxRequestsOutstanding = DefaultMaxRequestsOutstanding
 	}
+	if c.Git.Scan.Limits.MaxRequestsGlobal == 0 {
+		c.Git.Scan.Limits.MaxRequestsGlobal = DefaultMaxRequestsGlobal
+	}
 	if c.Git.WorkDir == "" {
 		c.Git.WorkDir = DefaultCommandWorkDir
 	}
 	// set defaults for optional c.GitHub config values
+	if c.GitHub.Action == "" {
+		c.GitHub.Action = DefaultGitHubAction
+	}
 	if c.GitHub.V3APIURL == "" {
 		c.GitHub.V3APIURL = DefaultGitHubV3APIURL
 	}
+	if len(c.Report.Formats) == 0 {
+		c.Report.Formats = DefaultReportFormats
+	}
+	if c.ResultStore == "" {
+		c.ResultStore = DefaultResultStore
+	}
 	// set defaults for optional c.Server config values
 	if c.Server.Address == "" {
 		c.Server.Address = DefaultServerAddress
@@ -274,6 +646,9 @@ func (c *Config) defaultConfig() {
 	if c.Server.RateLimit == 0 {
 		c.Server.RateLimit = DefaultRateLimit
 	}
+	if c.TrackerStore == "" {
+		c.TrackerStore = DefaultTrackerStore
+	}
 }
 
 // verifyConfig() method returns an error if a required value has not
@@ -296,17 +671,92 @@ func (c *Config) verifyConfig() (e error) {
 // verifyConfigCLI() method verifies required config values when running the app
 // in "cli" mode.
 func (c *Config) verifyConfigCLI() (e error) {
-	// check the c.AzureAI config values
-	if c.AzureAI.Service == "" {
-		e = errors.New("missing required config value: azure_ai.service")
+	// check the c.AzureAI config values, which are not required when using
+	// a detector that does not send requests to the Azure AI Language service
+	switch c.Detector {
+	case DetectorDryRun, DetectorRegex:
+		break
+	default:
+		if c.AzureAI.Service == "" {
+			e = errors.New("missing required config value: azure_ai.service")
+			return
+		}
+		if c.AzureAI.AuthKey == "" {
+			e = errors.New("missing required config value: azure_ai.auth_key")
+			return
+		}
+	}
+	if c.AzureAI.ConfidenceThreshold == 0 {
+		c.AzureAI.ConfidenceThreshold = DefaultConfidenceThreshold
+	}
+
+	// check the c.ResultStore config value
+	switch c.ResultStore {
+	case ResultStoreJSONL, ResultStoreMemory, ResultStoreSQLite:
+		break
+	default:
+		e = errors.New("invalid config value: result_store = " + c.ResultStore)
 		return
 	}
-	if c.AzureAI.AuthKey == "" {
-		e = errors.New("missing required config value: azure_ai.auth_key")
+
+	// check the c.TrackerStore config value
+	switch c.TrackerStore {
+	case TrackerStoreMemory, TrackerStoreSQLite:
+		break
+	default:
+		e = errors.New("invalid config value: tracker_store = " + c.TrackerStore)
 		return
 	}
-	if c.AzureAI.ConfidenceThreshold == 0 {
-		c.AzureAI.ConfidenceThreshold = DefaultConfidenceThreshold
+
+	// check the c.Checkpoint config values
+	switch c.Checkpoint.Store {
+	case CheckpointStoreFile, CheckpointStoreSQLite:
+		break
+	case CheckpointStoreS3:
+		if c.Checkpoint.S3.Bucket == "" {
+			e = errors.New("missing required config value: checkpoint.s3.bucket")
+			return
+		}
+		switch c.Checkpoint.S3.ServerSideEncryption {
+		case CheckpointS3SSEAES256, CheckpointS3SSEKMS:
+			break
+		default:
+			e = errors.New("invalid config value: checkpoint.s3.server_side_encryption = " + c.Checkpoint.S3.ServerSideEncryption)
+			return
+		}
+	default:
+		e = errors.New("invalid config value: checkpoint.store = " + c.Checkpoint.Store)
+		return
+	}
+
+	// check the c.Report.Formats config values
+	for _, format := range c.Report.Formats {
+		switch format {
+		case ReportFormatCSV, ReportFormatJSON, ReportFormatMarkdown, ReportFormatSARIF:
+			continue
+		default:
+			e = errors.New("invalid config value: report.formats = " + format)
+			return
+		}
+	}
+
+	// check the c.Git.Scan.Mode config value
+	if e = c.Git.Scan.verifyMode(); e != nil {
+		return
+	}
+
+	// check the c.Git.Scan.ErrorPolicy config value
+	if _, e = ParseErrorPolicy(c.Git.Scan.ErrorPolicy); e != nil {
+		return
+	}
+
+	// check the c.Git.Scan.Resume config value
+	switch c.Git.Scan.Resume {
+	case ResumeDiscard, ResumeFail, ResumeForce:
+		break
+	default:
+		e = errors.New("invalid config value: git.scan.resume = " + c.Git.Scan.Resume)
+		return
 	}
 
 	// check the c.Git.Auth.Token config value
@@ -335,6 +785,13 @@ func (c *Config) verifyConfigServer() (e error) {
 	}
 
 	// check the c.GitHub config values
+	switch c.GitHub.Action {
+	case GitHubActionLabel, GitHubActionMinimize, GitHubActionRedact:
+		break
+	default:
+		e = errors.New("invalid config value: github.action = " + c.GitHub.Action)
+		return
+	}
 	if c.GitHub.App.IntegrationID == 0 {
 		e = errors.New("missing required config value: github.app.integration_id")
 		return
@@ -377,6 +834,12 @@ func readConfigFile(path string) (*Config, error) {
 func ParseConfig() (*Config, *zerolog.Logger, error) {
 	// define flags
 	configPath := flag.String("config", "", "local relative path to the config file")
+	scanBranch := flag.String("branch", "", "scan the commits reachable from the branch (or other ref)")
+	scanErrorPolicy := flag.String("error-policy", "", "continue, fail-fast, or max-errors=N after recoverable scan errors")
+	scanHeadOnly := flag.Bool("head-only", false, "scan only the tree of the HEAD commit")
+	scanRange := flag.String("range", "", "scan the commits in the range <from>..<to>")
+	scanResume := flag.String("resume", "", "force, discard, or fail when the checkpoint of a previous scan does not match the scan")
+	scanSince := flag.String("since", "", "scan the commits that are not reachable from the commit")
 
 	// parse flags
 	flag.Parse()
@@ -402,6 +865,18 @@ func ParseConfig() (*Config, *zerolog.Logger, error) {
 		return c, nil, err
 	}
 
+	// override the scan mode config values with flags
+	c.Git.Scan.flagOverride(*scanBranch, *scanHeadOnly, *scanRange, *scanSince)
+
+	// override the scan error policy config value with the flag
+	if *scanErrorPolicy != "" {
+		c.Git.Scan.ErrorPolicy = *scanErrorPolicy
+	}
+	// override the scan resume config value with the flag
+	if *scanResume != "" {
+		c.Git.Scan.Resume = *scanResume
+	}
+
 	// verify required config values are set (i.e. not empty)
 	if err := c.verifyConfig(); err != nil {
 		return c, nil, err
@@ -18,15 +18,30 @@ func TestNewDefaultConfig(t *testing.T) {
 	assert.Equal(t, DefaultAppLogLevel, config.App.Log.Level)
 	assert.Equal(t, DefaultAppUserAgent, config.App.UserAgent)
 	assert.Equal(t, DefaultAzureAIShowStats, config.AzureAI.ShowStats)
+	assert.Equal(t, "", config.Checkpoint.Path)
+	assert.Equal(t, DefaultCheckpointS3Region, config.Checkpoint.S3.Region)
+	assert.Equal(t, DefaultCheckpointS3ServerSideEncryption, config.Checkpoint.S3.ServerSideEncryption)
+	assert.Equal(t, DefaultCheckpointStore, config.Checkpoint.Store)
 	assert.Equal(t, DefaultCommandRun, config.Command.Run)
+	assert.Equal(t, DefaultDetector, config.Detector)
+	assert.Equal(t, DefaultErrorPolicy, config.Git.Scan.ErrorPolicy)
+	assert.Equal(t, DefaultResume, config.Git.Scan.Resume)
 	assert.Equal(t, DefaultScanFileExtensions, config.Git.Scan.Extensions)
+	assert.Equal(t, DefaultMaxConcurrentRepositories, config.Git.Scan.Limits.MaxConcurrentRepositories)
 	assert.Equal(t, DefaultMaxRequestChunkSize, config.Git.Scan.Limits.MaxRequestChunkSize)
+	assert.Equal(t, DefaultMaxRequestsGlobal, config.Git.Scan.Limits.MaxRequestsGlobal)
 	assert.Equal(t, DefaultMaxRequestsOutstanding, config.Git.Scan.Limits.MaxRequestsOutstanding)
 	assert.Equal(t, DefaultCommandWorkDir, config.Git.WorkDir)
+	assert.Equal(t, DefaultGitHubAction, config.GitHub.Action)
 	assert.Equal(t, DefaultGitHubV3APIURL, config.GitHub.V3APIURL)
 	assert.Equal(t, DefaultServerAddress, config.Server.Address)
 	assert.Equal(t, DefaultServerPort, config.Server.Port)
 	assert.Equal(t, DefaultRateLimit, config.Server.RateLimit)
+	assert.Equal(t, DefaultReportFormats, config.Report.Formats)
+	assert.Equal(t, "", config.Report.OutputDir)
+	assert.Exactly(t, false, config.Report.Unmask)
+	assert.Equal(t, DefaultResultStore, config.ResultStore)
+	assert.Equal(t, DefaultTrackerStore, config.TrackerStore)
 	assert.Exactly(t, false, config.AzureAI.DryRun)
 
 	// assert that required values are not set by default
@@ -38,3 +53,92 @@ func TestNewDefaultConfig(t *testing.T) {
 	assert.Equal(t, "", config.GitHub.App.PrivateKey)
 	assert.Equal(t, "", config.GitHub.App.WebhookSecret)
 }
+
+func TestGitScanConfig_verifyMode(t *testing.T) {
+	tests := []struct {
+		config       GitScanConfig
+		err_expected bool
+		mode         string
+		name         string
+	}{
+		{config: GitScanConfig{}, mode: ScanModeAll, name: "default"},
+		{config: GitScanConfig{Branch: "main"}, mode: ScanModeBranch, name: "infer_branch"},
+		{config: GitScanConfig{Range: "a..b"}, mode: ScanModeRange, name: "infer_range"},
+		{config: GitScanConfig{Since: "a"}, mode: ScanModeSince, name: "infer_since"},
+		{config: GitScanConfig{Branch: "main", Since: "a"}, mode: ScanModeSince, name: "infer_since_branch"},
+		{config: GitScanConfig{Mode: ScanModeHead}, mode: ScanModeHead, name: "head"},
+		{config: GitScanConfig{Mode: ScanModeHead, Branch: "main"}, err_expected: true, name: "head_branch"},
+		{config: GitScanConfig{Mode: ScanModeBranch}, err_expected: true, name: "branch_missing"},
+		{config: GitScanConfig{Mode: ScanModeSince}, err_expected: true, name: "since_missing"},
+		{config: GitScanConfig{Range: "a..b", Since: "a"}, err_expected: true, name: "range_since"},
+		{config: GitScanConfig{Range: "a...b"}, err_expected: true, name: "range_symmetric"},
+		{config: GitScanConfig{Range: "a"}, err_expected: true, name: "range_invalid"},
+		{config: GitScanConfig{Mode: "invalid"}, err_expected: true, name: "invalid"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.verifyMode()
+			if test.err_expected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.mode, test.config.Mode)
+		})
+	}
+}
+
+func TestGitScanConfig_flagOverride(t *testing.T) {
+	config := GitScanConfig{Branch: "main", Mode: ScanModeBranch}
+	config.flagOverride("", false, "", "")
+	assert.Equal(t, GitScanConfig{Branch: "main", Mode: ScanModeBranch}, config)
+
+	config.flagOverride("", false, "v1..v2", "")
+	assert.NoError(t, config.verifyMode())
+	assert.Equal(t, GitScanConfig{Mode: ScanModeRange, Range: "v1..v2"}, config)
+
+	config.flagOverride("", true, "", "")
+	assert.NoError(t, config.verifyMode())
+	assert.Equal(t, GitScanConfig{Mode: ScanModeHead}, config)
+}
+
+func TestParseScanRange(t *testing.T) {
+	from, to, err := ParseScanRange("main..feature/x")
+	assert.NoError(t, err)
+	assert.Equal(t, "main", from)
+	assert.Equal(t, "feature/x", to)
+
+	_, _, err = ParseScanRange("..b")
+	assert.Error(t, err)
+	_, _, err = ParseScanRange("a..")
+	assert.Error(t, err)
+}
+
+func TestParseErrorPolicy(t *testing.T) {
+	tests := []struct {
+		expected     int
+		expected_err bool
+		policy       string
+	}{
+		{expected: -1, policy: ErrorPolicyContinue},
+		{expected: 0, policy: ErrorPolicyFailFast},
+		{expected: 0, policy: "max-errors=0"},
+		{expected: 25, policy: "max-errors=25"},
+		{expected_err: true, policy: ""},
+		{expected_err: true, policy: "max-errors"},
+		{expected_err: true, policy: "max-errors=-1"},
+		{expected_err: true, policy: "max-errors=many"},
+		{expected_err: true, policy: "min-errors=1"},
+	}
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			max_errors, err := ParseErrorPolicy(test.policy)
+			if test.expected_err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, max_errors)
+		})
+	}
+}
@@ -6,8 +6,16 @@ const AppModeCLI string = "cli"
 const AppModeServer string = "server"
 const AppVersion string = "1.0.0"
 
+const CheckpointS3SSEAES256 string = "AES256"
+const CheckpointS3SSEKMS string = "aws:kms"
+const CheckpointStoreFile string = "file"
+const CheckpointStoreS3 string = "s3"
+const CheckpointStoreSQLite string = "sqlite"
+
 const CommandRunHelp string = "help"
 const CommandRunListOrgRepos string = "list-org-repos"
+const CommandRunReport string = "report"
+const CommandRunRetryFailed string = "retry-failed"
 const CommandRunScanOrg string = "scan-org"
 const CommandRunScanRepos string = "scan-repos"
 const CommandRunScanTest string = "scan-test"
@@ -18,23 +26,82 @@ const DefaultAppMode string = AppModeServer
 const DefaultAppName string = "no-phi-ai"
 const DefaultAppUserAgent string = DefaultAppName + "/" + AppVersion
 const DefaultAzureAIShowStats bool = true
+const DefaultCheckpointS3Region string = "us-east-1"
+const DefaultCheckpointS3ServerSideEncryption string = CheckpointS3SSEAES256
+const DefaultCheckpointStore string = CheckpointStoreFile
 const DefaultClientTimeout time.Duration = 3 * time.Second
 const DefaultCommandRun string = CommandRunHelp
 const DefaultCommandWorkDir string = "/tmp/" + DefaultAppName
 const DefaultConfidenceThreshold float64 = 0.6
+const DefaultDetector string = DetectorAzure
+const DefaultErrorPolicy string = ErrorPolicyContinue
+const DefaultGitHubAction string = GitHubActionLabel
 const DefaultGitHubV3APIURL string = "https://api.github.com"
+const DefaultMaxConcurrentRepositories int = 4
 const DefaultMaxRequestChunkSize int = 5000
+const DefaultMaxRequestsGlobal int = 200
 const DefaultMaxRequestsOutstanding int = 100
 const DefaultRateLimit float64 = 1000.0
+const DefaultResultStore string = ResultStoreJSONL
+const DefaultResume string = ResumeFail
+const DefaultRuleConfidenceScore float64 = 0.9
 const DefaultServerAddress string = "127.0.0.1"
 const DefaultServerPort int = 8080
+const DefaultTrackerStore string = TrackerStoreMemory
+
+const DetectorAzure string = "azure"
+const DetectorComposite string = "composite"
+const DetectorDryRun string = "dryrun"
+const DetectorRegex string = "regex"
+
+const ErrorPolicyContinue string = "continue"
+const ErrorPolicyFailFast string = "fail-fast"
+const ErrorPolicyMaxErrors string = "max-errors"
+const ErrorPolicySeparator string = "="
+
+const GitHubActionLabel string = "label"
+const GitHubActionMinimize string = "minimize"
+const GitHubActionRedact string = "redact"
+
+const ReportFormatCSV string = "csv"
+const ReportFormatJSON string = "json"
+const ReportFormatMarkdown string = "markdown"
+const ReportFormatSARIF string = "sarif"
+
+const ResultStoreJSONL string = "jsonl"
+const ResultStoreMemory string = "memory"
+const ResultStoreSQLite string = "sqlite"
+
+const ResumeDiscard string = "discard"
+const ResumeFail string = "fail"
+const ResumeForce string = "force"
 
 const RouteGroupGHv1 string = "/api/v1/github"
 const RouteWebhook string = "/hook"
 
+const ScanModeAll string = "all"
+const ScanModeBranch string = "branch"
+const ScanModeHead string = "head"
+const ScanModeRange string = "range"
+const ScanModeSince string = "since"
+const ScanRangeSeparator string = ".."
+
+const TrackerStoreMemory string = "memory"
+const TrackerStoreSQLite string = "sqlite"
+
 const WorkDirCheckpoints string = "checkpoints"
+const WorkDirFailed string = "failed"
+const WorkDirReports string = "reports"
 const WorkDirRepositories string = "repositories"
 const WorkDirResults string = "results"
+const WorkDirTrackers string = "trackers"
+
+var DefaultReportFormats = []string{
+	ReportFormatCSV,
+	ReportFormatJSON,
+	ReportFormatMarkdown,
+	ReportFormatSARIF,
+}
 
 var DefaultScanFileExtensions = []string{
 	".csv",
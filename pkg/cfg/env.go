@@ -15,6 +15,9 @@ const NOPHI_AZURE_AI_AUTH_KEY string = "NOPHI_AZURE_AI_AUTH_KEY"
 const NOPHI_AZURE_AI_DRY_RUN string = "NOPHI_AZURE_AI_DRY_RUN"
 const NOPHI_AZURE_AI_SERVICE string = "NOPHI_AZURE_AI_SERVICE"
 const NOPHI_AZURE_AI_SHOW_STATS string = "NOPHI_AZURE_AI_SHOW_STATS"
+const NOPHI_CHECKPOINT_S3_ACCESS_KEY_ID string = "NOPHI_CHECKPOINT_S3_ACCESS_KEY_ID"
+const NOPHI_CHECKPOINT_S3_SECRET_ACCESS_KEY string = "NOPHI_CHECKPOINT_S3_SECRET_ACCESS_KEY"
+const NOPHI_CHECKPOINT_STORE string = "NOPHI_CHECKPOINT_STORE"
 const NOPHI_COMMAND_RUN = "NOPHI_COMMAND_RUN"
 const NOPHI_CONFIG_PATH string = "NOPHI_CONFIG_PATH"
 const NOPHI_GH_INTEGRATION_ID string = "NOPHI_GH_INTEGRATION_ID"
@@ -22,11 +25,17 @@ const NOPHI_GH_PRIVATE_KEY string = "NOPHI_GH_PRIVATE_KEY"
 const NOPHI_GH_V3APIURL string = "NOPHI_GH_V3APIURL"
 const NOPHI_GH_V4APIURL string = "NOPHI_GH_V4APIURL"
 const NOPHI_GH_WEBHOOK_SECRET = "NOPHI_GH_WEBHOOK_SECRET"
+const NOPHI_GIT_SCAN_ERROR_POLICY = "NOPHI_GIT_SCAN_ERROR_POLICY"
 const NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE = "NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE"
+const NOPHI_GIT_SCAN_RESUME = "NOPHI_GIT_SCAN_RESUME"
 const NOPHI_GIT_WORKDIR = "NOPHI_GIT_WORKDIR"
+const NOPHI_MAX_CONCURRENT_REPOSITORIES = "NOPHI_MAX_CONCURRENT_REPOSITORIES"
+const NOPHI_MAX_REQUESTS_GLOBAL = "NOPHI_MAX_REQUESTS_GLOBAL"
 const NOPHI_MAX_REQUESTS_OUTSTANDING = "NOPHI_MAX_REQUESTS_OUTSTANDING"
+const NOPHI_REPORT_UNMASK string = "NOPHI_REPORT_UNMASK"
 const NOPHI_SERVER_ADDRESS string = "NOPHI_SERVER_ADDRESS"
 const NOPHI_SERVER_PORT string = "NOPHI_SERVER_PORT"
+const NOPHI_TRACKER_STORE string = "NOPHI_TRACKER_STORE"
 
 // GetAppEnvVars() method returns a list of environment variables used by the app.
 func GetAppEnvVars() []string {
@@ -38,6 +47,9 @@ func GetAppEnvVars() []string {
 		NOPHI_AZURE_AI_DRY_RUN,
 		NOPHI_AZURE_AI_SERVICE,
 		NOPHI_AZURE_AI_SHOW_STATS,
+		NOPHI_CHECKPOINT_S3_ACCESS_KEY_ID,
+		NOPHI_CHECKPOINT_S3_SECRET_ACCESS_KEY,
+		NOPHI_CHECKPOINT_STORE,
 		NOPHI_COMMAND_RUN,
 		NOPHI_CONFIG_PATH,
 		NOPHI_GH_INTEGRATION_ID,
@@ -45,11 +57,17 @@ func GetAppEnvVars() []string {
 		NOPHI_GH_V3APIURL,
 		NOPHI_GH_V4APIURL,
 		NOPHI_GH_WEBHOOK_SECRET,
+		NOPHI_GIT_SCAN_ERROR_POLICY,
 		NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE,
+		NOPHI_GIT_SCAN_RESUME,
 		NOPHI_GIT_WORKDIR,
+		NOPHI_MAX_CONCURRENT_REPOSITORIES,
+		NOPHI_MAX_REQUESTS_GLOBAL,
 		NOPHI_MAX_REQUESTS_OUTSTANDING,
+		NOPHI_REPORT_UNMASK,
 		NOPHI_SERVER_ADDRESS,
 		NOPHI_SERVER_PORT,
+		NOPHI_TRACKER_STORE,
 	}
 }
 
@@ -84,14 +102,41 @@ func (c *Config) envOverride() error {
 		}
 		c.AzureAI.ShowStats = azShowStatsBool
 	}
+	if checkpointS3AccessKeyID := os.Getenv(NOPHI_CHECKPOINT_S3_ACCESS_KEY_ID); checkpointS3AccessKeyID != "" {
+		c.Checkpoint.S3.AccessKeyID = checkpointS3AccessKeyID
+	}
+	if checkpointS3SecretAccessKey := os.Getenv(NOPHI_CHECKPOINT_S3_SECRET_ACCESS_KEY); checkpointS3SecretAccessKey != "" {
+		c.Checkpoint.S3.SecretAccessKey = checkpointS3SecretAccessKey
+	}
+	if checkpointStore := os.Getenv(NOPHI_CHECKPOINT_STORE); checkpointStore != "" {
+		c.Checkpoint.Store = checkpointStore
+	}
 	if commandRun := os.Getenv(NOPHI_COMMAND_RUN); commandRun != "" {
 		c.Command.Run = commandRun
 	}
+	if maxConcurrentRepositories := os.Getenv(NOPHI_MAX_CONCURRENT_REPOSITORIES); maxConcurrentRepositories != "" {
+		maxConcurrentRepositoriesInt, err := strconv.Atoi(maxConcurrentRepositories)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_MAX_CONCURRENT_REPOSITORIES env var")
+		}
+		c.Git.Scan.Limits.MaxConcurrentRepositories = maxConcurrentRepositoriesInt
+	}
+	if maxRequestsGlobal := os.Getenv(NOPHI_MAX_REQUESTS_GLOBAL); maxRequestsGlobal != "" {
+		maxRequestsGlobalInt, err := strconv.Atoi(maxRequestsGlobal)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_MAX_REQUESTS_GLOBAL env var")
+		}
+		c.Git.Scan.Limits.MaxRequestsGlobal = maxRequestsGlobalInt
+	}
 	if maxRequestsOutstanding := os.Getenv(NOPHI_MAX_REQUESTS_OUTSTANDING); maxRequestsOutstanding != "" {
 		maxRequestsOutstandingInt, err := strconv.Atoi(maxRequestsOutstanding)
-		if err == nil {
-			c.Git.Scan.Limits.MaxRequestsOutstanding = maxRequestsOutstandingInt
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_MAX_REQUESTS_OUTSTANDING env var")
 		}
+		c.Git.Scan.Limits.MaxRequestsOutstanding = maxRequestsOutstandingInt
+	}
+	if errorPolicy := os.Getenv(NOPHI_GIT_SCAN_ERROR_POLICY); errorPolicy != "" {
+		c.Git.Scan.ErrorPolicy = errorPolicy
 	}
 	if chunkSize := os.Getenv(NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE); chunkSize != "" {
 		chunkSizeInt, err := strconv.Atoi(chunkSize)
@@ -100,6 +145,9 @@ func (c *Config) envOverride() error {
 		}
 		c.Git.Scan.Limits.MaxRequestChunkSize = chunkSizeInt
 	}
+	if resume := os.Getenv(NOPHI_GIT_SCAN_RESUME); resume != "" {
+		c.Git.Scan.Resume = resume
+	}
 	if gitWorkDir := os.Getenv(NOPHI_GIT_WORKDIR); gitWorkDir != "" {
 		c.Git.WorkDir = gitWorkDir
 	}
@@ -122,6 +170,13 @@ func (c *Config) envOverride() error {
 	if V4APIURL := os.Getenv(NOPHI_GH_V4APIURL); V4APIURL != "" {
 		c.GitHub.V4APIURL = V4APIURL
 	}
+	if reportUnmask := os.Getenv(NOPHI_REPORT_UNMASK); reportUnmask != "" {
+		reportUnmaskBool, err := strconv.ParseBool(reportUnmask)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_REPORT_UNMASK env var")
+		}
+		c.Report.Unmask = reportUnmaskBool
+	}
 	if serverAddress := os.Getenv(NOPHI_SERVER_ADDRESS); serverAddress != "" {
 		c.Server.Address = serverAddress
 	}
@@ -132,6 +187,9 @@ func (c *Config) envOverride() error {
 		}
 		c.Server.Port = serverPortInt
 	}
+	if trackerStore := os.Getenv(NOPHI_TRACKER_STORE); trackerStore != "" {
+		c.TrackerStore = trackerStore
+	}
 
 	return nil
 }
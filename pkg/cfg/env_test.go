@@ -13,6 +13,9 @@ func TestGetAppEnvVars(t *testing.T) {
 		NOPHI_AZURE_AI_DRY_RUN,
 		NOPHI_AZURE_AI_SERVICE,
 		NOPHI_AZURE_AI_SHOW_STATS,
+		NOPHI_CHECKPOINT_S3_ACCESS_KEY_ID,
+		NOPHI_CHECKPOINT_S3_SECRET_ACCESS_KEY,
+		NOPHI_CHECKPOINT_STORE,
 		NOPHI_COMMAND_RUN,
 		NOPHI_CONFIG_PATH,
 		NOPHI_GH_INTEGRATION_ID,
@@ -20,11 +23,17 @@ func TestGetAppEnvVars(t *testing.T) {
 		NOPHI_GH_V3APIURL,
 		NOPHI_GH_V4APIURL,
 		NOPHI_GH_WEBHOOK_SECRET,
+		NOPHI_GIT_SCAN_ERROR_POLICY,
 		NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE,
+		NOPHI_GIT_SCAN_RESUME,
 		NOPHI_GIT_WORKDIR,
+		NOPHI_MAX_CONCURRENT_REPOSITORIES,
+		NOPHI_MAX_REQUESTS_GLOBAL,
 		NOPHI_MAX_REQUESTS_OUTSTANDING,
+		NOPHI_REPORT_UNMASK,
 		NOPHI_SERVER_ADDRESS,
 		NOPHI_SERVER_PORT,
+		NOPHI_TRACKER_STORE,
 	}
 
 	result := GetAppEnvVars()
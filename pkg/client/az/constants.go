@@ -5,6 +5,14 @@ import "time"
 const DefaultDetectionApi string = "language/:analyze-text?api-version=2022-05-01"
 const DefaultLanguage string = "en"
 const DocumentCharacterLimit int = 5000
+const ErrMsgDetectDocumentsFailed string = "failed to detect entities for documents"
+const ErrMsgDetectRequestsFailed string = "failed to detect entities for requests"
+const ErrorCodeMissingDocumentResponse string = "MissingDocumentResponse"
+const ErrorCodeRequestFailed string = "RequestFailed"
 const RequestDocumentLimit int = 5
+const RequestRetryBackoff time.Duration = time.Millisecond * 500
+const RequestRetryBackoffMax time.Duration = time.Second * 30
+const RequestRetryLimit int = 5
 const RequestTimerDuration time.Duration = time.Second * 5
 const ShowStatsParam string = "&showStats=true"
+const StringIndexTypeUnicodeCodePoint string = "UnicodeCodePoint"
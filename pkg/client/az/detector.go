@@ -2,8 +2,10 @@ package az
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
@@ -28,13 +30,15 @@ func NewAzAiLanguagePhiDetector(ai *EntityDetectionAI) *AzAiLanguagePhiDetector
 	return &AzAiLanguagePhiDetector{ai: ai}
 }
 
-// Run() method listens for requests, performs no operations other than
-// translating the rrr.Request to a new rrr.Response, and sends responses
-// using the provided channels.
+// Run() method listens for requests, sends the text of the requests as
+// documents to the Azure AI Language service in batches of up to
+// RequestDocumentLimit documents, and sends responses with the detected
+// entities using the provided channels. A batch is sent when it is full or
+// when no request has been received for RequestTimerDuration.
 //
-// Useful for testing the performance of the Scanner in generating requests
-// and processing responses for chunks of text contained within the files and
-// commits of the scanned repositories.
+// Failed requests to the service are retried with backoff, and an error
+// response is sent for each request whose document could not be processed,
+// such that every request receives a response.
 func (detector *AzAiLanguagePhiDetector) Run(
 	ctx context.Context,
 	chan_requests_in <-chan rrr.Request,
@@ -43,8 +47,8 @@ func (detector *AzAiLanguagePhiDetector) Run(
 	defer close(chan_responses_out)
 
 	logger := zerolog.Ctx(ctx)
-	logger.Info().Msg("started dry run detector")
-	defer logger.Info().Msg("finished dry run detector")
+	logger.Info().Msg("started Azure AI Language detector")
+	defer logger.Info().Msg("finished Azure AI Language detector")
 
 	document_requests := make([]DocumentRequestWrapper, 0)
 
@@ -66,31 +70,28 @@ func (detector *AzAiLanguagePhiDetector) Run(
 		// create a new PiiEntityRecognitionRequest to send to AZ API
 		pii_request := NewPiiEntityRecognitionRequest(documents)
 		// send the request to AZ API and await the results
-		pii_results, err := detector.ai.requestAiResponse(ctx, pii_request)
-		if err != nil {
-			// send an error via the response channel
-			// TODO
+		pii_results, pii_err := detector.ai.requestAiResponse(ctx, pii_request)
+		if pii_err != nil {
+			logger.Error().Err(pii_err).Msgf(
+				"failed to detect entities for %d documents",
+				len(document_requests_pending),
+			)
 		}
-		// split the pii_results into individual responses
-		for _, document_response := range pii_results.Results.Documents {
-			// find the original request for the document response
-			var original_request *rrr.Request
-			for _, document_request := range document_requests_pending {
-				if document_request.Request.ID == document_response.ID {
-					original_request = document_request.Request
-					// convert and send the response to output channel
-					chan_responses_out <- convertDocumentResponseToResponse(
-						detector.ai.endpoint,
-						original_request,
-						&document_response,
-					)
-					break
-				}
+		// split the pii_results into individual responses, including an error
+		// response for each failed or orphaned request
+		responses := convertResultsToResponses(
+			detector.ai.endpoint,
+			document_requests_pending,
+			pii_results,
+			pii_err,
+		)
+		for _, response := range responses {
+			select {
+			case <-ctx.Done():
+				return
+			case chan_responses_out <- response:
 			}
 		}
-		// handle any remnants from document_requests_pending
-		// by sending an error response for each (orphaned) request
-		// TODO
 	}
 
 	timer := time.NewTimer(RequestTimerDuration)
@@ -98,17 +99,30 @@ func (detector *AzAiLanguagePhiDetector) Run(
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Warn().Msg("stopping dry run detector : context done")
+			logger.Warn().Msg("stopping Azure AI Language detector : context done")
 			// exit the function when the context is done
 			return
-		case request := <-chan_requests_in:
+		case request, ok := <-chan_requests_in:
+			if !ok {
+				// process any remaining requests before exiting when the
+				// input channel is closed
+				for len(document_requests) > 0 {
+					processDocumentRequests()
+				}
+				return
+			}
 			document_requests = append(document_requests, wrapDocumentRequest(&request))
 			if len(document_requests) >= RequestDocumentLimit {
 				processDocumentRequests()
 			}
-			// stop and reset the timer
+			// stop and reset the timer, where the channel of the timer is
+			// drained without blocking because the timer may have already
+			// fired and been received while the detector was idle
 			if !timer.Stop() {
-				<-timer.C
+				select {
+				case <-timer.C:
+				default:
+				}
 			}
 			timer.Reset(RequestTimerDuration)
 		case <-timer.C:
@@ -118,6 +132,121 @@ func (detector *AzAiLanguagePhiDetector) Run(
 	}
 }
 
+// DetectRequests() method sends the input rrr.Requests to the Azure AI Language
+// service API in batches of up to RequestDocumentLimit documents, then returns
+// one rrr.Response for each rrr.Request. Unlike the Run() method,
+// DetectRequests() blocks until all batches have been processed, which makes
+// it suitable for handling (small) webhook events. Returns a non-nil error if
+// any document failed or received no response, such that the caller does not
+// report the text of the failed documents as free of PHI/PII.
+func (ai *EntityDetectionAI) DetectRequests(ctx context.Context, requests []rrr.Request) (responses []rrr.Response, e error) {
+	responses = make([]rrr.Response, 0, len(requests))
+
+	var failed []string
+	for start := 0; start < len(requests); start += RequestDocumentLimit {
+		end := start + RequestDocumentLimit
+		if end > len(requests) {
+			end = len(requests)
+		}
+		batch := requests[start:end]
+
+		document_requests := make([]DocumentRequestWrapper, 0, len(batch))
+		documents := make([]Document, 0, len(batch))
+		for i := range batch {
+			document_request := wrapDocumentRequest(&batch[i])
+			document_requests = append(document_requests, document_request)
+			documents = append(documents, *document_request.Document)
+		}
+		// send the batch of documents to AZ API and await the results
+		pii_results, err := ai.requestAiResponse(ctx, NewPiiEntityRecognitionRequest(documents))
+		if err != nil {
+			e = errors.Wrap(err, ErrMsgDetectRequestsFailed)
+			return
+		}
+		// split the pii_results into individual responses, including an error
+		// response for each failed or orphaned document
+		for _, response := range convertResultsToResponses(ai.endpoint, document_requests, pii_results, nil) {
+			if response.Error != nil {
+				failed = append(failed, response.ID+" ("+response.Error.Code+" : "+response.Error.Message+")")
+			}
+			responses = append(responses, response)
+		}
+	}
+	if len(failed) > 0 {
+		e = errors.Errorf(
+			"%s : %d of %d document(s) failed : %s",
+			ErrMsgDetectRequestsFailed,
+			len(failed),
+			len(requests),
+			strings.Join(failed, ", "),
+		)
+	}
+
+	return
+}
+
+// convertResultsToResponses() function returns one rrr.Response for each of
+// the document requests sent to AZ API, where the response is an error
+// response if (1) the request to AZ API failed with pii_err, (2) AZ API
+// returned a DocumentError for the document, or (3) AZ API returned neither
+// a DocumentResponse nor a DocumentError for the (orphaned) document.
+func convertResultsToResponses(
+	endpoint string,
+	document_requests []DocumentRequestWrapper,
+	pii_results *PiiEntityRecognitionResults,
+	pii_err error,
+) []rrr.Response {
+	responses := make([]rrr.Response, 0, len(document_requests))
+
+	if pii_err != nil || pii_results == nil {
+		code := ErrorCodeRequestFailed
+		message := ErrMsgDetectRequestsFailed
+		var status_err *ResponseStatusError
+		if errors.As(pii_err, &status_err) {
+			code = status_err.Code
+		}
+		if pii_err != nil {
+			message = pii_err.Error()
+		}
+		for _, document_request := range document_requests {
+			responses = append(responses, rrr.NewErrorResponse(document_request.Request, code, message))
+		}
+		return responses
+	}
+
+	document_responses := make(map[string]*DocumentResponse, len(pii_results.Results.Documents))
+	for i := range pii_results.Results.Documents {
+		document_responses[pii_results.Results.Documents[i].ID] = &pii_results.Results.Documents[i]
+	}
+	document_errors := make(map[string]*DocumentError, len(pii_results.Results.Errors))
+	for i := range pii_results.Results.Errors {
+		document_errors[pii_results.Results.Errors[i].ID] = &pii_results.Results.Errors[i]
+	}
+
+	for _, document_request := range document_requests {
+		request := document_request.Request
+		if document_response, exists := document_responses[request.ID]; exists {
+			responses = append(responses, convertDocumentResponseToResponse(endpoint, request, document_response))
+			continue
+		}
+		if document_error, exists := document_errors[request.ID]; exists {
+			responses = append(responses, rrr.NewErrorResponse(
+				request,
+				document_error.Error.Code,
+				document_error.Error.Message,
+			))
+			continue
+		}
+		responses = append(responses, rrr.NewErrorResponse(
+			request,
+			ErrorCodeMissingDocumentResponse,
+			"no document response received for request ID = "+request.ID,
+		))
+	}
+
+	return responses
+}
+
 // convertDocumentResponseToResponse() function converts from a DocumentResponse
 // struct to a rrr.Response struct, using the original rrr.Request struct to
 // initialize the new rrr.Response struct.
@@ -0,0 +1,261 @@
+package az
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestEntityDetectionAI_DetectRequests() unit test function tests the
+// DetectRequests() method using the dry run mode of EntityDetectionAI.
+func TestEntityDetectionAI_DetectRequests(t *testing.T) {
+	c := &cfg.Config{}
+	c.AzureAI.AuthKey = "valid-key"
+	c.AzureAI.DryRun = true
+	c.AzureAI.Service = "https://example.com"
+	ai, err := NewEntityDetectionAI(c)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// use more requests than fit in a single batch
+	requests := make([]rrr.Request, 0)
+	for i := 0; i < RequestDocumentLimit*2+1; i++ {
+		request, err := rrr.NewRequest(rrr.NewRequestInput{
+			CommitID: "test_commit",
+			Length:   len("test text"),
+			ObjectID: "test_object",
+			Offset:   0,
+			RepoID:   "test_repo",
+			Text:     fmt.Sprintf("test text %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		requests = append(requests, request)
+	}
+
+	responses, err := ai.DetectRequests(context.Background(), requests)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if len(responses) != len(requests) {
+		t.Fatalf("Expected %d responses, but got %d", len(requests), len(responses))
+	}
+	for i, response := range responses {
+		if response.ID != requests[i].ID {
+			t.Errorf("Expected response ID %s, but got %s", requests[i].ID, response.ID)
+		}
+		if len(response.Results) != 0 {
+			t.Errorf("Expected no results in dry run mode, but got %d", len(response.Results))
+		}
+	}
+
+	// Test case 2: no requests
+	responses, err = ai.DetectRequests(context.Background(), []rrr.Request{})
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if len(responses) != 0 {
+		t.Errorf("Expected 0 responses, but got %d", len(responses))
+	}
+}
+
+// TestEntityDetectionAI_DetectRequests_Failed() unit test function tests that
+// the DetectRequests() method returns an error when a document is orphaned,
+// i.e. the Azure AI Language service returns no response for the document.
+func TestEntityDetectionAI_DetectRequests_Failed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pii_request PiiEntityRecognitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&pii_request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// respond to every document except the last one of the batch
+		pii_results := PiiEntityRecognitionResults{}
+		documents := pii_request.AnalysisInput.Documents
+		for _, document := range documents[:len(documents)-1] {
+			pii_results.Results.Documents = append(pii_results.Results.Documents, DocumentResponse{ID: document.ID})
+		}
+		json.NewEncoder(w).Encode(pii_results)
+	}))
+	defer server.Close()
+
+	ai := &EntityDetectionAI{
+		backoff:    time.Millisecond,
+		backoffMax: 5 * time.Millisecond,
+		client:     server.Client(),
+		endpoint:   server.URL,
+		retries:    RequestRetryLimit,
+	}
+	requests := make([]rrr.Request, 0)
+	for i := 0; i < 3; i++ {
+		request, err := rrr.NewRequest(rrr.NewRequestInput{
+			CommitID: "test_commit",
+			Length:   len("test text"),
+			ObjectID: "test_object",
+			RepoID:   "test_repo",
+			Text:     fmt.Sprintf("test text %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		requests = append(requests, request)
+	}
+
+	responses, err := ai.DetectRequests(context.Background(), requests)
+	if err == nil {
+		t.Fatal("Expected an error for the orphaned document, but got nil")
+	}
+	if !strings.Contains(err.Error(), requests[2].ID) {
+		t.Errorf("Expected the error to contain the orphaned request ID %s, but got: %v", requests[2].ID, err)
+	}
+	if len(responses) != len(requests) {
+		t.Fatalf("Expected %d responses, but got %d", len(requests), len(responses))
+	}
+	if responses[2].Error == nil || responses[2].Error.Code != ErrorCodeMissingDocumentResponse {
+		t.Errorf("Expected error response with code %s, but got: %v", ErrorCodeMissingDocumentResponse, responses[2].Error)
+	}
+}
+
+// Test_convertResultsToResponses() unit test function tests that the
+// convertResultsToResponses() function returns an error response for each
+// failed or orphaned request.
+func Test_convertResultsToResponses(t *testing.T) {
+	document_requests := make([]DocumentRequestWrapper, 0)
+	for i := 0; i < 3; i++ {
+		request, err := rrr.NewRequest(rrr.NewRequestInput{
+			CommitID: "test_commit",
+			Length:   len("test text"),
+			ObjectID: "test_object",
+			Offset:   0,
+			RepoID:   "test_repo",
+			Text:     fmt.Sprintf("test text %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		document_requests = append(document_requests, wrapDocumentRequest(&request))
+	}
+
+	// Test case 1: request failed
+	status_err := &ResponseStatusError{Code: "TooManyRequests", StatusCode: http.StatusTooManyRequests}
+	responses := convertResultsToResponses("test-endpoint", document_requests, nil, status_err)
+	if len(responses) != len(document_requests) {
+		t.Fatalf("Expected %d responses, but got %d", len(document_requests), len(responses))
+	}
+	for i, response := range responses {
+		if response.ID != document_requests[i].Request.ID {
+			t.Errorf("Expected response ID %s, but got %s", document_requests[i].Request.ID, response.ID)
+		}
+		if response.Error == nil || response.Error.Code != "TooManyRequests" {
+			t.Errorf("Expected error response with code TooManyRequests, but got: %v", response.Error)
+		}
+	}
+
+	// Test case 2: document response, document error and orphaned document
+	pii_results := &PiiEntityRecognitionResults{}
+	pii_results.Results.Documents = []DocumentResponse{
+		{
+			ID:       document_requests[0].Request.ID,
+			Entities: []Entity{{Category: "Person", ConfidenceScore: 0.9, Text: "John"}},
+		},
+	}
+	document_error := DocumentError{ID: document_requests[1].Request.ID}
+	document_error.Error.Code = "InvalidDocument"
+	document_error.Error.Message = "Document text is empty."
+	pii_results.Results.Errors = []DocumentError{document_error}
+
+	responses = convertResultsToResponses("test-endpoint", document_requests, pii_results, nil)
+	if len(responses) != len(document_requests) {
+		t.Fatalf("Expected %d responses, but got %d", len(document_requests), len(responses))
+	}
+	if responses[0].Error != nil || len(responses[0].Results) != 1 {
+		t.Errorf("Expected 1 result without error, but got %d results with error: %v", len(responses[0].Results), responses[0].Error)
+	}
+	if responses[1].Error == nil || responses[1].Error.Code != "InvalidDocument" {
+		t.Errorf("Expected error response with code InvalidDocument, but got: %v", responses[1].Error)
+	}
+	if responses[2].Error == nil || responses[2].Error.Code != ErrorCodeMissingDocumentResponse {
+		t.Errorf("Expected error response with code %s, but got: %v", ErrorCodeMissingDocumentResponse, responses[2].Error)
+	}
+}
+
+// TestAzAiLanguagePhiDetector_Run_Idle() unit test function tests that the
+// Run() method keeps receiving requests after the timer of the detector fired
+// while the detector was idle.
+func TestAzAiLanguagePhiDetector_Run_Idle(t *testing.T) {
+	c := &cfg.Config{}
+	c.AzureAI.AuthKey = "valid-key"
+	c.AzureAI.DryRun = true
+	c.AzureAI.Service = "https://example.com"
+	ai, err := NewEntityDetectionAI(c)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chan_requests := make(chan rrr.Request)
+	chan_responses := make(chan rrr.Response)
+	go NewAzAiLanguagePhiDetector(ai).Run(ctx, chan_requests, chan_responses)
+
+	newRequest := func(i int) rrr.Request {
+		request, err := rrr.NewRequest(rrr.NewRequestInput{
+			CommitID: "test_commit",
+			Length:   len("test text"),
+			ObjectID: "test_object",
+			Offset:   0,
+			RepoID:   "test_repo",
+			Text:     fmt.Sprintf("test text %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		return request
+	}
+	sendRequest := func(request rrr.Request) {
+		select {
+		case chan_requests <- request:
+		case <-time.After(RequestTimerDuration * 3):
+			t.Fatalf("Timed out sending request %s", request.ID)
+		}
+	}
+
+	// the first request is processed when the timer fires
+	request_1 := newRequest(1)
+	sendRequest(request_1)
+	select {
+	case response := <-chan_responses:
+		if response.ID != request_1.ID {
+			t.Errorf("Expected response ID %s, but got %s", request_1.ID, response.ID)
+		}
+	case <-time.After(RequestTimerDuration * 3):
+		t.Fatalf("Timed out waiting for response to request %s", request_1.ID)
+	}
+
+	// the second request is sent after the timer fired while idle
+	time.Sleep(RequestTimerDuration + time.Second)
+	request_2 := newRequest(2)
+	sendRequest(request_2)
+	close(chan_requests)
+	select {
+	case response := <-chan_responses:
+		if response.ID != request_2.ID {
+			t.Errorf("Expected response ID %s, but got %s", request_2.ID, response.ID)
+		}
+	case <-time.After(RequestTimerDuration * 3):
+		t.Fatalf("Timed out waiting for response to request %s", request_2.ID)
+	}
+	if _, ok := <-chan_responses; ok {
+		t.Errorf("Expected the responses channel to be closed")
+	}
+}
@@ -6,7 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -18,11 +23,68 @@ import (
 // detect entities of interest in natural language documents and (2) processing
 // responses from the Azure AI Language service.
 type EntityDetectionAI struct {
+	backoff    time.Duration
+	backoffMax time.Duration
 	client     *http.Client
 	confidence float64
 	dryRun     bool
 	endpoint   string
 	key        string
+	retries    int
+}
+
+// ResponseStatusError struct is the error returned when the Azure AI Language
+// service API responds with an HTTP status other than 200 OK, where the Code
+// and Message are set from the ErrorResponse in the body of the response.
+type ResponseStatusError struct {
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+	StatusCode int
+}
+
+// newResponseStatusError() function returns a new ResponseStatusError for the
+// HTTP response and its body, using the HTTP status text as the Code if the
+// body does not contain an ErrorResponse.
+func newResponseStatusError(http_response *http.Response, body []byte) *ResponseStatusError {
+	status_err := &ResponseStatusError{
+		Code:       http.StatusText(http_response.StatusCode),
+		StatusCode: http_response.StatusCode,
+	}
+	var error_response ErrorResponse
+	if err := json.Unmarshal(body, &error_response); err == nil && error_response.Error.Code != "" {
+		status_err.Code = error_response.Error.Code
+		status_err.Message = error_response.Error.Message
+	}
+	switch http_response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		status_err.RetryAfter = parseRetryAfter(http_response.Header.Get("Retry-After"))
+	}
+	return status_err
+}
+
+// Error() method returns the string representation of the ResponseStatusError.
+func (e *ResponseStatusError) Error() string {
+	return fmt.Sprintf(
+		"Azure AI Language service responded with HTTP status %d : %s : %s",
+		e.StatusCode,
+		e.Code,
+		e.Message,
+	)
+}
+
+// Temporary() method returns true if the HTTP status indicates that the same
+// request may succeed when retried, e.g. due to rate limiting (429).
+func (e *ResponseStatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
 }
 
 // NewEntityDetectionAI() function requires the Azure service host and
@@ -46,11 +108,14 @@ func NewEntityDetectionAI(c *cfg.Config) (*EntityDetectionAI, error) {
 	)
 
 	return &EntityDetectionAI{
+		backoff:    RequestRetryBackoff,
+		backoffMax: RequestRetryBackoffMax,
 		client:     &http.Client{},
 		confidence: c.AzureAI.ConfidenceThreshold,
 		dryRun:     c.AzureAI.DryRun,
 		endpoint:   endpoint,
 		key:        c.AzureAI.AuthKey,
+		retries:    RequestRetryLimit,
 	}, nil
 }
 
@@ -61,48 +126,73 @@ func (ai *EntityDetectionAI) DetectPiiEntities(
 	ctx context.Context,
 	request_data *PiiEntityRecognitionRequest,
 ) (detected bool, e error) {
-	// explicitly set detected to false
-	detected = false
+	entities, err := ai.DetectPiiEntitiesByDocument(ctx, request_data)
+	if err != nil {
+		e = err
+		return
+	}
+	// set detected to true if any entities are found over the confidence threshold
+	detected = len(entities) > 0
+
+	return
+}
 
+// DetectPiiEntitiesByDocument() method sends the PiiEntityRecognitionRequest
+// to the Azure AI language service and returns the entities detected above
+// the confidence threshold, keyed by the ID of the document in which they
+// were detected. Documents without any such entities are omitted. Returns a
+// non-nil error, along with the entities of the other documents, if the AI
+// service returned an error for any document, or no result at all.
+func (ai *EntityDetectionAI) DetectPiiEntitiesByDocument(
+	ctx context.Context,
+	request_data *PiiEntityRecognitionRequest,
+) (entities map[string][]Entity, e error) {
 	entity_recognition_results, entity_recognition_err := ai.requestAiResponse(ctx, request_data)
 	if entity_recognition_err != nil {
 		e = errors.Wrap(entity_recognition_err, "error requesting AI API response")
 		return
 	}
 
+	entities = make(map[string][]Entity)
+	answered := make(map[string]bool, len(request_data.AnalysisInput.Documents))
 	for _, doc := range entity_recognition_results.Results.Documents {
-		var entities []Entity
+		answered[doc.ID] = true
 		for _, entity := range doc.Entities {
 			if entity.ConfidenceScore >= ai.confidence {
-				entities = append(entities, entity)
+				entities[doc.ID] = append(entities[doc.ID], entity)
 			}
 		}
-		if len(entities) > 0 {
-			// set detected to true if any entities are found over the confidence threshold
-			detected = true
-
-			// TODO : remove/replace processing of entities
-			result := struct {
-				ID       string   `json:"id"`
-				Entities []Entity `json:"entities"`
-			}{
-				ID:       doc.ID,
-				Entities: entities,
-			}
-			var resultBytes []byte
-			resultBytes, e = json.MarshalIndent(result, "", "  ")
-			if e != nil {
-				log.Ctx(ctx).Error().Msgf("error marshalling result within entity detection response: %s", e)
-				return
-			}
-			// TODO : remove debug logging of resultBytes
-			log.Ctx(ctx).Debug().Msgf("entity detection result:\n%s", string(resultBytes))
+	}
+
+	failed := []string{}
+	for _, doc_err := range entity_recognition_results.Results.Errors {
+		answered[doc_err.ID] = true
+		failed = append(failed, doc_err.ID+" ("+doc_err.Error.Code+" : "+doc_err.Error.Message+")")
+	}
+	for _, doc := range request_data.AnalysisInput.Documents {
+		if !answered[doc.ID] {
+			failed = append(failed, doc.ID+" ("+ErrorCodeMissingDocumentResponse+")")
 		}
 	}
+	if len(failed) > 0 {
+		e = errors.Errorf(
+			"%s : %d of %d document(s) failed : %s",
+			ErrMsgDetectDocumentsFailed,
+			len(failed),
+			len(request_data.AnalysisInput.Documents),
+			strings.Join(failed, ", "),
+		)
+	}
 
 	return
 }
 
+// GetConfidenceThreshold() method returns the minimum confidence score
+// required for a detected entity to be considered valid.
+func (ai *EntityDetectionAI) GetConfidenceThreshold() float64 {
+	return ai.confidence
+}
+
 // GetServiceEndpoint() method return the full URL of the service API endpoint
 func (ai *EntityDetectionAI) GetServiceEndpoint() string {
 	return ai.endpoint
@@ -135,7 +225,10 @@ func (ai *EntityDetectionAI) dryRunRespond(ctx context.Context, entity_request *
 // requestAiResponse() method converts a PiiEntityRecognitionRequest to a
 // JSON byte array and sends the request to the Azure AI Language service API,
 // then converts the JSON response from the API to PiiEntityRecognitionResults.
-// This method returns an error if the request or response fails.
+// Failed requests are retried up to the retry limit with exponential backoff
+// and jitter, where the Retry-After header is honored for HTTP status 429 and
+// 503 responses. This method returns an error if the request or response fails
+// after all retries.
 func (ai *EntityDetectionAI) requestAiResponse(
 	ctx context.Context,
 	entity_request *PiiEntityRecognitionRequest,
@@ -153,6 +246,79 @@ func (ai *EntityDetectionAI) requestAiResponse(
 		return nil, e
 	}
 
+	log.Ctx(ctx).Trace().Msgf(
+		"requesting entity recognition for %d documents",
+		len(entity_request.AnalysisInput.Documents),
+	)
+
+	for attempt := 0; ; attempt++ {
+		entity_recognition_results, err := ai.sendAiRequest(ctx, entity_request_bytes)
+		if err == nil {
+			log.Ctx(ctx).Trace().Msgf(
+				"received entity recognition results with %d document responses",
+				len(entity_recognition_results.Results.Documents),
+			)
+			return entity_recognition_results, nil
+		}
+		if attempt >= ai.retries || !isRetryable(ctx, err) {
+			return nil, err
+		}
+
+		delay := ai.retryDelay(attempt, err)
+		log.Ctx(ctx).Warn().Err(err).Msgf(
+			"retrying request to Azure AI Language service in %s : retry %d of %d",
+			delay,
+			attempt+1,
+			ai.retries,
+		)
+		select {
+		case <-ctx.Done():
+			e = errors.Wrap(ctx.Err(), "stopped retrying request to Azure AI Language service")
+			return nil, e
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay() method returns the delay before the retry following the given
+// (zero-based) attempt, using the Retry-After duration of the error, if any,
+// or else an exponential backoff with jitter, where the delay is limited to
+// the maximum backoff in both cases such that a server cannot stall the
+// detector with a long Retry-After duration.
+func (ai *EntityDetectionAI) retryDelay(attempt int, err error) time.Duration {
+	var status_err *ResponseStatusError
+	if errors.As(err, &status_err) && status_err.RetryAfter > 0 {
+		if status_err.RetryAfter > ai.backoffMax {
+			return ai.backoffMax
+		}
+		return status_err.RetryAfter
+	}
+
+	backoff := ai.backoff
+	for i := 0; i < attempt && backoff < ai.backoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > ai.backoffMax {
+		backoff = ai.backoffMax
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	// use "equal jitter" such that the delay is between half and all of the backoff
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+// sendAiRequest() method sends a single HTTP request with the JSON bytes of a
+// PiiEntityRecognitionRequest to the Azure AI Language service API and returns
+// the PiiEntityRecognitionResults of the response. Returns a non-nil
+// *ResponseStatusError if the HTTP status of the response is not 200 OK.
+func (ai *EntityDetectionAI) sendAiRequest(
+	ctx context.Context,
+	entity_request_bytes []byte,
+) (*PiiEntityRecognitionResults, error) {
+	var e error
+
 	http_request, err := http.NewRequestWithContext(ctx, "POST", ai.endpoint, bytes.NewBuffer(entity_request_bytes))
 	if err != nil {
 		e = errors.Wrap(err, "failed creating HTTP request to Azure AI Language service")
@@ -162,11 +328,6 @@ func (ai *EntityDetectionAI) requestAiResponse(
 	// set the required headers for the HTTP request before sending
 	ai.setHttpRequestHeaders(http_request)
 
-	log.Ctx(ctx).Trace().Msgf(
-		"requesting entity recognition for %d documents",
-		len(entity_request.AnalysisInput.Documents),
-	)
-
 	// send the HTTP request to the Azure AI Language service API
 	http_response, err := ai.client.Do(http_request)
 	if err != nil {
@@ -182,6 +343,10 @@ func (ai *EntityDetectionAI) requestAiResponse(
 		return nil, e
 	}
 
+	if http_response.StatusCode != http.StatusOK {
+		return nil, newResponseStatusError(http_response, http_response_body)
+	}
+
 	var entity_recognition_results PiiEntityRecognitionResults
 	// unmarshal the bytes from the response body into a PiiEntityRecognitionResults
 	if e = json.Unmarshal(http_response_body, &entity_recognition_results); e != nil {
@@ -189,11 +354,6 @@ func (ai *EntityDetectionAI) requestAiResponse(
 		return nil, e
 	}
 
-	log.Ctx(ctx).Trace().Msgf(
-		"received entity recognition results with %d document responses",
-		len(entity_recognition_results.Results.Documents),
-	)
-
 	return &entity_recognition_results, nil
 }
 
@@ -210,3 +370,40 @@ func getDefaultDetectionApi(showStats bool) string {
 	}
 	return DefaultDetectionApi
 }
+
+// isRetryable() function returns true if the error of a request to the Azure
+// AI Language service API may be resolved by retrying the request, i.e. for
+// temporary HTTP status errors and for failures to send the request, unless
+// the context is done.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var status_err *ResponseStatusError
+	if errors.As(err, &status_err) {
+		return status_err.Temporary()
+	}
+	var url_err *url.Error
+	return errors.As(err, &url_err)
+}
+
+// parseRetryAfter() function parses the value of a Retry-After header, which
+// is either a number of seconds or an HTTP date, and returns the duration to
+// wait before retrying. Returns zero if the value is empty or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -1,7 +1,16 @@
 package az
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
 )
@@ -69,3 +78,192 @@ func Test_getDefaultDetectionApi(t *testing.T) {
 		t.Errorf("Expected result: %s, but got: %s", expectedResult, result)
 	}
 }
+
+// TestEntityDetectionAI_requestAiResponse() unit test function tests that the
+// requestAiResponse() method retries temporary HTTP status errors, honoring
+// the Retry-After header, and does not retry other HTTP status errors.
+func TestEntityDetectionAI_requestAiResponse(t *testing.T) {
+	tests := []struct {
+		name             string
+		retries          int
+		statuses         []int
+		expect_attempts  int
+		expect_err_code  string
+		expect_documents int
+	}{
+		{
+			name:             "OK",
+			retries:          RequestRetryLimit,
+			statuses:         []int{http.StatusOK},
+			expect_attempts:  1,
+			expect_documents: 1,
+		},
+		{
+			name:             "Retry_TooManyRequests",
+			retries:          2,
+			statuses:         []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK},
+			expect_attempts:  3,
+			expect_documents: 1,
+		},
+		{
+			name:            "Retry_Exhausted",
+			retries:         2,
+			statuses:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			expect_attempts: 3,
+			expect_err_code: "ServiceUnavailable",
+		},
+		{
+			name:            "No_Retry_BadRequest",
+			retries:         RequestRetryLimit,
+			statuses:        []int{http.StatusBadRequest, http.StatusOK},
+			expect_attempts: 1,
+			expect_err_code: "InvalidRequest",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := int(atomic.AddInt32(&attempts, 1)) - 1
+				status := test.statuses[attempt]
+				switch status {
+				case http.StatusOK:
+					fmt.Fprint(w, `{"kind":"PiiEntityRecognitionResults","results":{"documents":[{"id":"1","entities":[]}],"errors":[]}}`)
+				case http.StatusTooManyRequests:
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(status)
+				case http.StatusServiceUnavailable:
+					w.WriteHeader(status)
+					fmt.Fprint(w, `{"error":{"code":"ServiceUnavailable","message":"try again later"}}`)
+				default:
+					w.WriteHeader(status)
+					fmt.Fprint(w, `{"error":{"code":"InvalidRequest","message":"invalid request"}}`)
+				}
+			}))
+			defer server.Close()
+
+			ai := &EntityDetectionAI{
+				backoff:    time.Millisecond,
+				backoffMax: 5 * time.Millisecond,
+				client:     server.Client(),
+				endpoint:   server.URL,
+				retries:    test.retries,
+			}
+
+			results, err := ai.requestAiResponse(
+				context.Background(),
+				NewPiiEntityRecognitionRequest([]Document{NewDocument("1", "test text", "")}),
+			)
+			if got := int(atomic.LoadInt32(&attempts)); got != test.expect_attempts {
+				t.Errorf("Expected %d attempts, but got %d", test.expect_attempts, got)
+			}
+			if test.expect_err_code != "" {
+				var status_err *ResponseStatusError
+				if !errors.As(err, &status_err) {
+					t.Fatalf("Expected ResponseStatusError, but got: %v", err)
+				}
+				if status_err.Code != test.expect_err_code {
+					t.Errorf("Expected error code %s, but got %s", test.expect_err_code, status_err.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if len(results.Results.Documents) != test.expect_documents {
+				t.Errorf("Expected %d documents, but got %d", test.expect_documents, len(results.Results.Documents))
+			}
+		})
+	}
+}
+
+// TestEntityDetectionAI_DetectPiiEntitiesByDocument() unit test function tests
+// that the DetectPiiEntitiesByDocument() method returns the entities above the
+// confidence threshold, along with an error for each failed or orphaned
+// document.
+func TestEntityDetectionAI_DetectPiiEntitiesByDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"kind":"PiiEntityRecognitionResults","results":{"documents":[`+
+			`{"id":"1","entities":[{"category":"Person","confidenceScore":0.9,"length":4,"offset":0},`+
+			`{"category":"Age","confidenceScore":0.1,"length":2,"offset":5}]}],`+
+			`"errors":[{"id":"2","error":{"code":"InvalidDocument","message":"document is empty"}}]}}`)
+	}))
+	defer server.Close()
+
+	ai := &EntityDetectionAI{
+		backoff:    time.Millisecond,
+		backoffMax: 5 * time.Millisecond,
+		client:     server.Client(),
+		confidence: 0.5,
+		endpoint:   server.URL,
+		retries:    RequestRetryLimit,
+	}
+	entities, err := ai.DetectPiiEntitiesByDocument(
+		context.Background(),
+		NewPiiEntityRecognitionRequest([]Document{
+			NewDocument("1", "Jane 42", ""),
+			NewDocument("2", "", ""),
+			NewDocument("3", "test text", ""),
+		}),
+	)
+	if err == nil {
+		t.Fatal("Expected an error for the failed documents, but got nil")
+	}
+	for _, expect := range []string{"2 (InvalidDocument", "3 (" + ErrorCodeMissingDocumentResponse} {
+		if !strings.Contains(err.Error(), expect) {
+			t.Errorf("Expected the error to contain %q, but got: %v", expect, err)
+		}
+	}
+	if len(entities) != 1 || len(entities["1"]) != 1 || entities["1"][0].Category != "Person" {
+		t.Errorf("Expected a single Person entity for document 1, but got: %v", entities)
+	}
+}
+
+// Test_parseRetryAfter() unit test function tests the parseRetryAfter()
+// function.
+func Test_parseRetryAfter(t *testing.T) {
+	if delay := parseRetryAfter(""); delay != 0 {
+		t.Errorf("Expected 0, but got %s", delay)
+	}
+	if delay := parseRetryAfter("invalid"); delay != 0 {
+		t.Errorf("Expected 0, but got %s", delay)
+	}
+	if delay := parseRetryAfter("3"); delay != 3*time.Second {
+		t.Errorf("Expected 3s, but got %s", delay)
+	}
+	if delay := parseRetryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)); delay != 0 {
+		t.Errorf("Expected 0 for a date in the past, but got %s", delay)
+	}
+	if delay := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); delay <= 0 {
+		t.Errorf("Expected a positive delay for a date in the future, but got %s", delay)
+	}
+}
+
+// TestEntityDetectionAI_retryDelay() unit test function tests that the
+// retryDelay() method grows exponentially up to the maximum backoff, and that
+// the Retry-After duration of an error is limited to the maximum backoff.
+func TestEntityDetectionAI_retryDelay(t *testing.T) {
+	ai := &EntityDetectionAI{backoff: 100 * time.Millisecond, backoffMax: time.Second}
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		delay := ai.retryDelay(attempt, errors.New("test error"))
+		if delay < max/2 || delay > max {
+			t.Errorf("Expected delay of attempt %d between %s and %s, but got %s", attempt, max/2, max, delay)
+		}
+	}
+	delay := ai.retryDelay(0, &ResponseStatusError{RetryAfter: 300 * time.Millisecond})
+	if delay != 300*time.Millisecond {
+		t.Errorf("Expected Retry-After delay of 300ms, but got %s", delay)
+	}
+	delay = ai.retryDelay(0, &ResponseStatusError{RetryAfter: 24 * time.Hour})
+	if delay != time.Second {
+		t.Errorf("Expected Retry-After delay limited to 1s, but got %s", delay)
+	}
+}
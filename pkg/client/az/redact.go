@@ -0,0 +1,48 @@
+package az
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RedactionPlaceholder() function returns the placeholder text that replaces
+// an entity of the given category when the entity is redacted from a document.
+func RedactionPlaceholder(category string) string {
+	return fmt.Sprintf("[REDACTED:%s]", category)
+}
+
+// RedactEntities() function replaces the text of each entity within the text
+// of a document with a placeholder for the category of the entity. The offset
+// and length of each entity are expected to be measured in Unicode code points.
+// Entities that overlap a previously redacted entity, or that fall outside of
+// the bounds of the text, are ignored, where the longest of the entities that
+// start at the same offset is redacted.
+func RedactEntities(text string, entities []Entity) string {
+	if len(entities) == 0 {
+		return text
+	}
+	sorted := make([]Entity, len(entities))
+	copy(sorted, entities)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Offset == sorted[j].Offset {
+			return sorted[i].Length > sorted[j].Length
+		}
+		return sorted[i].Offset < sorted[j].Offset
+	})
+
+	runes := []rune(text)
+	redacted := make([]rune, 0, len(runes))
+	position := 0
+	for _, entity := range sorted {
+		end := entity.Offset + entity.Length
+		if entity.Offset < position || entity.Length <= 0 || end > len(runes) {
+			continue
+		}
+		redacted = append(redacted, runes[position:entity.Offset]...)
+		redacted = append(redacted, []rune(RedactionPlaceholder(entity.Category))...)
+		position = end
+	}
+	redacted = append(redacted, runes[position:]...)
+
+	return string(redacted)
+}
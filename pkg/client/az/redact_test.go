@@ -0,0 +1,33 @@
+package az
+
+import (
+	"testing"
+)
+
+// TestRedactEntities() unit test function tests the RedactEntities() function.
+func TestRedactEntities(t *testing.T) {
+	text := "Call Jane Doe at 555-123-4567 – née Smith"
+	entities := []Entity{
+		// out of order, to ensure entities are sorted before redaction
+		{Category: "PhoneNumber", Offset: 17, Length: 12},
+		// starts at the same offset as the longer entity, which is redacted
+		{Category: "Person", Offset: 5, Length: 4},
+		{Category: "Person", Offset: 5, Length: 8},
+		// overlaps the previous entity and should be ignored
+		{Category: "Person", Offset: 10, Length: 3},
+		// offset is measured in code points, past the multi-byte characters
+		{Category: "Person", Offset: 36, Length: 5},
+		// out of bounds and should be ignored
+		{Category: "Person", Offset: 40, Length: 10},
+	}
+
+	expected := "Call [REDACTED:Person] at [REDACTED:PhoneNumber] – née [REDACTED:Person]"
+	if result := RedactEntities(text, entities); result != expected {
+		t.Errorf("Expected redacted text %q, but got %q", expected, result)
+	}
+
+	// Test case 2: no entities
+	if result := RedactEntities(text, nil); result != text {
+		t.Errorf("Expected text to be unchanged, but got %q", result)
+	}
+}
@@ -45,6 +45,16 @@ type DocumentError struct {
 	} `json:"error"`
 }
 
+// ErrorResponse struct represents the error returned by the Azure AI Language
+// service API when the whole request fails, e.g. with HTTP status 429.
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#errorresponse
+type ErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 // DocumentResponse struct represents the document-specific response from the
 // Azure AI Language service API.
 // ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#documents
@@ -118,6 +128,11 @@ type Parameters struct {
 	//
 	// (default = ["Default"])
 	PiiCategories []string `json:"piiCategories"`
+	// stringIndexType determines how the offset and length of each entity
+	// are measured, where "UnicodeCodePoint" matches the runes of a Go string
+	//
+	// (default = "UnicodeCodePoint")
+	StringIndexType string `json:"stringIndexType,omitempty"`
 }
 
 // ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#piitaskresult
@@ -140,10 +155,11 @@ func NewPiiEntityRecognitionRequest(documents []Document) *PiiEntityRecognitionR
 			Documents: documents,
 		},
 		Parameters: Parameters{
-			Domain:        "phi",
-			LoggingOptOut: true,
-			ModelVersion:  "latest",
-			PiiCategories: []string{"Default"},
+			Domain:          "phi",
+			LoggingOptOut:   true,
+			ModelVersion:    "latest",
+			PiiCategories:   []string{"Default"},
+			StringIndexType: StringIndexTypeUnicodeCodePoint,
 		},
 	}
 }
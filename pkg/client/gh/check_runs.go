@@ -0,0 +1,105 @@
+package gh
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/pkg/errors"
+)
+
+const CheckRunAnnotationLevelFailure string = "failure"
+const CheckRunAnnotationsLimit int = 50
+const CheckRunConclusionFailure string = "failure"
+const CheckRunConclusionSuccess string = "success"
+const CheckRunName string = "no-phi-ai"
+const CheckRunStatusCompleted string = "completed"
+const CheckRunStatusInProgress string = "in_progress"
+
+// CompleteCheckRunInput struct contains the input parameters required for
+// the CompleteCheckRun() function.
+type CompleteCheckRunInput struct {
+	Annotations []*github.CheckRunAnnotation
+	CheckRunID  int64
+	Conclusion  string
+	Owner       string
+	Repo        string
+	Summary     string
+	Title       string
+}
+
+// CompleteCheckRun() function marks the check run as completed with the given
+// conclusion and output. The GitHub API limits each request to 50 annotations,
+// so any additional annotations are sent in subsequent updates to the check run.
+func CompleteCheckRun(ctx context.Context, client *github.Client, in CompleteCheckRunInput) error {
+	remaining := in.Annotations
+	for {
+		batch := remaining
+		if len(batch) > CheckRunAnnotationsLimit {
+			batch = remaining[:CheckRunAnnotationsLimit]
+		}
+		remaining = remaining[len(batch):]
+
+		opts := github.UpdateCheckRunOptions{
+			Name: CheckRunName,
+			Output: &github.CheckRunOutput{
+				Annotations: batch,
+				Summary:     &in.Summary,
+				Title:       &in.Title,
+			},
+		}
+		// only complete the check run with the last batch of annotations
+		if len(remaining) == 0 {
+			status := CheckRunStatusCompleted
+			opts.Status = &status
+			opts.Conclusion = &in.Conclusion
+			opts.CompletedAt = &github.Timestamp{Time: time.Now()}
+		}
+		_, resp, err := client.Checks.UpdateCheckRun(ctx, in.Owner, in.Repo, in.CheckRunID, opts)
+		if err = checkResponse(resp, err); err != nil {
+			return errors.Wrapf(err, "failed to update check run %d", in.CheckRunID)
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+	}
+}
+
+// CreateCheckRun() function creates a new, in-progress check run for the
+// given head sha and returns the ID of the check run.
+func CreateCheckRun(ctx context.Context, client *github.Client, owner, repo, head_sha string) (int64, error) {
+	status := CheckRunStatusInProgress
+	check_run, resp, err := client.Checks.CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+		HeadSHA:   head_sha,
+		Name:      CheckRunName,
+		StartedAt: &github.Timestamp{Time: time.Now()},
+		Status:    &status,
+	})
+	if err = checkResponse(resp, err); err != nil {
+		return 0, errors.Wrapf(err, "failed to create check run for commit %s", head_sha)
+	}
+	return check_run.GetID(), nil
+}
+
+// ListPullRequestFiles() function returns the list of files changed by the
+// pull request, excluding any files removed by the pull request.
+func ListPullRequestFiles(ctx context.Context, client *github.Client, owner, repo string, number int) (files []*github.CommitFile, e error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, number, opts)
+		if e = checkResponse(resp, err); e != nil {
+			e = errors.Wrapf(e, "failed to list files for pull request #%d", number)
+			return
+		}
+		for _, file := range page {
+			if file.GetStatus() == CommitFileStatusRemoved {
+				continue
+			}
+			files = append(files, file)
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -1,8 +1,13 @@
 package gh
 
 import (
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v58/github"
 	"github.com/gregjones/httpcache"
 	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
 	metrics "github.com/rcrowley/go-metrics"
 	"github.com/rs/zerolog"
 
@@ -13,6 +18,8 @@ import (
 // and adds additional methods for implementing the business logic of the app.
 type ClientManager struct {
 	githubapp.ClientCreator
+
+	config *cfg.Config
 }
 
 // NewClientManager() function initializes a new ClientManager object
@@ -37,5 +44,28 @@ func NewClientManager(config *cfg.Config) (*ClientManager, error) {
 		return nil, err
 	}
 
-	return &ClientManager{cc}, nil
+	return &ClientManager{ClientCreator: cc, config: config}, nil
+}
+
+// NewCLIClient() method returns a client for interacting with GitHub APIs when
+// running the app in "cli" mode, which is authenticated with the configured
+// git auth token, or is unauthenticated (and can only read public data) if
+// no token is configured.
+func (cm *ClientManager) NewCLIClient() (*github.Client, error) {
+	if cm.config.Git.Auth.Token != "" {
+		return cm.NewTokenClient(cm.config.Git.Auth.Token)
+	}
+
+	base_url := cm.config.GitHub.V3APIURL
+	if !strings.HasSuffix(base_url, "/") {
+		base_url += "/"
+	}
+	parsed_url, err := url.Parse(base_url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse GitHub API URL %s", base_url)
+	}
+	client := github.NewClient(nil)
+	client.BaseURL = parsed_url
+	client.UserAgent = cm.config.App.UserAgent
+	return client, nil
 }
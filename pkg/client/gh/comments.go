@@ -0,0 +1,53 @@
+package gh
+
+import (
+	"context"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/githubv4"
+)
+
+// EditIssueComment() function replaces the body of an issue comment, which
+// includes comments on the conversation of a pull request.
+func EditIssueComment(ctx context.Context, client *github.Client, owner, repo string, comment_id int64, body string) error {
+	_, resp, err := client.Issues.EditComment(ctx, owner, repo, comment_id, &github.IssueComment{Body: &body})
+	if err = checkResponse(resp, err); err != nil {
+		return errors.Wrapf(err, "failed to edit issue comment %d", comment_id)
+	}
+	return nil
+}
+
+// EditPullRequestReviewComment() function replaces the body of a review
+// comment on the diff of a pull request.
+func EditPullRequestReviewComment(ctx context.Context, client *github.Client, owner, repo string, comment_id int64, body string) error {
+	_, resp, err := client.PullRequests.EditComment(ctx, owner, repo, comment_id, &github.PullRequestComment{Body: &body})
+	if err = checkResponse(resp, err); err != nil {
+		return errors.Wrapf(err, "failed to edit pull request review comment %d", comment_id)
+	}
+	return nil
+}
+
+// MinimizeComment() function uses the GraphQL API to hide the comment with
+// the given node ID as off-topic. The REST API does not support minimizing
+// comments.
+func MinimizeComment(ctx context.Context, client *githubv4.Client, node_id string) error {
+	var mutation struct {
+		MinimizeComment struct {
+			MinimizedComment struct {
+				IsMinimized githubv4.Boolean
+			}
+		} `graphql:"minimizeComment(input: $input)"`
+	}
+	input := githubv4.MinimizeCommentInput{
+		Classifier: githubv4.ReportedContentClassifiersOffTopic,
+		SubjectID:  githubv4.ID(node_id),
+	}
+	if err := client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return errors.Wrapf(err, "failed to minimize comment %s", node_id)
+	}
+	if !mutation.MinimizeComment.MinimizedComment.IsMinimized {
+		return errors.New("comment was not minimized : " + node_id)
+	}
+	return nil
+}
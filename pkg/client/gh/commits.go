@@ -0,0 +1,185 @@
+package gh
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/pkg/errors"
+)
+
+const CommitFileStatusRemoved string = "removed"
+const CommitStatusContext string = "no-phi-ai"
+const CommitStatusDescriptionLimit int = 140
+const CommitStatusStateError string = "error"
+const CommitStatusStateFailure string = "failure"
+const CommitStatusStatePending string = "pending"
+const CommitStatusStateSuccess string = "success"
+const PushEventCommitsLimit int = 20
+
+// CreateCommitComment() function creates a comment on the commit with the
+// given sha and returns the HTML URL of the new comment.
+func CreateCommitComment(ctx context.Context, client *github.Client, owner, repo, sha, body string) (html_url string, e error) {
+	if body == "" {
+		e = errors.New("cannot create commit comment with empty body")
+		return
+	}
+	comment, resp, err := client.Repositories.CreateComment(ctx, owner, repo, sha, &github.RepositoryComment{
+		Body: &body,
+	})
+	if e = checkResponse(resp, err); e != nil {
+		e = errors.Wrapf(e, "failed to create comment for commit %s", sha)
+		return
+	}
+	html_url = comment.GetHTMLURL()
+	return
+}
+
+// CreateCommitStatus() function sets the status of the commit with the given
+// sha, where state must be one of the CommitStatusState* values. The target_url
+// is optional and can be used to link to a summary of the findings.
+func CreateCommitStatus(ctx context.Context, client *github.Client, owner, repo, sha, state, description, target_url string) error {
+	status_context := CommitStatusContext
+	// the GitHub API rejects descriptions longer than the documented limit
+	if len(description) > CommitStatusDescriptionLimit {
+		description = description[:CommitStatusDescriptionLimit-3] + "..."
+	}
+	status := &github.RepoStatus{
+		Context:     &status_context,
+		Description: &description,
+		State:       &state,
+	}
+	if target_url != "" {
+		status.TargetURL = &target_url
+	}
+	_, resp, err := client.Repositories.CreateStatus(ctx, owner, repo, sha, status)
+	if err = checkResponse(resp, err); err != nil {
+		return errors.Wrapf(err, "failed to create status for commit %s", sha)
+	}
+	return nil
+}
+
+// GetBlobContent() function returns the raw content of the git blob with the
+// given sha.
+func GetBlobContent(ctx context.Context, client *github.Client, owner, repo, sha string) ([]byte, error) {
+	content, resp, err := client.Git.GetBlobRaw(ctx, owner, repo, sha)
+	if err = checkResponse(resp, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get content for blob %s", sha)
+	}
+	return content, nil
+}
+
+// ListCommitFiles() function returns the list of files changed by the commit
+// with the given sha, excluding any files removed by the commit.
+func ListCommitFiles(ctx context.Context, client *github.Client, owner, repo, sha string) (files []*github.CommitFile, e error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		commit, resp, err := client.Repositories.GetCommit(ctx, owner, repo, sha, opts)
+		if e = checkResponse(resp, err); e != nil {
+			e = errors.Wrapf(e, "failed to get files for commit %s", sha)
+			return
+		}
+		for _, file := range commit.Files {
+			if file.GetStatus() == CommitFileStatusRemoved {
+				continue
+			}
+			files = append(files, file)
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListPushCommits() function returns the SHAs of all commits in the range
+// (before, after] of a push, ordered from oldest to newest. When the push
+// creates a new ref (i.e. before is empty or all zeros), the commits listed
+// in the push event payload are used, unless the payload lists the maximum of
+// PushEventCommitsLimit commits and may therefore be truncated, in which case
+// the commits of the new ref are listed by listNewRefCommits().
+func ListPushCommits(ctx context.Context, client *github.Client, event github.PushEvent) (shas []string, e error) {
+	owner := event.GetRepo().GetOwner().GetLogin()
+	if owner == "" {
+		owner = event.GetRepo().GetOwner().GetName()
+	}
+	repo := event.GetRepo().GetName()
+	before := event.GetBefore()
+	after := event.GetAfter()
+
+	if before == "" || strings.Trim(before, "0") == "" {
+		if len(event.Commits) >= PushEventCommitsLimit {
+			return listNewRefCommits(ctx, client, owner, repo, event)
+		}
+		for _, commit := range event.Commits {
+			shas = append(shas, commit.GetID())
+		}
+		if len(shas) == 0 && after != "" {
+			shas = append(shas, after)
+		}
+		return
+	}
+
+	return compareCommits(ctx, client, owner, repo, before, after)
+}
+
+// compareCommits() function returns the SHAs of all commits that are
+// reachable from head but not from base, ordered from oldest to newest.
+func compareCommits(ctx context.Context, client *github.Client, owner, repo, base, head string) (shas []string, e error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		comparison, resp, err := client.Repositories.CompareCommits(ctx, owner, repo, base, head, opts)
+		if e = checkResponse(resp, err); e != nil {
+			e = errors.Wrapf(e, "failed to compare commits %s...%s", base, head)
+			return
+		}
+		for _, commit := range comparison.Commits {
+			shas = append(shas, commit.GetSHA())
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listNewRefCommits() function returns the SHAs of all commits of a push that
+// created a new ref, ordered from oldest to newest. The commits of a new
+// branch are the commits that are not on the default branch of the
+// repository, while every commit is listed when the push created the default
+// branch itself (e.g. the first push to a new repository).
+func listNewRefCommits(ctx context.Context, client *github.Client, owner, repo string, event github.PushEvent) (shas []string, e error) {
+	after := event.GetAfter()
+	default_branch := event.GetRepo().GetDefaultBranch()
+	if default_branch != "" && event.GetRef() != "refs/heads/"+default_branch {
+		shas, e = compareCommits(ctx, client, owner, repo, default_branch, after)
+		if e != nil {
+			e = errors.Wrapf(e, "failed to list the commits of new ref %s", event.GetRef())
+		}
+		return
+	}
+
+	opts := &github.CommitsListOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+		SHA:         after,
+	}
+	for {
+		commits, resp, err := client.Repositories.ListCommits(ctx, owner, repo, opts)
+		if e = checkResponse(resp, err); e != nil {
+			e = errors.Wrapf(e, "failed to list the commits of new ref %s", event.GetRef())
+			return
+		}
+		for _, commit := range commits {
+			shas = append(shas, commit.GetSHA())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	// the commits are listed from newest to oldest
+	for i, j := 0, len(shas)-1; i < j; i, j = i+1, j-1 {
+		shas[i], shas[j] = shas[j], shas[i]
+	}
+	return
+}
@@ -0,0 +1,113 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListPushCommits() unit test function tests that ListPushCommits()
+// completes the commits of a push that created a new ref when the commits
+// listed in the push event payload may be truncated.
+func TestListPushCommits(t *testing.T) {
+	t.Parallel()
+
+	// the commits of the repository, from oldest to newest
+	shas := make([]string, 30)
+	for i := range shas {
+		shas[i] = fmt.Sprintf("%040d", i+1)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/compare/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/compare/main..."+shas[29], r.URL.Path)
+		comparison := &github.CommitsComparison{}
+		for _, sha := range shas[5:] {
+			comparison.Commits = append(comparison.Commits, &github.RepositoryCommit{SHA: github.String(sha)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(comparison)
+	})
+	mux.HandleFunc("/repos/org/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, shas[29], r.URL.Query().Get("sha"))
+		// the commits are listed from newest to oldest in two pages
+		commits := make([]*github.RepositoryCommit, 0)
+		for i := len(shas) - 1; i >= 0; i-- {
+			commits = append(commits, &github.RepositoryCommit{SHA: github.String(shas[i])})
+		}
+		if r.URL.Query().Get("page") == "2" {
+			commits = commits[20:]
+		} else {
+			commits = commits[:20]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(commits)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	base_url, _ := url.Parse(server.URL + "/")
+	client.BaseURL = base_url
+
+	event := func(ref string, count int) github.PushEvent {
+		e := github.PushEvent{
+			After:  github.String(shas[29]),
+			Before: github.String("0000000000000000000000000000000000000000"),
+			Ref:    github.String(ref),
+			Repo: &github.PushEventRepository{
+				DefaultBranch: github.String("main"),
+				Name:          github.String("repo"),
+				Owner:         &github.User{Login: github.String("org")},
+			},
+		}
+		for _, sha := range shas[len(shas)-count:] {
+			e.Commits = append(e.Commits, &github.HeadCommit{ID: github.String(sha)})
+		}
+		return e
+	}
+
+	tests := []struct {
+		event    github.PushEvent
+		expected []string
+		name     string
+	}{
+		{
+			event:    event("refs/heads/feature", 3),
+			expected: shas[27:],
+			name:     "new_branch_payload",
+		},
+		{
+			event:    event("refs/heads/feature", PushEventCommitsLimit),
+			expected: shas[5:],
+			name:     "new_branch_truncated",
+		},
+		{
+			event:    event("refs/heads/main", PushEventCommitsLimit),
+			expected: shas,
+			name:     "new_default_branch_truncated",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commits, err := ListPushCommits(context.Background(), client, test.event)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, commits)
+		})
+	}
+
+	// the push fails if the truncated commits cannot be listed
+	failed := event("refs/heads/feature", PushEventCommitsLimit)
+	failed.Repo.Name = github.String("missing")
+	_, err := ListPushCommits(context.Background(), client, failed)
+	assert.Error(t, err)
+}
@@ -8,16 +8,20 @@ import (
 	"github.com/pkg/errors"
 )
 
-// ApplyLabelForIssueComment() method applies the specified label to the GitHub issue.
-func (cms *ClientManager) ApplyLabelForIssueComment(ctx context.Context, event github.IssueCommentEvent, label string) error {
-
+// ApplyLabelForIssue() method applies the specified label to the GitHub issue
+// or pull request with the given number, and removes the opposite label (e.g.
+// the clean label when PHI/PII was detected) if it was previously applied.
+func (cms *ClientManager) ApplyLabelForIssue(
+	ctx context.Context,
+	installationID int64,
+	repo *github.Repository,
+	issueNum int,
+	label string,
+) error {
 	if label == "" {
-		return errors.New("cannot apply empty label to issue comment")
+		return errors.New("cannot apply empty label to issue")
 	}
 
-	installationID := githubapp.GetInstallationIDFromEvent(&event)
-	issueNum := event.GetIssue().GetNumber()
-	repo := event.GetRepo()
 	repoOwner := repo.GetOwner().GetLogin()
 	repoName := repo.GetName()
 
@@ -29,6 +33,9 @@ func (cms *ClientManager) ApplyLabelForIssueComment(ctx context.Context, event g
 
 	labelsAdd := []string{label}
 	labelsRm := []string{}
+	if opposite := oppositeLabel(label); opposite != "" {
+		labelsRm = append(labelsRm, opposite)
+	}
 
 	ctx, logger := githubapp.PreparePRContext(ctx, installationID, repo, issueNum)
 
@@ -39,3 +46,14 @@ func (cms *ClientManager) ApplyLabelForIssueComment(ctx context.Context, event g
 
 	return nil
 }
+
+// ApplyLabelForIssueComment() method applies the specified label to the GitHub issue.
+func (cms *ClientManager) ApplyLabelForIssueComment(ctx context.Context, event github.IssueCommentEvent, label string) error {
+	if label == "" {
+		return errors.New("cannot apply empty label to issue comment")
+	}
+
+	installationID := githubapp.GetInstallationIDFromEvent(&event)
+
+	return cms.ApplyLabelForIssue(ctx, installationID, event.GetRepo(), event.GetIssue().GetNumber(), label)
+}
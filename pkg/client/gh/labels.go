@@ -127,7 +127,8 @@ func setRepoLabels(ctx context.Context, client *github.Client, owner string, rep
 }
 
 // updateIssueLabels() function updates the labels associated with a GitHub issue,
-// including the creation of any labels that don't already exist in the repo.
+// including the creation of any labels that don't already exist in the repo, and
+// removes the labelsToRemove that are currently applied to the issue.
 func updateIssueLabels(ctx context.Context, client *github.Client, owner string, repo string, issueNum int, labelsToAdd, labelsToRemove []string) error {
 	if len(labelsToAdd) == 0 && len(labelsToRemove) == 0 {
 		return errors.New("cannot apply labels : input label lists are empty")
@@ -166,7 +167,30 @@ func updateIssueLabels(ctx context.Context, client *github.Client, owner string,
 		log.Ctx(ctx).Debug().Msgf("no new labels to add for %s/%s#%d", owner, repo, issueNum)
 	}
 
-	// TODO : remove labelsToRemove from the issue
+	// remove each label of labelsToRemove that has been applied to the issue
+	for _, label := range labelsToRemove {
+		if !hasLabelName(issue.Labels, label) {
+			continue
+		}
+		resp, err = client.Issues.RemoveLabelForIssue(ctx, owner, repo, issueNum, label)
+		if err = checkResponse(resp, err); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
+
+// oppositeLabel() function returns the label that contradicts the given label,
+// i.e. the clean label for the dirty label and vice versa, which is removed
+// from an issue when the given label is applied. Returns an empty string for
+// any other label.
+func oppositeLabel(label string) string {
+	switch label {
+	case LabelCleanPHI:
+		return LabelDirtyPHI
+	case LabelDirtyPHI:
+		return LabelCleanPHI
+	}
+	return ""
+}
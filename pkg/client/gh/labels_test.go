@@ -0,0 +1,74 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_updateIssueLabels() unit test function tests that updateIssueLabels()
+// adds the new labels and removes the opposite label from the issue.
+func Test_updateIssueLabels(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		added   []string
+		removed []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/labels", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(generateLabels())
+	})
+	mux.HandleFunc("/repos/org/repo/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		name := LabelCleanPHI
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&github.Issue{Labels: []*github.Label{{Name: &name}}})
+	})
+	mux.HandleFunc("/repos/org/repo/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		var labels []string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&labels))
+		mu.Lock()
+		added = append(added, labels...)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*github.Label{})
+	})
+	mux.HandleFunc("/repos/org/repo/issues/1/labels/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		label, err := url.PathUnescape(r.URL.EscapedPath()[len("/repos/org/repo/issues/1/labels/"):])
+		require.NoError(t, err)
+		mu.Lock()
+		removed = append(removed, label)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*github.Label{})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	base_url, _ := url.Parse(server.URL + "/")
+	client.BaseURL = base_url
+
+	err := updateIssueLabels(context.Background(), client, "org", "repo", 1, []string{LabelDirtyPHI}, []string{oppositeLabel(LabelDirtyPHI)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{LabelDirtyPHI}, added)
+	assert.Equal(t, []string{LabelCleanPHI}, removed)
+
+	// a label that is not applied to the issue is not removed
+	added, removed = nil, nil
+	err = updateIssueLabels(context.Background(), client, "org", "repo", 1, []string{LabelCleanPHI}, []string{oppositeLabel(LabelCleanPHI)})
+	require.NoError(t, err)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+
+	assert.Equal(t, "", oppositeLabel("other"))
+}
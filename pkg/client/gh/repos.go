@@ -0,0 +1,96 @@
+package gh
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/pkg/errors"
+)
+
+// RepoFilter struct contains the criteria used by FilterRepos() to select the
+// repositories of an organization that should be scanned.
+type RepoFilter struct {
+	// IgnoreRepositories is a list of repositories to exclude, where each
+	// entry is in the format "<owner>/<repo>" and is compared ignoring case.
+	IgnoreRepositories []string
+	// IgnoreTopics is a list of topics, where any repository that has at
+	// least one of the topics is excluded.
+	IgnoreTopics []string
+	// IncludeArchived controls whether archived repositories are included.
+	IncludeArchived bool
+	// IncludeForks controls whether forked repositories are included.
+	IncludeForks bool
+	// Topics is a list of topics, where only repositories that have at least
+	// one of the topics are included, unless the list is empty.
+	Topics []string
+}
+
+// FilterRepos() function returns the subset of the input repositories that
+// match the criteria of the RepoFilter, in the same order as the input.
+func FilterRepos(repos []*github.Repository, filter RepoFilter) []*github.Repository {
+	ignore := make(map[string]bool, len(filter.IgnoreRepositories))
+	for _, full_name := range filter.IgnoreRepositories {
+		ignore[strings.ToLower(full_name)] = true
+	}
+
+	filtered := []*github.Repository{}
+	for _, repo := range repos {
+		if ignore[strings.ToLower(repo.GetFullName())] {
+			continue
+		}
+		if repo.GetArchived() && !filter.IncludeArchived {
+			continue
+		}
+		if repo.GetFork() && !filter.IncludeForks {
+			continue
+		}
+		if hasAnyTopic(repo, filter.IgnoreTopics) {
+			continue
+		}
+		if len(filter.Topics) > 0 && !hasAnyTopic(repo, filter.Topics) {
+			continue
+		}
+		filtered = append(filtered, repo)
+	}
+	return filtered
+}
+
+// ListOrgRepos() function returns all repositories of the GitHub organization,
+// following the pagination of the GitHub API until the last page is read.
+func ListOrgRepos(ctx context.Context, client *github.Client, org string) (repos []*github.Repository, e error) {
+	if org == "" {
+		e = errors.New("cannot list repositories of organization with empty name")
+		return
+	}
+	opts := &github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+		Sort:        "full_name",
+		Type:        "all",
+	}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, org, opts)
+		if e = checkResponse(resp, err); e != nil {
+			e = errors.Wrapf(e, "failed to list repositories of organization %s", org)
+			return
+		}
+		repos = append(repos, page...)
+		if resp.NextPage == 0 {
+			return
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// hasAnyTopic() function returns true if the repository has at least one of
+// the topics, which are compared ignoring case.
+func hasAnyTopic(repo *github.Repository, topics []string) bool {
+	for _, repo_topic := range repo.Topics {
+		for _, topic := range topics {
+			if strings.EqualFold(repo_topic, topic) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+)
+
+// newTestOrgServer() function returns a local stand-in for the GitHub API,
+// which serves the repositories of the organization in pages of page_size.
+func newTestOrgServer(t *testing.T, org string, repos []*github.Repository, page_size int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/"+org+"/repos", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, _ = strconv.Atoi(p)
+		}
+		start := (page - 1) * page_size
+		end := start + page_size
+		if end >= len(repos) {
+			end = len(repos)
+		} else {
+			next := *r.URL
+			query := next.Query()
+			query.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = query.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.RequestURI()))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(repos[start:end]); err != nil {
+			t.Error(err)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestRepo(full_name string, archived bool, fork bool, topics ...string) *github.Repository {
+	return &github.Repository{
+		Archived: &archived,
+		Fork:     &fork,
+		FullName: &full_name,
+		Topics:   topics,
+	}
+}
+
+func TestFilterRepos(t *testing.T) {
+	repos := []*github.Repository{
+		newTestRepo("org/active", false, false, "phi"),
+		newTestRepo("org/archived", true, false, "phi"),
+		newTestRepo("org/fork", false, true),
+		newTestRepo("org/Ignored", false, false, "phi"),
+		newTestRepo("org/no-topics", false, false),
+		newTestRepo("org/sandbox", false, false, "phi", "sandbox"),
+	}
+	names := func(repos []*github.Repository) (out []string) {
+		for _, repo := range repos {
+			out = append(out, repo.GetFullName())
+		}
+		return
+	}
+
+	tests := []struct {
+		name   string
+		filter RepoFilter
+		want   []string
+	}{
+		{
+			name:   "default",
+			filter: RepoFilter{},
+			want:   []string{"org/active", "org/Ignored", "org/no-topics", "org/sandbox"},
+		},
+		{
+			name:   "include archived and forks",
+			filter: RepoFilter{IncludeArchived: true, IncludeForks: true},
+			want:   []string{"org/active", "org/archived", "org/fork", "org/Ignored", "org/no-topics", "org/sandbox"},
+		},
+		{
+			name:   "ignore repositories",
+			filter: RepoFilter{IgnoreRepositories: []string{"org/ignored", "org/sandbox"}},
+			want:   []string{"org/active", "org/no-topics"},
+		},
+		{
+			name:   "topics",
+			filter: RepoFilter{IgnoreTopics: []string{"SANDBOX"}, Topics: []string{"phi"}},
+			want:   []string{"org/active", "org/Ignored"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, names(FilterRepos(repos, test.filter)))
+		})
+	}
+}
+
+func TestListOrgRepos(t *testing.T) {
+	var repos []*github.Repository
+	for i := 0; i < 7; i++ {
+		repos = append(repos, newTestRepo(fmt.Sprintf("org/repo-%d", i), false, false))
+	}
+	server := newTestOrgServer(t, "org", repos, 3)
+
+	config := cfg.NewDefaultConfig()
+	config.GitHub.V3APIURL = server.URL
+	cm, err := NewClientManager(config)
+	require.NoError(t, err)
+	client, err := cm.NewCLIClient()
+	require.NoError(t, err)
+	expected_url, _ := url.Parse(server.URL + "/")
+	assert.Equal(t, expected_url, client.BaseURL)
+
+	listed, err := ListOrgRepos(context.Background(), client, "org")
+	require.NoError(t, err)
+	require.Len(t, listed, len(repos))
+	for i, repo := range listed {
+		assert.Equal(t, repos[i].GetFullName(), repo.GetFullName())
+	}
+
+	_, err = ListOrgRepos(context.Background(), client, "")
+	assert.Error(t, err)
+	_, err = ListOrgRepos(context.Background(), client, "missing")
+	assert.Error(t, err)
+}
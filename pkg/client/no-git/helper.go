@@ -28,6 +28,21 @@ func ParseOrgNameFromURL(url_in string) (string, error) {
 	return owner_name, nil
 }
 
+// ParseOrgRepoNameFromURL() function is used to parse a name in the format
+// "<org>_<repo>" from a GitHub repository URL, which is used as the base name
+// of any files that store data about the repository in the work dir.
+func ParseOrgRepoNameFromURL(url_in string) (string, error) {
+	org_name, err := ParseOrgNameFromURL(url_in)
+	if err != nil {
+		return "", err
+	}
+	repo_name, err := ParseRepoNameFromURL(url_in)
+	if err != nil {
+		return "", err
+	}
+	return org_name + "_" + repo_name, nil
+}
+
 // ParseRepoNameFromURL() function is used to parse the repository name
 // from a GitHub repository URL.
 func ParseRepoNameFromURL(url_in string) (string, error) {
@@ -115,3 +115,34 @@ func TestParseRepoNameFromURL(t *testing.T) {
 		}
 	}
 }
+
+func TestParseOrgRepoNameFromURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+		wantErr  bool
+	}{
+		{
+			url:      "git@github.com:example-org/repo.git",
+			expected: "example-org_repo",
+		},
+		{
+			url:      "https://github.com/username/repo",
+			expected: "username_repo",
+		},
+		{
+			url:     "https://github.com/repo",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseOrgRepoNameFromURL(test.url)
+		if actual != test.expected {
+			t.Errorf("ParseOrgRepoNameFromURL(%s) = %s, expected %s", test.url, actual, test.expected)
+		}
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseOrgRepoNameFromURL(%s) error = %v, wantErr %v", test.url, err, test.wantErr)
+		}
+	}
+}
@@ -11,6 +11,12 @@ func (m *Manager) runCLI() (e error) {
 	case cfg.CommandRunListOrgRepos:
 		e = m.commandListOrgRepos()
 		return
+	case cfg.CommandRunReport:
+		e = m.commandReport()
+		return
+	case cfg.CommandRunRetryFailed:
+		e = m.commandRetryFailed()
+		return
 	case cfg.CommandRunScanOrg:
 		e = m.commandScanOrg()
 		return
@@ -1,16 +1,25 @@
 package manager
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
-	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
-	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/dryrun"
-	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/detector"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/multi"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/report"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/store"
 )
 
 // commandHelp() method is used to run the "help" (default) command.
@@ -23,15 +32,23 @@ func (m *Manager) commandHelp() (e error) {
 	)
 	printNameAndDescription(
 		cfg.CommandRunListOrgRepos,
-		"... not yet implemented ...",
+		"Lists the repositories of the configured organization that would be scanned.",
+	)
+	printNameAndDescription(
+		cfg.CommandRunReport,
+		"Writes reports of the stored results for the configured repositories.",
+	)
+	printNameAndDescription(
+		cfg.CommandRunRetryFailed,
+		"Retries the failed chunks of the last scan of each configured repository.",
 	)
 	printNameAndDescription(
 		cfg.CommandRunScanOrg,
-		"... not yet implemented ...",
+		"Scans the repositories of the configured organization, plus the configured repositories.",
 	)
 	printNameAndDescription(
 		cfg.CommandRunScanRepos,
-		"... work in progress ...",
+		"Scans the configured repositories in parallel.",
 	)
 	printNameAndDescription(
 		cfg.CommandRunScanTest,
@@ -41,6 +58,31 @@ func (m *Manager) commandHelp() (e error) {
 		cfg.CommandRunVersion,
 		"Prints version information for the app.",
 	)
+	fmt.Println("\tScan Flags:")
+	printNameAndDescription(
+		"--branch <ref>",
+		"Scans the commits reachable from the branch (or other ref).",
+	)
+	printNameAndDescription(
+		"--error-policy <policy>",
+		"Continues (continue), stops (fail-fast), or stops after N (max-errors=N) recoverable scan errors.",
+	)
+	printNameAndDescription(
+		"--head-only",
+		"Scans only the tree of the HEAD commit.",
+	)
+	printNameAndDescription(
+		"--range <from>..<to>",
+		"Scans the commits reachable from <to> but not from <from>, e.g. a pull request.",
+	)
+	printNameAndDescription(
+		"--resume <force|discard|fail>",
+		"Resumes (force), starts over (discard), or fails (fail) when the checkpoint of a previous scan has a different config or tip commit.",
+	)
+	printNameAndDescription(
+		"--since <commit>",
+		"Scans the commits that are not reachable from the commit, e.g. the last scanned commit.",
+	)
 	fmt.Println("\tEnvironment Variables:")
 	for _, envVar := range cfg.GetAppEnvVars() {
 		printNameAndDescription(envVar, "")
@@ -48,97 +90,381 @@ func (m *Manager) commandHelp() (e error) {
 	return
 }
 
-// commandListOrgRepos() method is used to run the "list-org-repos" command.
+// commandListOrgRepos() method is used to run the "list-org-repos" command,
+// which prints the URL of each repository that would be scanned by the
+// "scan-org" command.
 func (m *Manager) commandListOrgRepos() (e error) {
-	m.logger.Warn().Msgf("%s commmand is TODO\n", cfg.CommandRunListOrgRepos)
+	var repo_urls []string
+	repo_urls, e = m.listOrgRepoURLs()
+	if e != nil {
+		e = errors.Wrapf(e, "failed to run command '%s'", m.config.Command.Run)
+		return
+	}
+	for _, repo_url := range repo_urls {
+		fmt.Println(repo_url)
+	}
 	return
 }
 
+// commandReport() method is used to run the "report" command, which writes
+// reports of the results stored by previous scans of each configured
+// repository, in each of the configured formats.
+func (m *Manager) commandReport() (e error) {
+	if m.config.ResultStore == cfg.ResultStoreMemory {
+		e = errors.Errorf("command %s requires a persistent result store, not '%s'", m.config.Command.Run, m.config.ResultStore)
+		return
+	}
+	if len(m.config.Git.Scan.Repositories) == 0 {
+		e = errors.New("no repositories specified for report")
+		return
+	}
+
+	for _, repo_url := range m.config.Git.Scan.Repositories {
+		if e = m.reportRepository(repo_url, m.config.ResultStore); e != nil {
+			return
+		}
+	}
+	return
+}
+
+// commandRetryFailed() method is used to run the "retry-failed" command, which
+// sends new requests for only the chunks that failed in the last scan of each
+// configured repository, and skips the repositories without failed chunks.
+func (m *Manager) commandRetryFailed() (e error) {
+	if m.config.ResultStore == cfg.ResultStoreMemory {
+		e = errors.Errorf("command %s requires a persistent result store, not '%s'", m.config.Command.Run, m.config.ResultStore)
+		return
+	}
+	var configured_urls []string
+	configured_urls, e = m.appendConfiguredRepoURLs(nil, make(map[string]bool))
+	if e != nil {
+		e = errors.Wrapf(e, "failed to run command '%s'", m.config.Command.Run)
+		return
+	}
+	var repo_urls []string
+	for _, repo_url := range configured_urls {
+		chunks, chunks_err := scanner.FailedChunksGet(m.ctx, m.config.Git.WorkDir, repo_url)
+		if chunks_err != nil {
+			e = errors.Wrapf(chunks_err, "failed to run command '%s'", m.config.Command.Run)
+			return
+		}
+		if len(chunks) == 0 {
+			m.logger.Info().Msgf("no failed chunks to retry for repository %s", repo_url)
+			continue
+		}
+		repo_urls = append(repo_urls, repo_url)
+	}
+	if len(repo_urls) == 0 {
+		m.logger.Info().Msg("no failed chunks to retry")
+		return
+	}
+
+	var d detector.Detector
+	d, e = detector.New(m.ctx, m.config)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize detector for command %s", m.config.Command.Run)
+		return
+	}
+
+	return m.scanRepositories(d, m.config.Detector, m.config.ResultStore, repo_urls, true)
+}
+
 // commandScanOrg() method is used to run the "scan-org" command, which
 // is applies the "scan-repos" command to all repositories in the organization.
 func (m *Manager) commandScanOrg() (e error) {
-	m.logger.Warn().Msgf("%s commmand is TODO\n", cfg.CommandRunScanOrg)
-	return
+	var repo_urls []string
+	repo_urls, e = m.listOrgRepoURLs()
+	if e != nil {
+		e = errors.Wrapf(e, "failed to run command '%s'", m.config.Command.Run)
+		return
+	}
+	if len(repo_urls) == 0 {
+		m.logger.Warn().Msgf("no repositories to scan in organization %s", m.config.Git.Scan.Organization)
+		return
+	}
+
+	var d detector.Detector
+	d, e = detector.New(m.ctx, m.config)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize detector for command %s", m.config.Command.Run)
+		return
+	}
+
+	return m.scanRepositories(d, m.config.Detector, m.config.ResultStore, repo_urls, false)
 }
 
 // commandScanRepos() method is used to run the "scan-repos" command, which
-// is used to scan the contents of a single git repository for PHI/PII.
+// is used to scan the contents of the configured git repositories for PHI/PII
+// using the detector selected by the config.
 func (m *Manager) commandScanRepos() (e error) {
-	m.scanner, e = scanner.NewScanner(m.ctx, &m.config.Git, memory.NewMemoryResultRecordIO(m.ctx))
+	var d detector.Detector
+	d, e = detector.New(m.ctx, m.config)
 	if e != nil {
-		e = errors.Wrapf(e, "failed to initialize new Scanner for command %s", m.config.Command.Run)
+		e = errors.Wrapf(e, "failed to initialize detector for command %s", m.config.Command.Run)
 		return
 	}
 
-	if len(m.config.Git.Scan.Repositories) == 0 {
-		e = errors.New("no repositories specified for scan")
+	return m.scanConfiguredRepositories(d, m.config.Detector, m.config.ResultStore)
+}
+
+// commandScanTest() method is used to run the "scan-test" command, which is
+// for development use only and always uses the dry run detector and the
+// memory store, such that dummy results are never persisted.
+func (m *Manager) commandScanTest() (e error) {
+	var d detector.Detector
+	d, e = detector.NewByName(m.ctx, cfg.DetectorDryRun, m.config)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize detector for command %s", m.config.Command.Run)
 		return
 	}
 
-	repo_url := m.config.Git.Scan.Repositories[0]
-	// clone the repository
-	repository, repository_err := m.git_manager.CloneRepo(repo_url)
-	if repository_err != nil {
-		e = errors.Wrap(repository_err, ErrMsgCloneRepository)
+	return m.scanConfiguredRepositories(d, cfg.DetectorDryRun, cfg.ResultStoreMemory)
+}
+
+// commandVersion() method is used to run the "version" command, which prints
+// the version information for the app and then exits.
+func (m *Manager) commandVersion() (e error) {
+	fmt.Printf("%s %s\n", m.config.App.Name, cfg.AppVersion)
+	return
+}
+
+// listOrgRepoURLs() method returns the URLs of the repositories to scan for
+// the configured organization, which are the repositories of the organization
+// that match the configured filters, plus the configured repositories, minus
+// any duplicates and ignored repositories.
+func (m *Manager) listOrgRepoURLs() (repo_urls []string, e error) {
+	if m.config.Git.Scan.Organization == "" {
+		e = errors.New("missing required config value: git.scan.organization")
+		return
+	}
+	org_name, org_err := nogit.ParseOrgNameFromURL(m.config.Git.Scan.Organization)
+	if org_err != nil {
+		e = errors.Wrapf(org_err, "invalid config value: git.scan.organization = %s", m.config.Git.Scan.Organization)
+		return
+	}
+
+	client_manager, cm_err := gh.NewClientManager(m.config)
+	if cm_err != nil {
+		e = errors.Wrap(cm_err, "failed to initialize GitHub client manager")
 		return
 	}
+	client, client_err := client_manager.NewCLIClient()
+	if client_err != nil {
+		e = errors.Wrap(client_err, "failed to initialize GitHub client")
+		return
+	}
+
+	repos, list_err := gh.ListOrgRepos(m.ctx, client, org_name)
+	if list_err != nil {
+		e = list_err
+		return
+	}
+	repos = gh.FilterRepos(repos, gh.RepoFilter{
+		IgnoreRepositories: m.config.Git.Scan.IgnoreRepositories,
+		IgnoreTopics:       m.config.Git.Scan.IgnoreTopics,
+		IncludeArchived:    m.config.Git.Scan.IncludeArchived,
+		IncludeForks:       m.config.Git.Scan.IncludeForks,
+		Topics:             m.config.Git.Scan.Topics,
+	})
+	m.logger.Debug().Msgf("found %d repositories to scan in organization %s", len(repos), org_name)
 
-	var ai *az.EntityDetectionAI
-	ai, e = az.NewEntityDetectionAI(m.config)
+	// track the "<owner>/<repo>" name of each repository to remove duplicates
+	// from the configured repositories
+	seen := make(map[string]bool)
+	for _, repo := range repos {
+		seen[strings.ToLower(repo.GetFullName())] = true
+		if m.config.Git.Auth.SSHKeyPath != "" {
+			repo_urls = append(repo_urls, repo.GetSSHURL())
+		} else {
+			repo_urls = append(repo_urls, repo.GetCloneURL())
+		}
+	}
+	return m.appendConfiguredRepoURLs(repo_urls, seen)
+}
+
+// appendConfiguredRepoURLs() method appends the URLs of the configured
+// repositories to repo_urls, skipping any repositories that are ignored by
+// the config, or whose "<owner>/<repo>" name (in lower case) is in seen.
+func (m *Manager) appendConfiguredRepoURLs(repo_urls []string, seen map[string]bool) ([]string, error) {
+	ignore := make(map[string]bool, len(m.config.Git.Scan.IgnoreRepositories))
+	for _, full_name := range m.config.Git.Scan.IgnoreRepositories {
+		ignore[strings.ToLower(full_name)] = true
+	}
+	for _, repo_url := range m.config.Git.Scan.Repositories {
+		owner_name, owner_err := nogit.ParseOrgNameFromURL(repo_url)
+		if owner_err != nil {
+			return repo_urls, owner_err
+		}
+		repo_name, repo_err := nogit.ParseRepoNameFromURL(repo_url)
+		if repo_err != nil {
+			return repo_urls, repo_err
+		}
+		full_name := strings.ToLower(owner_name + "/" + repo_name)
+		if ignore[full_name] || seen[full_name] {
+			continue
+		}
+		seen[full_name] = true
+		repo_urls = append(repo_urls, repo_url)
+	}
+	return repo_urls, nil
+}
+
+// scanConfiguredRepositories() method scans the configured repositories,
+// minus any duplicates and ignored repositories, with the provided detector,
+// where detector_name is the name of the detector.
+func (m *Manager) scanConfiguredRepositories(d detector.Detector, detector_name string, result_store string) (e error) {
+	var repo_urls []string
+	repo_urls, e = m.appendConfiguredRepoURLs(nil, make(map[string]bool))
 	if e != nil {
-		e = errors.Wrapf(e, "failed to initialize new EntityDetectionAI for command %s", m.config.Command.Run)
+		e = errors.Wrapf(e, "failed to run command '%s'", m.config.Command.Run)
+		return
+	}
+	if len(repo_urls) == 0 {
+		e = errors.New("no repositories specified for scan")
 		return
 	}
-	az_ai_detector := az.NewAzAiLanguagePhiDetector(ai)
+	return m.scanRepositories(d, detector_name, result_store, repo_urls, false)
+}
 
-	// create channels for scanner errors, requests, and responses
-	chan_scan_errors := make(chan error)
-	chan_requests := make(chan rrr.Request)
-	chan_responses := make(chan rrr.Response)
+// scanRepositories() method scans the repositories in parallel, using up to
+// the configured number of concurrent repositories, where every repository
+// shares the provided detector and its global budget of outstanding requests.
+// Prints a summary of the scan of each repository when all scans are done,
+// and returns an error if the scan of any repository failed. If failed_only
+// is true, then only the failed chunks of the last scan of each repository
+// are retried.
+func (m *Manager) scanRepositories(d detector.Detector, detector_name string, result_store string, repo_urls []string, failed_only bool) (e error) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
 
-	// Scan the respository in a goroutine that writes errors to chan_scan_errors,
-	// writes requests to chan_requests, and reads responses from chan_responses
-	go m.scanner.Scan(scanner.ScanInput{
-		ChanErrorsSend:      chan_scan_errors,
-		ChanRequestSend:     chan_requests,
-		ChanResponseReceive: chan_responses,
-		RepoID:              repo_url,
-		Repository:          repository,
-	})
-	// Run the AI detector in a goroutine that reads requests from chan_requests
-	// and writes responses to chan_responses
-	go az_ai_detector.Run(
-		m.ctx,
-		chan_requests,
-		chan_responses,
-	)
+	// create the checkpoint store, which is shared by the scans of all
+	// repositories
+	checkpoint_store, checkpoint_err := store.NewCheckpointStore(ctx, m.config)
+	if checkpoint_err != nil {
+		e = errors.Wrapf(checkpoint_err, "failed to run command '%s'", m.config.Command.Run)
+		return
+	}
+	if closer, ok := checkpoint_store.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				m.logger.Error().Err(err).Msg("failed to close checkpoint store")
+			}
+		}()
+	}
 
-	// wait for an error to be returned from the scanner
-	e = <-chan_scan_errors
+	// run the detector via a router that routes the responses of the
+	// detector back to the scanner of each repository, where the router is
+	// only started once the first repository is cloned and its scanner is
+	// registered, such that the detector is not left idle during the clone
+	router := multi.NewRouter(ctx, m.config.Git.Scan.Limits.MaxRequestsGlobal)
+	router_once := &sync.Once{}
+	startRouter := func() {
+		router_once.Do(func() { go router.Run(d) })
+	}
+
+	var summaries []multi.Summary
+	summaries, e = multi.Run(
+		ctx,
+		repo_urls,
+		m.config.Git.Scan.Limits.MaxConcurrentRepositories,
+		func(ctx context.Context, repo_url string) (multi.Summary, error) {
+			return m.scanRepository(ctx, router, startRouter, checkpoint_store, detector_name, result_store, repo_url, failed_only)
+		},
+	)
 	if e != nil {
-		e = errors.Wrapf(e, "failed to run command '%s' ", m.config.Command.Run)
+		e = errors.Wrapf(e, "failed to run command '%s'", m.config.Command.Run)
+		return
+	}
+	for _, summary := range summaries {
+		if summary.Error != nil {
+			m.logger.Error().Err(summary.Error).Msgf("failed to scan repository %s", summary.RepoURL)
+		}
+	}
+	if e = multi.WriteSummaries(os.Stdout, summaries); e != nil {
+		e = errors.Wrap(e, "failed to write scan summary")
 		return
 	}
-	m.logger.Info().Msgf("command '%s' completed successfully", m.config.Command.Run)
 
+	if failed := multi.Failed(summaries); failed > 0 {
+		e = errors.Errorf("failed to run command '%s' : %d of %d repositories failed", m.config.Command.Run, failed, len(summaries))
+		return
+	}
+	m.logger.Info().Msgf("command '%s' completed successfully", m.config.Command.Run)
 	return
 }
 
-// commandScanTest() method is used to run the "scan-test" command, which is
-// for development use only.
-func (m *Manager) commandScanTest() (e error) {
-	m.scanner, e = scanner.NewScanner(m.ctx, &m.config.Git, memory.NewMemoryResultRecordIO(m.ctx))
-	if e != nil {
-		e = errors.Wrapf(e, "failed to initialize new Scanner for command %s", m.config.Command.Run)
+// scanRepository() method clones the repository and scans it with its own
+// Scanner, which sends requests to the detector via the router, saves
+// checkpoints to the checkpoint store, and writes results to the named result
+// store, then waits for the scan to complete and writes reports of the
+// results. The router is started with startRouter once the Scanner is
+// registered. If failed_only is true, then the Scanner retries only the
+// failed chunks of the last scan of the repository.
+func (m *Manager) scanRepository(ctx context.Context, router *multi.Router, startRouter func(), checkpoint_store scanner.CheckpointStore, detector_name string, result_store string, repo_url string, failed_only bool) (summary multi.Summary, e error) {
+	logger := m.logger.With().Str("repository", repo_url).Logger()
+	// the scan of each repository has its own context, which is canceled to
+	// stop the goroutines of the Scanner when the scan returns, e.g. due to
+	// an error of the scan
+	ctx, cancel := context.WithCancel(logger.WithContext(ctx))
+	defer cancel()
+
+	// create the result store for the repository
+	store_config := *m.config
+	store_config.ResultStore = result_store
+	result_io, store_err := store.New(ctx, &store_config, repo_url)
+	if store_err != nil {
+		e = errors.Wrapf(store_err, "failed to initialize result store for repository %s", repo_url)
 		return
 	}
+	if closer, ok := result_io.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				logger.Error().Err(err).Msg("failed to close result store")
+			}
+		}()
+	}
 
-	if len(m.config.Git.Scan.Repositories) == 0 {
-		e = errors.New("no repositories specified for scan")
+	s, scanner_err := scanner.NewScanner(ctx, &m.config.Git, result_io)
+	if scanner_err != nil {
+		e = errors.Wrapf(scanner_err, "failed to initialize new Scanner for repository %s", repo_url)
 		return
 	}
+	s.SetCheckpointStore(checkpoint_store)
+
+	// keep the keys of the trackers of the Scanner in the tracker store of
+	// the repository, unless only the failed chunks of the last scan are
+	// retried, which tracks too few keys to need a tracker store
+	if !failed_only {
+		key_store, key_store_err := store.NewKeyStore(ctx, m.config, repo_url)
+		if key_store_err != nil {
+			e = errors.Wrapf(key_store_err, "failed to initialize tracker store for repository %s", repo_url)
+			return
+		}
+		if key_store != nil {
+			if closer, ok := key_store.(io.Closer); ok {
+				defer func() {
+					if err := closer.Close(); err != nil {
+						logger.Error().Err(err).Msg("failed to close tracker store")
+					}
+				}()
+			}
+			if err := s.SetTrackerStore(key_store); err != nil {
+				e = errors.Wrapf(err, "failed to initialize tracker store for repository %s", repo_url)
+				return
+			}
+		}
+	}
+
+	var chunks []scanner.FailedChunk
+	if failed_only {
+		var chunks_err error
+		chunks, chunks_err = scanner.FailedChunksGet(ctx, m.config.Git.WorkDir, repo_url)
+		if chunks_err != nil {
+			e = errors.Wrapf(chunks_err, "failed to read failed chunks of repository %s", repo_url)
+			return
+		}
+	}
 
-	repo_url := m.config.Git.Scan.Repositories[0]
 	// clone the repository
 	repository, repository_err := m.git_manager.CloneRepo(repo_url)
 	if repository_err != nil {
@@ -146,44 +472,128 @@ func (m *Manager) commandScanTest() (e error) {
 		return
 	}
 
-	dry_run_detector := dryrun.NewDryRunPhiDetector()
-
-	chan_scan_errors := make(chan error)
-	chan_requests := make(chan rrr.Request)
-	chan_responses := make(chan rrr.Response)
+	// register a route for the requests of the Scanner, which are identified
+	// by the ID of the repository
+	repo_id := rrr.RepositoryID(repo_url)
+	route, route_err := router.Register(repo_id)
+	if route_err != nil {
+		e = errors.Wrapf(route_err, "failed to route requests for repository %s", repo_url)
+		return
+	}
+	startRouter()
 
 	// Scan the respository in a goroutine that writes errors to chan_scan_errors,
-	// writes requests to chan_requests, and reads responses from chan_responses
-	go m.scanner.Scan(scanner.ScanInput{
+	// writes requests to the route, and reads responses from the route
+	chan_scan_errors := make(chan error)
+	scan_input := scanner.ScanInput{
 		ChanErrorsSend:      chan_scan_errors,
-		ChanRequestSend:     chan_requests,
-		ChanResponseReceive: chan_responses,
+		ChanRequestSend:     route.ChanRequests,
+		ChanResponseReceive: route.ChanResponses,
+		Config:              m.config,
+		Detector:            detector_name,
 		RepoID:              repo_url,
 		Repository:          repository,
-	})
-	// Run the AI detector in a goroutine that reads requests from chan_requests
-	// and writes responses to chan_responses
-	go dry_run_detector.Run(
-		m.ctx,
-		chan_requests,
-		chan_responses,
-	)
+	}
+	chan_scan_returned := make(chan struct{})
+	go func() {
+		defer close(chan_scan_returned)
+		if failed_only {
+			s.RetryFailed(scan_input, chunks)
+		} else {
+			s.Scan(scan_input)
+		}
+	}()
+	// stop the Scanner and wait for it to return before the route and the
+	// stores of the repository are closed by the deferred calls above
+	defer func() {
+		cancel()
+		<-chan_scan_returned
+		router.Unregister(repo_id)
+	}()
 
 	// wait for an error to be returned from the scanner
-	e = <-chan_scan_errors
+	select {
+	case <-ctx.Done():
+		e = ctx.Err()
+	case e = <-chan_scan_errors:
+	}
+	scan_summary := s.Summary()
+	summary.Commits = scan_summary.Commits
+	summary.Files = scan_summary.Files
+	summary.Recovered = scan_summary.RecoverableCount
+	summary.Requests = scan_summary.Requests
+	for _, err := range scan_summary.Recoverable {
+		logger.Warn().Err(err).Msgf("scan of repository %s continued after recoverable error", repo_url)
+	}
 	if e != nil {
-		e = errors.Wrapf(e, "failed to run command '%s' ", m.config.Command.Run)
+		e = errors.Wrapf(e, "failed to scan repository %s", repo_url)
 		return
 	}
-	m.logger.Info().Msgf("command '%s' completed successfully", m.config.Command.Run)
+	logger.Info().Msgf("scan of repository %s completed successfully", repo_url)
+	if failed := scan_summary.FailedChunks; failed > 0 {
+		logger.Warn().Msgf(
+			"%d chunks of repository %s failed, run the '%s' command to retry them",
+			failed,
+			repo_url,
+			cfg.CommandRunRetryFailed,
+		)
+	}
 
+	records, list_err := result_io.List()
+	if list_err != nil {
+		e = errors.Wrapf(list_err, "failed to list results for repository %s", repo_url)
+		return
+	}
+	summary.Results = len(records)
+
+	e = m.writeReports(repo_url, result_io)
 	return
 }
 
-// commandVersion() method is used to run the "version" command, which prints
-// the version information for the app and then exits.
-func (m *Manager) commandVersion() (e error) {
-	fmt.Printf("%s %s\n", m.config.App.Name, cfg.AppVersion)
+// reportRepository() method opens the named result store of the repository
+// and writes reports of the results in the store.
+func (m *Manager) reportRepository(repo_url string, result_store string) (e error) {
+	store_config := *m.config
+	store_config.ResultStore = result_store
+	result_io, store_err := store.New(m.ctx, &store_config, repo_url)
+	if store_err != nil {
+		e = errors.Wrapf(store_err, "failed to open result store for repository %s", repo_url)
+		return
+	}
+	if closer, ok := result_io.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return m.writeReports(repo_url, result_io)
+}
+
+// writeReports() method writes reports of all results in the result store of
+// the repository, in each of the configured formats, to the configured
+// report output directory.
+func (m *Manager) writeReports(repo_url string, result_io rrr.ResultRecordIO) (e error) {
+	records, list_err := result_io.List()
+	if list_err != nil {
+		e = errors.Wrapf(list_err, "failed to list results for repository %s", repo_url)
+		return
+	}
+	name, name_err := nogit.ParseOrgRepoNameFromURL(repo_url)
+	if name_err != nil {
+		e = errors.Wrapf(name_err, "failed to write reports for repository %s", repo_url)
+		return
+	}
+	output_dir := m.config.Report.OutputDir
+	if output_dir == "" {
+		output_dir = filepath.Join(m.config.Git.WorkDir, cfg.WorkDirReports)
+	}
+
+	r := report.NewReport(repo_url, records, m.config.Report.Unmask)
+	paths, write_err := r.WriteFiles(output_dir, name, m.config.Report.Formats)
+	if write_err != nil {
+		e = errors.Wrapf(write_err, "failed to write reports for repository %s", repo_url)
+		return
+	}
+	for _, path := range paths {
+		m.logger.Info().Msgf("wrote report with %d finding(s) to %s", r.Total, path)
+	}
 	return
 }
 
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+)
+
+// commentActionInput struct contains the details of a comment in which PHI/PII
+// was detected, as required by the applyCommentAction() function.
+type commentActionInput struct {
+	// Action is the configured action, i.e. the value of cfg.GitHubConfig.Action
+	Action     string
+	CommentID  int64
+	CommentURL string
+	Entities   map[string][]az.Entity
+	// Field is the body of the comment, as passed to TextFieldsToDocuments()
+	Field          TextField
+	InstallationID int64
+	// IsReviewComment is true for comments on the diff of a pull request,
+	// which are edited through a different API than issue comments.
+	IsReviewComment bool
+	NodeID          string
+	Owner           string
+	Repo            string
+}
+
+// applyCommentAction() function redacts or minimizes the comment, depending on
+// the configured action, and writes an audit log entry for the action. The
+// audit log entry records the categories of the detected entities, but never
+// the detected text.
+//
+// GitHub keeps the original body of an edited comment in the edit history of
+// the comment, which can be read by anyone with read access to the
+// repository, so a redacted comment is also minimized, and the audit log entry
+// records that the original text remains in the edit history.
+func applyCommentAction(ctx context.Context, ghcm *gh.ClientManager, in commentActionInput) error {
+	if len(in.Entities) == 0 {
+		return nil
+	}
+
+	switch in.Action {
+	case cfg.GitHubActionMinimize:
+		client, err := ghcm.NewInstallationV4Client(in.InstallationID)
+		if err != nil {
+			return err
+		}
+		if err := gh.MinimizeComment(ctx, client, in.NodeID); err != nil {
+			return err
+		}
+	case cfg.GitHubActionRedact:
+		client, err := ghcm.NewInstallationClient(in.InstallationID)
+		if err != nil {
+			return err
+		}
+		body := redactTextField(in.Field, in.Entities)
+		if in.IsReviewComment {
+			err = gh.EditPullRequestReviewComment(ctx, client, in.Owner, in.Repo, in.CommentID, body)
+		} else {
+			err = gh.EditIssueComment(ctx, client, in.Owner, in.Repo, in.CommentID, body)
+		}
+		if err != nil {
+			return err
+		}
+		// minimize the redacted comment, which collapses the comment along
+		// with the edit history that still contains the original text
+		v4_client, err := ghcm.NewInstallationV4Client(in.InstallationID)
+		if err != nil {
+			return err
+		}
+		if err := gh.MinimizeComment(ctx, v4_client, in.NodeID); err != nil {
+			return err
+		}
+	default:
+		// the label has already been applied, so there is nothing else to do
+		return nil
+	}
+
+	categories, count := entityCategories(in.Entities)
+	zerolog.Ctx(ctx).Info().
+		Bool("audit", true).
+		Str("action", in.Action).
+		Str("comment_url", in.CommentURL).
+		Strs("categories", categories).
+		Int("entities", count).
+		Bool("edit_history_retained", in.Action == cfg.GitHubActionRedact).
+		Msgf("applied action=%s to comment with detected PHI/PII", in.Action)
+
+	return nil
+}
+
+// entityCategories() function returns the sorted, unique categories of the
+// entities, along with the total number of entities.
+func entityCategories(entities map[string][]az.Entity) (categories []string, count int) {
+	unique := make(map[string]bool)
+	for _, document_entities := range entities {
+		for _, entity := range document_entities {
+			unique[entity.Category] = true
+			count++
+		}
+	}
+	for category := range unique {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return
+}
+
+// redactTextField() function redacts the entities detected in the documents
+// of the text field, as split by TextFieldsToDocuments(), from the text of the
+// field. The offset of each entity is moved from the document to the text, so
+// that an entity detected in the overlap of two documents is redacted once.
+func redactTextField(field TextField, entities map[string][]az.Entity) string {
+	chunks, offsets := splitText(field.Text, az.DocumentCharacterLimit, splitTextOverlap)
+	text_entities := []az.Entity{}
+	for i := range chunks {
+		for _, entity := range entities[documentID(field.ID, i, len(chunks))] {
+			entity.Offset += offsets[i]
+			text_entities = append(text_entities, entity)
+		}
+	}
+	return az.RedactEntities(field.Text, text_entities)
+}
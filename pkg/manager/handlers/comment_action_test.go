@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+)
+
+// TestRedactTextField() unit test function tests the redactTextField() and
+// entityCategories() functions for a comment split across several documents,
+// where an entity in the overlap of the documents is detected in both.
+func TestRedactTextField(t *testing.T) {
+	t.Parallel()
+
+	padding := strings.Repeat("x", az.DocumentCharacterLimit-20)
+	field := TextField{ID: "comment", Text: padding + "Jane Doe SSN 123-45-6789"}
+	documents := TextFieldsToDocuments([]TextField{field})
+	if !assert.Len(t, documents, 2) {
+		t.FailNow()
+	}
+	// the first document ends after "SSN ", and the second document starts
+	// with the last splitTextOverlap characters of the first
+	assert.True(t, strings.HasSuffix(documents[0].Text, "Jane Doe SSN "))
+	person_offset := splitTextOverlap - len("Jane Doe SSN ")
+	entities := map[string][]az.Entity{
+		"comment#0": {
+			{Category: "Person", Offset: len(padding), Length: 8},
+		},
+		"comment#1": {
+			{Category: "Person", Offset: person_offset, Length: 8},
+			{Category: "USSocialSecurityNumber", Offset: splitTextOverlap, Length: 11},
+		},
+	}
+
+	assert.Equal(
+		t,
+		padding+"[REDACTED:Person] SSN [REDACTED:USSocialSecurityNumber]",
+		redactTextField(field, entities),
+	)
+
+	categories, count := entityCategories(entities)
+	assert.Equal(t, []string{"Person", "USSocialSecurityNumber"}, categories)
+	assert.Equal(t, 3, count)
+}
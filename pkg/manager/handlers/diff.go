@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// AddedLine struct represents a single line added by a diff, where Line is the
+// (1-based) line number of the text within the new version of the file.
+type AddedLine struct {
+	Line int
+	Text string
+}
+
+// DiffRequest struct wraps an rrr.Request generated from the added lines of a
+// diff with the information needed to map the offset of a result within the
+// request text back to a line and column within the file.
+type DiffRequest struct {
+	Request rrr.Request
+	Path    string
+
+	// line_numbers[i] is the file line number of the text that starts at
+	// line_offsets[i] within the request text
+	line_numbers []int
+	line_offsets []int
+}
+
+// Position() method maps an offset (in code points) within the request text
+// to the (1-based) line and column of the same character within the file.
+func (dr *DiffRequest) Position(offset int) (line int, column int) {
+	if len(dr.line_offsets) == 0 {
+		return 0, 0
+	}
+	// find the last line that starts at or before the offset
+	i := sort.Search(len(dr.line_offsets), func(i int) bool {
+		return dr.line_offsets[i] > offset
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	return dr.line_numbers[i], offset - dr.line_offsets[i] + 1
+}
+
+// ParsePatchAddedLines() function parses a unified diff patch, such as the
+// patch returned by the GitHub API for a file in a pull request, and returns
+// the lines added by the patch along with their line numbers in the new file.
+func ParsePatchAddedLines(patch string) (added []AddedLine, e error) {
+	var new_line int
+	var in_hunk bool
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			new_line, e = parseHunkHeaderNewStart(line)
+			if e != nil {
+				return
+			}
+			in_hunk = true
+			continue
+		}
+		if !in_hunk {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added = append(added, AddedLine{Line: new_line, Text: strings.TrimPrefix(line, "+")})
+			new_line++
+		case strings.HasPrefix(line, "-"):
+			// removed lines do not exist in the new file
+		case strings.HasPrefix(line, "\\"):
+			// e.g. "\ No newline at end of file"
+		default:
+			// context line
+			new_line++
+		}
+	}
+	return
+}
+
+// ContentAddedLines() function returns every line of the content of a file as
+// an added line, e.g. for a file of a pull request whose patch is omitted by
+// the GitHub API, such that the whole file is scanned instead of skipped.
+func ContentAddedLines(content []byte) (added []AddedLine) {
+	text := strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if text == "" {
+		return
+	}
+	for i, line := range strings.Split(text, "\n") {
+		added = append(added, AddedLine{Line: i + 1, Text: line})
+	}
+	return
+}
+
+// AddedLinesToRequests() function groups the added lines of a single file into
+// rrr.Requests with text limited to max_chunk_size characters, where lines are
+// only grouped together if they are contiguous within the file.
+func AddedLinesToRequests(
+	repo_id, commit_id, object_id, path string,
+	added []AddedLine,
+	max_chunk_size int,
+) (requests []DiffRequest, e error) {
+	if max_chunk_size <= 0 {
+		e = rrr.ErrMaxChunkSizeInvalid
+		return
+	}
+	var current *DiffRequest
+	var current_text string
+	var previous_line int
+
+	flush := func() error {
+		if current == nil || current_text == "" {
+			current = nil
+			current_text = ""
+			return nil
+		}
+		request, err := rrr.NewRequest(rrr.NewRequestInput{
+			CommitID: commit_id,
+			Length:   utf8.RuneCountInString(current_text),
+			ObjectID: object_id,
+			Offset:   0,
+			RepoID:   repo_id,
+			Text:     current_text,
+		})
+		if err != nil {
+			return err
+		}
+		current.Request = request
+		requests = append(requests, *current)
+		current = nil
+		current_text = ""
+		return nil
+	}
+
+	for _, added_line := range added {
+		// lines longer than the chunk size are split into several requests
+		line_length := utf8.RuneCountInString(added_line.Text)
+		if line_length >= max_chunk_size {
+			if e = flush(); e != nil {
+				return
+			}
+			_, line_requests, err := rrr.ChunkLineToRequests(rrr.ChunkLineInput{
+				CommitID:     commit_id,
+				Line:         added_line.Text,
+				MaxChunkSize: max_chunk_size,
+				ObjectID:     object_id,
+				Offset:       0,
+				RepoID:       repo_id,
+			})
+			if err != nil {
+				e = errors.Wrapf(err, "failed to chunk line %d of file %s", added_line.Line, path)
+				return
+			}
+			// the offset of each chunk is its (0-based) column within the line
+			for _, line_request := range line_requests {
+				requests = append(requests, DiffRequest{
+					Request:      line_request,
+					Path:         path,
+					line_numbers: []int{added_line.Line},
+					line_offsets: []int{-line_request.Object.Offset},
+				})
+			}
+			previous_line = added_line.Line
+			continue
+		}
+		contiguous := current != nil && added_line.Line == previous_line+1
+		if current != nil && (!contiguous || utf8.RuneCountInString(current_text)+len("\n")+line_length >= max_chunk_size) {
+			if e = flush(); e != nil {
+				return
+			}
+		}
+		if current == nil {
+			current = &DiffRequest{Path: path}
+			current.line_numbers = append(current.line_numbers, added_line.Line)
+			current.line_offsets = append(current.line_offsets, 0)
+			current_text = added_line.Text
+		} else {
+			current_text += "\n"
+			current.line_numbers = append(current.line_numbers, added_line.Line)
+			current.line_offsets = append(current.line_offsets, utf8.RuneCountInString(current_text))
+			current_text += added_line.Text
+		}
+		previous_line = added_line.Line
+	}
+	e = flush()
+	return
+}
+
+// parseHunkHeaderNewStart() function parses the start line of the new file
+// from a hunk header in the format "@@ -a,b +c,d @@".
+func parseHunkHeaderNewStart(header string) (int, error) {
+	fields := strings.Fields(header)
+	for _, field := range fields {
+		if !strings.HasPrefix(field, "+") {
+			continue
+		}
+		start := strings.SplitN(strings.TrimPrefix(field, "+"), ",", 2)[0]
+		n, err := strconv.Atoi(start)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to parse hunk header : %s", header)
+		}
+		return n, nil
+	}
+	return 0, errors.New("failed to parse hunk header : " + header)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var test_patch = strings.Join([]string{
+	"@@ -1,3 +1,4 @@",
+	" unchanged line 1",
+	"-removed line 2",
+	"+added line 2",
+	"+added line 3",
+	" unchanged line 4",
+	"@@ -10,2 +11,3 @@ func foo() {",
+	" unchanged line 11",
+	"+added line 12 with SSN 123-45-6789",
+	" unchanged line 13",
+	"\\ No newline at end of file",
+}, "\n")
+
+// TestParsePatchAddedLines() unit test function tests the
+// ParsePatchAddedLines() function.
+func TestParsePatchAddedLines(t *testing.T) {
+	t.Parallel()
+
+	added, err := ParsePatchAddedLines(test_patch)
+	assert.NoError(t, err)
+	assert.Equal(t, []AddedLine{
+		{Line: 2, Text: "added line 2"},
+		{Line: 3, Text: "added line 3"},
+		{Line: 12, Text: "added line 12 with SSN 123-45-6789"},
+	}, added)
+
+	_, err = ParsePatchAddedLines("@@ -1,3 +x,4 @@\n+foo")
+	assert.Error(t, err)
+}
+
+// TestContentAddedLines() unit test function tests the ContentAddedLines()
+// function.
+func TestContentAddedLines(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []AddedLine{
+		{Line: 1, Text: "line 1"},
+		{Line: 2, Text: ""},
+		{Line: 3, Text: "line 3"},
+	}, ContentAddedLines([]byte("line 1\r\n\nline 3\n")))
+	assert.Empty(t, ContentAddedLines([]byte{}))
+}
+
+// TestAddedLinesToRequests() unit test function tests the
+// AddedLinesToRequests() function and the Position() method
+// of the resulting DiffRequests.
+func TestAddedLinesToRequests(t *testing.T) {
+	t.Parallel()
+
+	added, err := ParsePatchAddedLines(test_patch)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	requests, err := AddedLinesToRequests("test_repo", "test_commit", "test_object", "test.md", added, 1000)
+	assert.NoError(t, err)
+	// non-contiguous lines must be split into separate requests
+	if !assert.Len(t, requests, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "added line 2\nadded line 3", requests[0].Request.Text)
+	assert.Equal(t, "test.md", requests[0].Path)
+
+	line, column := requests[0].Position(strings.Index(requests[0].Request.Text, "line 3"))
+	assert.Equal(t, 3, line)
+	assert.Equal(t, 7, column)
+
+	line, column = requests[1].Position(strings.Index(requests[1].Request.Text, "123-45-6789"))
+	assert.Equal(t, 12, line)
+	assert.Equal(t, 24, column)
+
+	// lines longer than the chunk size are split into several requests
+	requests, err = AddedLinesToRequests("test_repo", "test_commit", "test_object", "test.md", added[2:], 12)
+	assert.NoError(t, err)
+	assert.Greater(t, len(requests), 1)
+	for _, request := range requests {
+		line, column := request.Position(0)
+		assert.Equal(t, 12, line)
+		assert.Equal(t, strings.Index(added[2].Text, request.Request.Text)+1, column)
+	}
+
+	// the chunk size is counted in characters (code points) instead of bytes
+	non_ascii := []AddedLine{{Line: 1, Text: "名前: 山田"}, {Line: 2, Text: "電話: 太郎"}}
+	requests, err = AddedLinesToRequests("test_repo", "test_commit", "test_object", "test.md", non_ascii, 14)
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "名前: 山田\n電話: 太郎", requests[0].Request.Text)
+		assert.Equal(t, 13, requests[0].Request.Object.Length)
+		line, column := requests[0].Position(utf8.RuneCountInString("名前: 山田\n電話: "))
+		assert.Equal(t, 2, line)
+		assert.Equal(t, 5, column)
+	}
+
+	_, err = AddedLinesToRequests("test_repo", "test_commit", "test_object", "test.md", added, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+)
+
+// TextField struct identifies a single text field of a webhook event, such as
+// the title or body of an issue, that is a potential source of PHI/PII.
+type TextField struct {
+	// ID uniquely identifies the field, e.g. the URL of a comment.
+	ID   string
+	Text string
+}
+
+// splitTextOverlap is the number of characters at the end of each chunk of a
+// split text that are repeated at the start of the next chunk, such that an
+// entity that spans the split is detected in at least one of the chunks.
+const splitTextOverlap int = 50
+
+// TextFieldsToDocuments() function converts each non-empty TextField into one
+// or more az.Documents, splitting any text that exceeds the character limit of
+// a single document into several overlapping documents with distinct IDs.
+func TextFieldsToDocuments(fields []TextField) []az.Document {
+	documents := []az.Document{}
+	for _, field := range fields {
+		chunks, _ := splitText(field.Text, az.DocumentCharacterLimit, splitTextOverlap)
+		for i, chunk := range chunks {
+			documents = append(documents, az.NewDocument(documentID(field.ID, i, len(chunks)), chunk, az.DefaultLanguage))
+		}
+	}
+	return documents
+}
+
+// documentID() function returns the ID of the document for chunk i of the
+// count chunks of the text field with the given ID.
+func documentID(field_id string, i, count int) string {
+	if count > 1 {
+		return fmt.Sprintf("%s#%d", field_id, i)
+	}
+	return field_id
+}
+
+// detectDocuments() function sends the documents to the Azure AI Language
+// service, in batches no larger than the document limit of a single request,
+// and returns the label to apply based on whether any PHI/PII was detected,
+// along with the detected entities keyed by document ID.
+func detectDocuments(
+	ctx context.Context,
+	ai *az.EntityDetectionAI,
+	documents []az.Document,
+) (label string, entities map[string][]az.Entity, e error) {
+	// default label value assumes there is no PHI/PII in the documents
+	label = gh.LabelCleanPHI
+	entities = make(map[string][]az.Entity)
+	for start := 0; start < len(documents); start += az.RequestDocumentLimit {
+		end := start + az.RequestDocumentLimit
+		if end > len(documents) {
+			end = len(documents)
+		}
+		zerolog.Ctx(ctx).Debug().Msgf("sending PII entity detection request for %d documents", end-start)
+		var batch_entities map[string][]az.Entity
+		batch_entities, e = ai.DetectPiiEntitiesByDocument(ctx, az.NewPiiEntityRecognitionRequest(documents[start:end]))
+		if e != nil {
+			return
+		}
+		for id, document_entities := range batch_entities {
+			entities[id] = document_entities
+		}
+	}
+	if len(entities) > 0 {
+		label = gh.LabelDirtyPHI
+	}
+	zerolog.Ctx(ctx).Debug().Msgf("AI scanned %d documents : %s", len(documents), label)
+	return
+}
+
+// splitText() function splits the text into chunks of at most limit
+// characters (i.e. Unicode code points, as counted by the AI service), and
+// returns the chunks along with the character offset of each chunk within the
+// text. Where possible, the text is split after the last whitespace in the
+// second half of a chunk, such that words are not split across chunks, and
+// each chunk after the first starts with the last overlap characters of the
+// previous chunk.
+func splitText(text string, limit, overlap int) (chunks []string, offsets []int) {
+	if text == "" {
+		return
+	}
+	runes := []rune(text)
+	start := 0
+	for len(runes)-start > limit {
+		end := start + limit
+		for i := end; i > start+limit/2; i-- {
+			if unicode.IsSpace(runes[i-1]) {
+				end = i
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[start:end]))
+		offsets = append(offsets, start)
+		if end-overlap > start {
+			start = end - overlap
+		} else {
+			start = end
+		}
+	}
+	chunks = append(chunks, string(runes[start:]))
+	offsets = append(offsets, start)
+	return
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+)
+
+// TestTextFieldsToDocuments() unit test function tests the
+// TextFieldsToDocuments() function.
+func TestTextFieldsToDocuments(t *testing.T) {
+	t.Parallel()
+
+	long_text := strings.Repeat("é", az.DocumentCharacterLimit+1)
+	documents := TextFieldsToDocuments([]TextField{
+		{ID: "issue#title", Text: "test title"},
+		{ID: "issue#body", Text: ""},
+		{ID: "comment", Text: long_text},
+	})
+	// the empty body is skipped and the long comment is split in two
+	if !assert.Len(t, documents, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, "issue#title", documents[0].ID)
+	assert.Equal(t, "test title", documents[0].Text)
+	assert.Equal(t, "comment#0", documents[1].ID)
+	assert.Equal(t, "comment#1", documents[2].ID)
+	assert.Equal(t, az.DocumentCharacterLimit, utf8.RuneCountInString(documents[1].Text))
+	assert.Equal(t, splitTextOverlap+1, utf8.RuneCountInString(documents[2].Text))
+	for _, document := range documents {
+		assert.LessOrEqual(t, utf8.RuneCountInString(document.Text), az.DocumentCharacterLimit)
+		assert.Equal(t, az.DefaultLanguage, document.Language)
+	}
+}
+
+// Test_splitText() unit test function tests that the splitText() function
+// splits the text on whitespace where possible, and that each chunk overlaps
+// the previous chunk.
+func Test_splitText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		text           string
+		limit          int
+		overlap        int
+		expect_chunks  []string
+		expect_offsets []int
+	}{
+		{
+			name:  "empty",
+			text:  "",
+			limit: 10,
+		},
+		{
+			name:           "short",
+			text:           "short",
+			limit:          10,
+			expect_chunks:  []string{"short"},
+			expect_offsets: []int{0},
+		},
+		{
+			name:           "whitespace",
+			text:           "aaaa bbbb cccc",
+			limit:          10,
+			expect_chunks:  []string{"aaaa bbbb ", "cccc"},
+			expect_offsets: []int{0, 10},
+		},
+		{
+			name:           "whitespace_overlap",
+			text:           "aaaa bbbb cccc",
+			limit:          10,
+			overlap:        2,
+			expect_chunks:  []string{"aaaa bbbb ", "b cccc"},
+			expect_offsets: []int{0, 8},
+		},
+		{
+			// the only whitespace is in the first half of the chunk
+			name:           "no_whitespace",
+			text:           "a bbbbbbbbbbbb",
+			limit:          10,
+			overlap:        2,
+			expect_chunks:  []string{"a bbbbbbbb", "bbbbbb"},
+			expect_offsets: []int{0, 8},
+		},
+		{
+			name:           "multi_byte",
+			text:           "ééééééé",
+			limit:          4,
+			overlap:        1,
+			expect_chunks:  []string{"éééé", "éééé"},
+			expect_offsets: []int{0, 3},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			chunks, offsets := splitText(test.text, test.limit, test.overlap)
+			assert.Equal(t, test.expect_chunks, chunks)
+			assert.Equal(t, test.expect_offsets, offsets)
+			runes := []rune(test.text)
+			for i, chunk := range chunks {
+				assert.True(t, utf8.ValidString(chunk))
+				assert.Equal(t, chunk, string(runes[offsets[i]:offsets[i]+utf8.RuneCountInString(chunk)]))
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 const EventTypeInstallation string = "installation"
+const EventTypeIssues string = "issues"
 const EventTypePullRequest string = "pull_request"
+const EventTypePullRequestReviewComment string = "pull_request_review_comment"
 const EventTypePush string = "push"
 const EventTypeIssueComment string = "issue_comment"
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+)
+
+type IssuesHandler struct {
+	AI   *az.EntityDetectionAI
+	GHCM *gh.ClientManager
+}
+
+func (h *IssuesHandler) Handles() []string {
+	return []string{EventTypeIssues}
+}
+
+// Handle() method scans the title and body of an issue whenever the issue is
+// opened, edited or reopened.
+func (h *IssuesHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	var event github.IssuesEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return errors.Wrap(err, "failed to parse payload for eventType="+EventTypeIssues)
+	}
+	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook eventType=%s", h.name(), eventType)
+
+	// check the "action" field of the event
+	eventAction := event.GetAction()
+	switch eventAction {
+	case "opened", "edited", "reopened":
+		break
+	default:
+		zerolog.Ctx(ctx).Debug().Msgf("ignoring event action=%s for eventType=%s : deliveryID=%s", eventAction, eventType, deliveryID)
+		return nil
+	}
+
+	issue := event.GetIssue()
+	documents := TextFieldsToDocuments([]TextField{
+		{ID: issue.GetURL() + "#title", Text: issue.GetTitle()},
+		{ID: issue.GetURL() + "#body", Text: issue.GetBody()},
+	})
+	if len(documents) == 0 {
+		zerolog.Ctx(ctx).Debug().Msgf("no documents to process for eventType=%s : deliveryID=%s", eventType, deliveryID)
+		return nil
+	}
+
+	issue_label, _, err := detectDocuments(ctx, h.AI, documents)
+	if err != nil {
+		zerolog.Ctx(ctx).Debug().Msg(err.Error())
+		return err
+	}
+
+	installationID := githubapp.GetInstallationIDFromEvent(&event)
+
+	return h.GHCM.ApplyLabelForIssue(ctx, installationID, event.GetRepo(), issue.GetNumber(), issue_label)
+}
+
+// IssuesHandler.name() method is NOT required by any interface.
+func (h *IssuesHandler) name() string {
+	return "IssuesHandler"
+}
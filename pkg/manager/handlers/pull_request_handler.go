@@ -3,18 +3,25 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
 )
 
 type PullRequestHandler struct {
-	AI   *az.EntityDetectionAI
-	GHCM *gh.ClientManager
+	AI     *az.EntityDetectionAI
+	Config *cfg.Config
+	GHCM   *gh.ClientManager
 }
 
 func (h *PullRequestHandler) Handles() []string {
@@ -27,14 +34,243 @@ func (h *PullRequestHandler) Handle(ctx context.Context, eventType, deliveryID s
 		return errors.Wrap(err, "failed to parse payload for event type="+EventTypePullRequest)
 	}
 	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event type=%s", h.name(), eventType)
-	// TODO : remove vulnerable use of payload as unfiltered input to logging function
-	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event:\n%s", h.name(), string(payload))
 
-	// TODO
+	// check the "action" field of the event
+	eventAction := event.GetAction()
+	switch eventAction {
+	case "edited", "opened", "reopened", "synchronize":
+		break
+	default:
+		zerolog.Ctx(ctx).Debug().Msgf("ignoring event action=%s for eventType=%s : deliveryID=%s", eventAction, eventType, deliveryID)
+		return nil
+	}
+
+	installationID := githubapp.GetInstallationIDFromEvent(&event)
+	client, err := h.GHCM.NewInstallationClient(installationID)
+	if err != nil {
+		return errors.Wrap(err, "failed to create installation client for pull request event")
+	}
+
+	repo := event.GetRepo()
+	repoOwner := repo.GetOwner().GetLogin()
+	repoName := repo.GetName()
+	prNum := event.GetPullRequest().GetNumber()
+	headSHA := event.GetPullRequest().GetHead().GetSHA()
+
+	ctx, logger := githubapp.PreparePRContext(ctx, installationID, repo, prNum)
+
+	// scan the title and description of the pull request, which can only
+	// change when the pull request is opened, reopened or edited
+	if eventAction != "synchronize" {
+		if err := h.scanPullRequestText(ctx, installationID, event); err != nil {
+			logger.Error().Err(err).Msgf("failed to scan title and description of pull request #%d", prNum)
+		}
+	}
+	// editing the pull request does not change the lines it adds
+	if eventAction == "edited" {
+		return nil
+	}
+
+	// publish an in-progress check run for the head commit of the pull request
+	checkRunID, err := gh.CreateCheckRun(ctx, client, repoOwner, repoName, headSHA)
+	if err != nil {
+		return err
+	}
+
+	findings, requests, scan_err := h.scanPullRequest(ctx, client, repoOwner, repoName, repo.GetHTMLURL(), prNum, headSHA)
+	if scan_err != nil {
+		complete_err := gh.CompleteCheckRun(ctx, client, gh.CompleteCheckRunInput{
+			CheckRunID: checkRunID,
+			Conclusion: gh.CheckRunConclusionFailure,
+			Owner:      repoOwner,
+			Repo:       repoName,
+			Summary:    "The pull request could not be scanned for PHI/PII.",
+			Title:      "PHI/PII scan failed",
+		})
+		if complete_err != nil {
+			logger.Error().Err(complete_err).Msgf("failed to complete check run %d", checkRunID)
+		}
+		return scan_err
+	}
+
+	in := gh.CompleteCheckRunInput{
+		Annotations: findingsToAnnotations(findings, requests),
+		CheckRunID:  checkRunID,
+		Conclusion:  gh.CheckRunConclusionSuccess,
+		Owner:       repoOwner,
+		Repo:        repoName,
+		Summary:     "No PHI/PII detected in the lines added by this pull request.",
+		Title:       "No PHI/PII detected",
+	}
+	if len(findings) > 0 {
+		in.Conclusion = gh.CheckRunConclusionFailure
+		in.Summary = summarizeFindings("PHI/PII detected by "+h.Config.App.Name, findings)
+		in.Title = fmt.Sprintf("%d potential PHI/PII entities detected", len(findings))
+	}
+	if err := gh.CompleteCheckRun(ctx, client, in); err != nil {
+		return err
+	}
+	logger.Info().Msgf("scanned pull request #%d with %d findings", prNum, len(findings))
 
 	return nil
 }
 
+// scanPullRequest() method scans only the lines added by the pull request and
+// returns any findings above the confidence threshold, along with a map of the
+// requests that were sent for detection, keyed by request ID.
+func (h *PullRequestHandler) scanPullRequest(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, repo_url string,
+	number int,
+	head_sha string,
+) (findings []Finding, requests map[string]DiffRequest, e error) {
+	files, err := gh.ListPullRequestFiles(ctx, client, owner, repo, number)
+	if err != nil {
+		e = err
+		return
+	}
+
+	requests = make(map[string]DiffRequest)
+	paths := make(map[string]string)
+	var detect_requests []rrr.Request
+	for _, file := range files {
+		path := file.GetFilename()
+		var added []AddedLine
+		if file.GetPatch() != "" {
+			added, err = ParsePatchAddedLines(file.GetPatch())
+			if err != nil {
+				e = errors.Wrapf(err, "failed to parse patch for file %s", path)
+				return
+			}
+		} else if file.GetStatus() != "removed" && file.GetChanges() > 0 {
+			// the GitHub API omits the patch for binary files and very large
+			// diffs, so the whole file at the head commit is scanned instead
+			zerolog.Ctx(ctx).Debug().Msgf("pull request #%d : scanning whole file %s without patch", number, path)
+			content, err := gh.GetBlobContent(ctx, client, owner, repo, file.GetSHA())
+			if err != nil {
+				e = errors.Wrapf(err, "failed to get content of file %s without patch", path)
+				return
+			}
+			added = ContentAddedLines(content)
+		}
+		if len(added) == 0 {
+			continue
+		}
+		// check if the file should be ignored, using the added lines as content
+		added_text := make([]string, 0, len(added))
+		for _, added_line := range added {
+			added_text = append(added_text, added_line.Text)
+		}
+		file_object, err := newFileObject(path, []byte(strings.Join(added_text, "\n")))
+		if err != nil {
+			e = err
+			return
+		}
+		should_ignore, ignore_reason := scanner.IgnoreFileObject(
+			file_object,
+			h.Config.Git.Scan.Extensions,
+			h.Config.Git.Scan.IgnoreExtensions,
+		)
+		if should_ignore {
+			zerolog.Ctx(ctx).Trace().Msgf("pull request #%d : skipping scan of file %s : %s", number, path, ignore_reason)
+			continue
+		}
+		diff_requests, err := AddedLinesToRequests(
+			rrr.RepositoryID(repo_url),
+			head_sha,
+			file.GetSHA(),
+			path,
+			added,
+			h.Config.Git.Scan.Limits.MaxRequestChunkSize,
+		)
+		if err != nil {
+			e = err
+			return
+		}
+		for _, diff_request := range diff_requests {
+			if _, exists := requests[diff_request.Request.ID]; exists {
+				continue
+			}
+			requests[diff_request.Request.ID] = diff_request
+			paths[diff_request.Request.ID] = path
+			detect_requests = append(detect_requests, diff_request.Request)
+		}
+	}
+
+	findings, e = detectFindings(ctx, h.AI, detect_requests, paths)
+	return
+}
+
+// scanPullRequestText() method scans the title and description of the pull
+// request and labels the pull request based on the result.
+func (h *PullRequestHandler) scanPullRequestText(ctx context.Context, installationID int64, event github.PullRequestEvent) error {
+	pr := event.GetPullRequest()
+	documents := TextFieldsToDocuments([]TextField{
+		{ID: pr.GetURL() + "#title", Text: pr.GetTitle()},
+		{ID: pr.GetURL() + "#body", Text: pr.GetBody()},
+	})
+	if len(documents) == 0 {
+		return nil
+	}
+
+	pr_label, _, err := detectDocuments(ctx, h.AI, documents)
+	if err != nil {
+		return err
+	}
+
+	return h.GHCM.ApplyLabelForIssue(ctx, installationID, event.GetRepo(), pr.GetNumber(), pr_label)
+}
+
+// findingsToAnnotations() function converts each Finding to a check run
+// annotation, using the DiffRequest of the finding to map the offset of
+// the result back to the line and column of the file. The detected text
+// is not included in the annotation.
+func findingsToAnnotations(findings []Finding, requests map[string]DiffRequest) []*github.CheckRunAnnotation {
+	annotations := make([]*github.CheckRunAnnotation, 0, len(findings))
+	for _, finding := range findings {
+		diff_request, exists := requests[finding.Request.ID]
+		if !exists {
+			continue
+		}
+		end_offset := finding.Result.Offset
+		if finding.Result.Length > 0 {
+			end_offset += finding.Result.Length - 1
+		}
+		start_line, start_column := diff_request.Position(finding.Result.Offset)
+		end_line, end_column := diff_request.Position(end_offset)
+
+		annotation := &github.CheckRunAnnotation{
+			AnnotationLevel: github.String(gh.CheckRunAnnotationLevelFailure),
+			EndLine:         github.Int(end_line),
+			Message: github.String(fmt.Sprintf(
+				"Potential %s detected with confidence score %.2f",
+				categoryLabel(finding.Result),
+				finding.Result.ConfidenceScore,
+			)),
+			Path:      github.String(finding.Path),
+			StartLine: github.Int(start_line),
+			Title:     github.String("PHI/PII detected"),
+		}
+		// the GitHub API only accepts columns for single-line annotations
+		if start_line == end_line {
+			annotation.StartColumn = github.Int(start_column)
+			annotation.EndColumn = github.Int(end_column)
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations
+}
+
+// categoryLabel() function returns the category of the result, including
+// the subcategory when one is available.
+func categoryLabel(result rrr.Result) string {
+	if result.Subcategory == "" {
+		return result.Category
+	}
+	return result.Category + "/" + result.Subcategory
+}
+
 // PullRequestHandler.name() method is NOT required by any interface.
 func (h *PullRequestHandler) name() string {
 	return "PullRequestHandler"
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+)
+
+type PullRequestReviewCommentHandler struct {
+	AI     *az.EntityDetectionAI
+	Config *cfg.Config
+	GHCM   *gh.ClientManager
+}
+
+func (h *PullRequestReviewCommentHandler) Handles() []string {
+	return []string{EventTypePullRequestReviewComment}
+}
+
+// Handle() method scans review comments left on the diff of a pull request
+// whenever a comment is created or edited.
+func (h *PullRequestReviewCommentHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	var event github.PullRequestReviewCommentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return errors.Wrap(err, "failed to parse payload for eventType="+EventTypePullRequestReviewComment)
+	}
+	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook eventType=%s", h.name(), eventType)
+
+	// check the "action" field of the event
+	eventAction := event.GetAction()
+	switch eventAction {
+	case "created", "edited":
+		break
+	default:
+		zerolog.Ctx(ctx).Debug().Msgf("ignoring event action=%s for eventType=%s : deliveryID=%s", eventAction, eventType, deliveryID)
+		return nil
+	}
+
+	field := TextField{ID: event.GetComment().GetURL(), Text: event.GetComment().GetBody()}
+	documents := TextFieldsToDocuments([]TextField{field})
+	if len(documents) == 0 {
+		zerolog.Ctx(ctx).Debug().Msgf("no documents to process for eventType=%s : deliveryID=%s", eventType, deliveryID)
+		return nil
+	}
+
+	pr_label, entities, err := detectDocuments(ctx, h.AI, documents)
+	if err != nil {
+		zerolog.Ctx(ctx).Debug().Msg(err.Error())
+		return err
+	}
+
+	installationID := githubapp.GetInstallationIDFromEvent(&event)
+	repo := event.GetRepo()
+
+	if err := h.GHCM.ApplyLabelForIssue(ctx, installationID, repo, event.GetPullRequest().GetNumber(), pr_label); err != nil {
+		return err
+	}
+
+	return applyCommentAction(ctx, h.GHCM, commentActionInput{
+		Action:          h.Config.GitHub.Action,
+		CommentID:       event.GetComment().GetID(),
+		CommentURL:      event.GetComment().GetHTMLURL(),
+		Entities:        entities,
+		Field:           field,
+		InstallationID:  installationID,
+		IsReviewComment: true,
+		NodeID:          event.GetComment().GetNodeID(),
+		Owner:           repo.GetOwner().GetLogin(),
+		Repo:            repo.GetName(),
+	})
+}
+
+// PullRequestReviewCommentHandler.name() method is NOT required by any interface.
+func (h *PullRequestReviewCommentHandler) name() string {
+	return "PullRequestReviewCommentHandler"
+}
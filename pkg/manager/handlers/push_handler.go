@@ -3,18 +3,23 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/gh"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
 )
 
 type PushHandler struct {
-	AI   *az.EntityDetectionAI
-	GHCM *gh.ClientManager
+	AI     *az.EntityDetectionAI
+	Config *cfg.Config
+	GHCM   *gh.ClientManager
 }
 
 func (h *PushHandler) Handles() []string {
@@ -30,11 +35,132 @@ func (h *PushHandler) Handle(ctx context.Context, eventType, deliveryID string,
 	// TODO : remove vulnerable use of payload as unfiltered input to logging function
 	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event:\n%s", h.name(), string(payload))
 
-	// TODO
+	// nothing to scan when the push deletes the ref
+	if event.GetDeleted() {
+		zerolog.Ctx(ctx).Debug().Msgf("ignoring push that deleted ref=%s : deliveryID=%s", event.GetRef(), deliveryID)
+		return nil
+	}
+
+	installationID := githubapp.GetInstallationIDFromEvent(&event)
+	client, err := h.GHCM.NewInstallationClient(installationID)
+	if err != nil {
+		return errors.Wrap(err, "failed to create installation client for push event")
+	}
+
+	repo := event.GetRepo()
+	repoOwner := repo.GetOwner().GetLogin()
+	if repoOwner == "" {
+		repoOwner = repo.GetOwner().GetName()
+	}
+	repoName := repo.GetName()
+
+	// get the list of commits in the push range
+	commits, err := gh.ListPushCommits(ctx, client, event)
+	if err != nil {
+		// the head commit is marked as failed because the commits of the push
+		// cannot be scanned without the complete list of commits
+		if status_err := gh.CreateCommitStatus(ctx, client, repoOwner, repoName, event.GetAfter(), gh.CommitStatusStateError, "failed to list commits to scan for PHI/PII", ""); status_err != nil {
+			zerolog.Ctx(ctx).Error().Err(status_err).Msgf("failed to set error status for commit %s", event.GetAfter())
+		}
+		return err
+	}
+	if len(commits) == 0 {
+		zerolog.Ctx(ctx).Debug().Msgf("no commits to scan for push to ref=%s : deliveryID=%s", event.GetRef(), deliveryID)
+		return nil
+	}
+
+	// mark each commit as pending while the scan is in progress
+	for _, sha := range commits {
+		if err := gh.CreateCommitStatus(ctx, client, repoOwner, repoName, sha, gh.CommitStatusStatePending, "scanning commit for PHI/PII", ""); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to set pending status for commit %s", sha)
+		}
+	}
+
+	findings, scan_err := h.scanCommits(ctx, client, repoOwner, repoName, repo.GetHTMLURL(), commits)
+	if scan_err != nil {
+		for _, sha := range commits {
+			if err := gh.CreateCommitStatus(ctx, client, repoOwner, repoName, sha, gh.CommitStatusStateError, "failed to scan commit for PHI/PII", ""); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to set error status for commit %s", sha)
+			}
+		}
+		return scan_err
+	}
+
+	// post a summary of the findings as a comment on the head commit, which
+	// the status of each commit can then link to
+	var summary_url string
+	if len(findings) > 0 {
+		summary := summarizeFindings("PHI/PII detected by "+h.Config.App.Name, findings)
+		summary_url, err = gh.CreateCommitComment(ctx, client, repoOwner, repoName, event.GetAfter(), summary)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to post findings summary for commit %s", event.GetAfter())
+		}
+	}
+
+	// count the findings for each commit in the push range
+	commit_findings := make(map[string]int)
+	for _, finding := range findings {
+		commit_findings[finding.CommitID]++
+	}
+	for _, sha := range commits {
+		state := gh.CommitStatusStateSuccess
+		description := "no PHI/PII detected"
+		target_url := ""
+		if count := commit_findings[sha]; count > 0 {
+			state = gh.CommitStatusStateFailure
+			description = fmt.Sprintf("%d potential PHI/PII entities detected", count)
+			target_url = summary_url
+		}
+		if err := gh.CreateCommitStatus(ctx, client, repoOwner, repoName, sha, state, description, target_url); err != nil {
+			return err
+		}
+	}
+	zerolog.Ctx(ctx).Info().Msgf("scanned %d commits with %d findings for push to ref=%s", len(commits), len(findings), event.GetRef())
 
 	return nil
 }
 
+// scanCommits() method scans the files changed by each of the input commits
+// and returns any findings above the confidence threshold.
+func (h *PushHandler) scanCommits(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, repo_url string,
+	commits []string,
+) ([]Finding, error) {
+	// avoid fetching and scanning the same blob more than once
+	seen_blobs := make(map[string]bool)
+	files := make([]ScanFile, 0)
+	for _, sha := range commits {
+		commit_files, err := gh.ListCommitFiles(ctx, client, owner, repo, sha)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit_file := range commit_files {
+			blob_sha := commit_file.GetSHA()
+			if blob_sha == "" || seen_blobs[blob_sha] {
+				continue
+			}
+			seen_blobs[blob_sha] = true
+			content, err := gh.GetBlobContent(ctx, client, owner, repo, blob_sha)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, ScanFile{
+				CommitID: sha,
+				Content:  content,
+				Path:     commit_file.GetFilename(),
+			})
+		}
+	}
+
+	requests, paths, err := scanFilesToRequests(ctx, &h.Config.Git.Scan, rrr.RepositoryID(repo_url), files)
+	if err != nil {
+		return nil, err
+	}
+	return detectFindings(ctx, h.AI, requests, paths)
+}
+
 // PushHandler.name() method is NOT required by any interface.
 func (h *PushHandler) name() string {
 	return "PushHandler"
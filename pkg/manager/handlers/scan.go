@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// Finding struct pairs a single detection result with the location of the
+// scanned text, so that the result can be reported back to GitHub.
+type Finding struct {
+	// CommitID is the SHA of the commit in which the text was scanned.
+	CommitID string
+	// Path is the path of the scanned file within the commit tree.
+	Path string
+	// Request is the metadata of the request that produced the result.
+	Request rrr.MetadataRequestResponse
+	// Result is the detection result returned by the detector.
+	Result rrr.Result
+}
+
+// ScanFile struct contains the inputs needed to scan a single file that was
+// fetched from the GitHub API rather than from a local clone.
+type ScanFile struct {
+	CommitID string
+	Content  []byte
+	Path     string
+}
+
+// detectFindings() function sends the requests to the detector and converts
+// each result at or above the detector's confidence threshold to a Finding,
+// using the paths map to resolve each request ID to the path of its file.
+// Returns a non-nil error if the detection of any request failed, such that
+// the caller reports the scan as failed instead of free of PHI/PII.
+func detectFindings(
+	ctx context.Context,
+	ai *az.EntityDetectionAI,
+	requests []rrr.Request,
+	paths map[string]string,
+) (findings []Finding, e error) {
+	if len(requests) == 0 {
+		return
+	}
+	zerolog.Ctx(ctx).Debug().Msgf("sending PII entity detection requests for %d chunks", len(requests))
+
+	var responses []rrr.Response
+	responses, e = ai.DetectRequests(ctx, requests)
+	if e != nil {
+		return
+	}
+	for _, response := range responses {
+		for _, result := range response.Results {
+			if result.ConfidenceScore < ai.GetConfidenceThreshold() {
+				continue
+			}
+			findings = append(findings, Finding{
+				CommitID: response.Commit.ID,
+				Path:     paths[response.ID],
+				Request:  response.MetadataRequestResponse,
+				Result:   result,
+			})
+		}
+	}
+	return
+}
+
+// newFileObject() function creates an in-memory object.File from the path and
+// content of a file, where the hash of the object.File matches the hash of the
+// equivalent git blob.
+func newFileObject(path string, content []byte) (*object.File, error) {
+	o := &plumbing.MemoryObject{}
+	o.SetType(plumbing.BlobObject)
+	if _, err := o.Write(content); err != nil {
+		return nil, errors.Wrapf(err, "failed to write content of file %s", path)
+	}
+	blob := &object.Blob{}
+	if err := blob.Decode(o); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode blob for file %s", path)
+	}
+	return object.NewFile(path, filemode.Regular, blob), nil
+}
+
+// scanFilesToRequests() function converts the input files to rrr.Requests,
+// skipping any file that should be ignored according to the scan config.
+// Returns the requests along with a map of request ID to file path.
+func scanFilesToRequests(
+	ctx context.Context,
+	config *cfg.GitScanConfig,
+	repo_id string,
+	files []ScanFile,
+) (requests []rrr.Request, paths map[string]string, e error) {
+	paths = make(map[string]string)
+	for _, scan_file := range files {
+		file, err := newFileObject(scan_file.Path, scan_file.Content)
+		if err != nil {
+			e = err
+			return
+		}
+		// check if the file should be ignored instead of scanned
+		should_ignore, ignore_reason := scanner.IgnoreFileObject(file, config.Extensions, config.IgnoreExtensions)
+		if should_ignore {
+			zerolog.Ctx(ctx).Trace().Msgf(
+				"commit %s : skipping scan of file %s : %s",
+				scan_file.CommitID,
+				scan_file.Path,
+				ignore_reason,
+			)
+			continue
+		}
+		file_requests, err := rrr.ChunkFileToRequests(rrr.ChunkFileInput{
+			CommitID:     scan_file.CommitID,
+			File:         file,
+			MaxChunkSize: config.Limits.MaxRequestChunkSize,
+			RepoID:       repo_id,
+		})
+		if err != nil {
+			e = errors.Wrapf(err, "failed to generate requests for file %s", scan_file.Path)
+			return
+		}
+		for _, request := range file_requests {
+			// the same chunk of text may appear in more than one file
+			if _, exists := paths[request.ID]; exists {
+				continue
+			}
+			paths[request.ID] = scan_file.Path
+			requests = append(requests, request)
+		}
+	}
+	return
+}
+
+// summarizeFindings() function renders a Markdown summary of the findings,
+// grouped by file path and category. The detected text is never included
+// in the summary in order to avoid leaking PHI/PII into GitHub.
+func summarizeFindings(title string, findings []Finding) string {
+	counts := make(map[string]map[string]int)
+	for _, finding := range findings {
+		if _, exists := counts[finding.Path]; !exists {
+			counts[finding.Path] = make(map[string]int)
+		}
+		counts[finding.Path][finding.Result.Category]++
+	}
+	file_paths := make([]string, 0, len(counts))
+	for file_path := range counts {
+		file_paths = append(file_paths, file_path)
+	}
+	sort.Strings(file_paths)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### %s\n\n", title))
+	sb.WriteString(fmt.Sprintf("Detected %d potential PHI/PII entities in %d file(s).\n\n", len(findings), len(file_paths)))
+	sb.WriteString("| File | Category | Count |\n")
+	sb.WriteString("| ---- | -------- | ----- |\n")
+	for _, file_path := range file_paths {
+		categories := make([]string, 0, len(counts[file_path]))
+		for category := range counts[file_path] {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+		for _, category := range categories {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %d |\n", file_path, category, counts[file_path][category]))
+		}
+	}
+	return sb.String()
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
 	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
-	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
 )
 
 // Manager struct holds the configuration and state for app.
@@ -18,7 +17,6 @@ type Manager struct {
 	ctx         context.Context
 	git_manager *nogit.GitManager
 	logger      *zerolog.Logger
-	scanner     *scanner.Scanner
 	server      *http.Server
 }
 
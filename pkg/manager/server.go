@@ -99,16 +99,28 @@ func setupEventDispatcher(config *cfg.Config) (http.Handler, error) {
 		GHCM: ghcm,
 	}
 	issueCommentHandler := &handlers.IssueCommentHandler{
+		AI:     ai,
+		Config: config,
+		GHCM:   ghcm,
+	}
+	issuesHandler := &handlers.IssuesHandler{
 		AI:   ai,
 		GHCM: ghcm,
 	}
 	pullRequestHandler := &handlers.PullRequestHandler{
-		AI:   ai,
-		GHCM: ghcm,
+		AI:     ai,
+		Config: config,
+		GHCM:   ghcm,
+	}
+	pullRequestReviewCommentHandler := &handlers.PullRequestReviewCommentHandler{
+		AI:     ai,
+		Config: config,
+		GHCM:   ghcm,
 	}
 	pushHandler := &handlers.PushHandler{
-		AI:   ai,
-		GHCM: ghcm,
+		AI:     ai,
+		Config: config,
+		GHCM:   ghcm,
 	}
 
 	// register the event handlers with a new/default event dispatcher
@@ -116,7 +128,9 @@ func setupEventDispatcher(config *cfg.Config) (http.Handler, error) {
 		*config.GitHub.GetGitHubAppConfig(),
 		installationHandler,
 		issueCommentHandler,
+		issuesHandler,
 		pullRequestHandler,
+		pullRequestReviewCommentHandler,
 		pushHandler,
 	)
 
@@ -1,13 +1,20 @@
 package scanner
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"os"
-	"path/filepath"
+	"fmt"
+	"io"
+	"sort"
 	"strings"
 
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
@@ -17,13 +24,46 @@ import (
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
 )
 
+// checkpoint_gzip_header is the magic number at the start of gzip data, which
+// distinguishes compressed checkpoint files from the legacy format.
+var checkpoint_gzip_header = []byte{0x1f, 0x8b}
+
 // Checkpoints struct defines the structure of the data used to save and restore
 // the state of the scanner from a checkpoint in time.
 type Checkpoint struct {
-	CreatedAt           int64              `json:"created_at"`
-	TrackerCommitsData  tracker.KeyDataMap `json:"commits"`
-	TrackerFilesData    tracker.KeyDataMap `json:"files"`
+	CreatedAt int64 `json:"created_at"`
+	// Fingerprint is the fingerprint of the config of the scan that created
+	// the Checkpoint, as returned by the CheckpointFingerprint() function.
+	Fingerprint string `json:"fingerprint"`
+	// Head is the hash of the tip commit of the history that was scanned.
+	Head string `json:"head"`
+	// Occurrences are the occurrences of the objects that were not done or
+	// have results, keyed by the ID of the object, which are not otherwise
+	// found again when the commits that are complete are skipped.
+	Occurrences map[string][]rrr.MetadataRequestResponseOccurrence `json:"occurrences"`
+	// ResultStore is the path of the persistent result store that holds the
+	// results of the completed requests, or empty if the results are stored
+	// in the Checkpoint itself.
+	ResultStore string `json:"result_store"`
+	// Results are the results of the completed requests when the scan does
+	// not use a persistent result store, e.g. the in-memory store, which
+	// would otherwise lose the results when the scan is interrupted. The
+	// text of each result is left out, because it is the detected PHI/PII,
+	// and is read again from the repository when the scan is resumed.
+	Results            []rrr.ResultRecord `json:"results"`
+	TrackerCommitsData tracker.KeyDataMap `json:"commits"`
+	TrackerFilesData   tracker.KeyDataMap `json:"files"`
+	// TrackerGeneration is the generation of the tracker store that holds
+	// the tracker data of the Checkpoint, if TrackerStore is not empty.
+	TrackerGeneration   int64              `json:"tracker_generation"`
 	TrackerRequestsData tracker.KeyDataMap `json:"requests"`
+	// TrackerStore is the path of the tracker store that holds the tracker
+	// data of the scan, which is only written incrementally to the store,
+	// or empty if the tracker data is stored in the Checkpoint itself.
+	TrackerStore string `json:"tracker_store"`
+	// Version is the schema version of the Checkpoint, which is migrated to
+	// CheckpointVersion when the Checkpoint is read from an older file.
+	Version int `json:"version"`
 }
 
 // NewCheckpoint() function creates a new Checkpoint struct with the given data
@@ -38,207 +78,360 @@ func NewCheckpoint(
 		TrackerCommitsData:  data_commits,
 		TrackerFilesData:    data_files,
 		TrackerRequestsData: data_requests,
+		Version:             CheckpointVersion,
 	}
 }
 
-// CheckpointDelete() function deletes the Checkpoint file from the expected file
-// path, based on the given repository and (optional) commit ID. Returns a non-nil
-// error if unable to locate and delete the expected file path.
-func CheckpointDelete(ctx context.Context, work_dir, repo_url, commit_id string) error {
-	logger := zerolog.Ctx(ctx)
-	file_path, err := getCheckpointPath(work_dir, repo_url, commit_id)
-	if err != nil {
-		return errors.Wrap(ErrCheckpointDeleteFailed, err.Error())
+// checkpoint_migrations maps each older schema version of the Checkpoint to
+// the function that migrates a Checkpoint from the version to the next one.
+var checkpoint_migrations = map[int]func(c *Checkpoint) error{
+	// the legacy base64 encoded format has the same fields as version 2
+	CheckpointVersionLegacy: func(c *Checkpoint) error { return nil },
+	// version 3 adds the Fingerprint and Head, which are unknown for older
+	// checkpoints, such that the checkpoint never matches a scan
+	2: func(c *Checkpoint) error { return nil },
+	// version 4 adds the ResultStore and Results, which are unknown for older
+	// checkpoints, but older checkpoints never match a scan (see version 3)
+	3: func(c *Checkpoint) error { return nil },
+	// version 5 adds the TrackerGeneration and TrackerStore, where the tracker
+	// data of older checkpoints is always stored in the checkpoint itself
+	4: func(c *Checkpoint) error { return nil },
+	// version 6 adds the Occurrences, where the occurrences of the objects
+	// with results are still rebuilt from the results of older checkpoints
+	5: func(c *Checkpoint) error { return nil },
+}
+
+// CheckpointFingerprint() function returns a fingerprint of the config values
+// that determine the requests of a scan (e.g. the commits in the scope of the
+// scan mode, and the chunking of files, which determines the request IDs) and
+// the results of the requests (i.e. the detector and its config), such that
+// the Checkpoint of a scan is only resumed by a scan with the same config. The
+// config of the detector is taken from the app config, which may be nil.
+func CheckpointFingerprint(git_config *cfg.GitConfig, detector string, config *cfg.Config) string {
+	sorted := func(values []string) []string {
+		out := append([]string{}, values...)
+		sort.Strings(out)
+		return out
 	}
-	if file_path == "" {
-		return ErrCheckpointDeleteFailed
+	// the auth key of the detector does not affect the results
+	var azure_ai cfg.AzureAIConfig
+	var rules cfg.RulesConfig
+	if config != nil {
+		azure_ai = config.AzureAI
+		azure_ai.AuthKey = ""
+		rules = config.Rules
 	}
-	logger.Debug().Msgf("deleting scan checkpoint file: %s", file_path)
-	err = os.Remove(file_path)
-	if err != nil {
-		return errors.Wrap(ErrCheckpointDeleteFailed, err.Error())
-	}
-	logger.Info().Msgf("deleted scan checkpoint file: %s", file_path)
-	return nil
+	data, _ := json.Marshal(struct {
+		AzureAI             cfg.AzureAIConfig `json:"azure_ai"`
+		Branch              string            `json:"branch"`
+		Detector            string            `json:"detector"`
+		Extensions          []string          `json:"extensions"`
+		IgnoreExtensions    []string          `json:"ignore_extensions"`
+		MaxRequestChunkSize int               `json:"max_request_chunk_size"`
+		Mode                string            `json:"mode"`
+		Range               string            `json:"range"`
+		Rules               cfg.RulesConfig   `json:"rules"`
+		Since               string            `json:"since"`
+	}{
+		AzureAI:             azure_ai,
+		Branch:              git_config.Scan.Branch,
+		Detector:            detector,
+		Extensions:          sorted(git_config.Scan.Extensions),
+		IgnoreExtensions:    sorted(git_config.Scan.IgnoreExtensions),
+		MaxRequestChunkSize: git_config.Scan.Limits.MaxRequestChunkSize,
+		Mode:                git_config.Scan.Mode,
+		Range:               git_config.Scan.Range,
+		Rules:               rules,
+		Since:               git_config.Scan.Since,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
-// CheckpointGet() function retrieves the Checkpoint data from the checkpoint file
-// for the given repository and commit ID. Returns a non-nil error if unable to read
-// valid Checkpoint data from the expected file path.
-func CheckpointGet(ctx context.Context, work_dir, repo_url, commit_id string) (cpoint *Checkpoint, e error) {
-	logger := zerolog.Ctx(ctx)
-	var file_path string
-	file_path, e = getCheckpointPath(work_dir, repo_url, commit_id)
-	if e != nil {
-		return
+// Mismatch() method compares the Checkpoint to the fingerprint of the config,
+// the tip commit, the path of the persistent result store (if any), and the
+// path and generation of the tracker store (if any) of a scan, and returns a
+// description of the difference, or an empty string if the scan can resume
+// from the Checkpoint.
+func (c *Checkpoint) Mismatch(fingerprint, head, result_store, tracker_store string, tracker_generation int64) string {
+	if c.Fingerprint != fingerprint {
+		return "checkpoint was created with a different scan config or detector"
 	}
-
-	var file *os.File
-	file, e = openCheckpointFile(work_dir, repo_url, commit_id)
-	if e != nil {
-		return
+	if c.Head != head {
+		return fmt.Sprintf("checkpoint was created at commit %s instead of commit %s", c.Head, head)
 	}
-	file_info, err := file.Stat()
-	if err != nil {
-		e = err
-		return
+	// the results of the completed requests are only available to the scan
+	// if the scan writes to the same persistent result store
+	if c.ResultStore != "" && c.ResultStore != result_store {
+		return fmt.Sprintf("checkpoint results are in result store %s, which is not used by the scan", c.ResultStore)
 	}
-
-	if file_info.Size() == 0 {
-		e = errors.Wrap(ErrCheckpointFileReadFailed, "file size is 0")
-		return
+	// the tracker data is only available to the scan if the scan uses the
+	// same tracker store
+	if c.TrackerStore != "" && c.TrackerStore != tracker_store {
+		return fmt.Sprintf("checkpoint tracker data is in tracker store %s, which is not used by the scan", c.TrackerStore)
 	}
-
-	data_encoded := make([]byte, file_info.Size())
-	_, e = file.Read(data_encoded)
-	if e != nil {
-		e = errors.Wrap(ErrCheckpointFileReadFailed, e.Error())
-		return
+	// a tracker store that was flushed after the Checkpoint was saved, e.g.
+	// due to a crash between both, does not hold the tracker data of the
+	// Checkpoint, e.g. its completed requests may have results that are
+	// missing from the results in the Checkpoint
+	if c.TrackerStore != "" && c.TrackerGeneration != tracker_generation {
+		return fmt.Sprintf(
+			"tracker store is at generation %d instead of generation %d of the checkpoint",
+			tracker_generation,
+			c.TrackerGeneration,
+		)
 	}
+	return ""
+}
 
-	data_json, err := base64.StdEncoding.DecodeString(string(data_encoded))
+// SetResults() method stores the results of the scan in the Checkpoint, which
+// are either the path of the persistent result store, or a copy of all results
+// in any other store. Must be called after the tracker data is copied to the
+// Checkpoint, because results are written before the associated request is
+// marked as complete, such that the results then include those of every
+// request that is complete in the Checkpoint.
+//
+// The text of each copied result is left out, such that the Checkpoint (and
+// its store, e.g. a file, database or bucket) never holds the detected
+// PHI/PII. The text is restored by the restoreResultsText() function.
+func (c *Checkpoint) SetResults(result_io rrr.ResultRecordIO) error {
+	if persistent, ok := result_io.(rrr.PersistentResultRecordIO); ok {
+		c.ResultStore = persistent.GetPath()
+		c.Results = nil
+		return nil
+	}
+	results, err := result_io.List()
 	if err != nil {
-		e = err
-		return
+		return err
 	}
-
-	// initialize the pointer to the Checkpoint struct
-	cpoint = &Checkpoint{}
-	// unmarshal the JSON data into the Checkpoint struct
-	e = json.Unmarshal(data_json, cpoint)
-	if e != nil {
-		e = errors.Wrap(e, ErrCheckpointDataUnmarshalFailed.Error())
-		return
+	c.ResultStore = ""
+	c.Results = make([]rrr.ResultRecord, len(results))
+	for i, result := range results {
+		result.Text = ""
+		c.Results[i] = result
 	}
-	logger.Info().Msgf("retrieved scan checkpoint data from file: %s", file_path)
-
-	return
+	return nil
 }
 
-// CheckpointSet() function saves the Checkpoint data to the checkpoint file for the
-// given repository and (optional) commit ID. Returns a non-nil error if unable to
-// write the Checkpoint data to the expected file path.
-func CheckpointSet(ctx context.Context, work_dir, repo_url, commit_id string, c *Checkpoint) (e error) {
-	logger := zerolog.Ctx(ctx)
-	var file *os.File
-	var file_path string
-	file_path, e = getCheckpointPath(work_dir, repo_url, commit_id)
-	if e != nil {
-		e = errors.Wrap(e, ErrMsgCheckpointSaveFailed)
-		return
+// migrateCheckpoint() function migrates the Checkpoint from its schema version
+// to CheckpointVersion, one version at a time. Returns a non-nil error if the
+// version is unknown, e.g. a Checkpoint written by a newer version of the app.
+func migrateCheckpoint(c *Checkpoint) error {
+	if c.Version == 0 {
+		c.Version = CheckpointVersionLegacy
 	}
-	// attempt to open the checkpoint file
-	file, e = openCheckpointFile(work_dir, repo_url, commit_id)
-	if e != nil {
-		// create the checkpoint file if it does not exist
-		file, e = createCheckpointFile(work_dir, repo_url, commit_id)
-		if e != nil {
-			e = errors.Wrap(e, ErrMsgCheckpointSaveFailed)
-			return
+	for c.Version < CheckpointVersion {
+		migrate, exists := checkpoint_migrations[c.Version]
+		if !exists {
+			return errors.Wrapf(ErrCheckpointVersionUnsupported, "version %d", c.Version)
+		}
+		if err := migrate(c); err != nil {
+			return errors.Wrapf(err, "failed to migrate checkpoint from version %d", c.Version)
 		}
-		logger.Debug().Msgf("created scan checkpoint file: %s", file_path)
+		c.Version++
 	}
-
-	// marshal the Checkpoint struct into JSON bytes
-	data_json, err := json.Marshal(c)
-	if err != nil {
-		e = errors.Wrap(err, ErrMsgCheckpointSaveFailed)
+	if c.Version > CheckpointVersion {
+		return errors.Wrapf(ErrCheckpointVersionUnsupported, "version %d", c.Version)
 	}
-	data_encoded := base64.StdEncoding.EncodeToString(data_json)
+	return nil
+}
 
-	// truncate the file to ensure it is empty before writing new data
-	err = file.Truncate(0)
-	if err != nil {
-		e = errors.Wrap(err, ErrMsgCheckpointSaveFailed)
-	}
+// CheckpointStore interface defines the methods for saving, retrieving, and
+// deleting Checkpoints from/to some store (e.g. file, database, object
+// storage), where each Checkpoint is identified by a key that is derived
+// from the repository URL and (optional) commit ID of the scan.
+type CheckpointStore interface {
+	Delete(key string) error
+	// Get() method returns ErrCheckpointNotFound if no Checkpoint exists
+	// for the key.
+	Get(key string) (*Checkpoint, error)
+	Set(key string, c *Checkpoint) error
+}
 
-	// write the base64-encoded JSON data to the file
-	_, err = file.WriteString(data_encoded)
+// CheckpointDelete() function deletes the Checkpoint from the store, based on
+// the given repository and (optional) commit ID. Returns a non-nil error if
+// unable to lookup the key of the Checkpoint or to delete an existing
+// Checkpoint.
+func CheckpointDelete(ctx context.Context, store CheckpointStore, repo_url, commit_id string) error {
+	logger := zerolog.Ctx(ctx)
+	key, err := getCheckpointKey(repo_url, commit_id)
 	if err != nil {
-		e = errors.Wrap(err, ErrMsgCheckpointSaveFailed)
+		return errors.Wrap(ErrCheckpointDeleteFailed, err.Error())
 	}
-	logger.Info().Msgf("saved scan checkpoint to file: %s", file_path)
+	logger.Debug().Msgf("deleting scan checkpoint: %s", key)
+	if err := store.Delete(key); err != nil {
+		return errors.Wrap(ErrCheckpointDeleteFailed, err.Error())
+	}
+	logger.Info().Msgf("deleted scan checkpoint: %s", key)
+	return nil
+}
 
+// CheckpointGet() function retrieves the Checkpoint data from the store for
+// the given repository and (optional) commit ID. Returns ErrCheckpointNotFound
+// if no Checkpoint exists, or another non-nil error if unable to read valid
+// Checkpoint data from the store.
+func CheckpointGet(ctx context.Context, store CheckpointStore, repo_url, commit_id string) (cpoint *Checkpoint, e error) {
+	logger := zerolog.Ctx(ctx)
+	var key string
+	key, e = getCheckpointKey(repo_url, commit_id)
+	if e != nil {
+		return
+	}
+	if cpoint, e = store.Get(key); e != nil {
+		if !errors.Is(e, ErrCheckpointNotFound) {
+			e = errors.Wrap(e, ErrMsgCheckpointGetFailed)
+		}
+		return
+	}
+	logger.Info().Msgf("retrieved scan checkpoint data: %s", key)
 	return
 }
 
-// createCheckpointFile() function is used to create a new checkpoint file for
-// the given repository URL and commit ID. Returns a non-nil error if the file
-// creation fails.
-func createCheckpointFile(work_dir, repo_url, commit_id string) (file *os.File, e error) {
-	var path string
-	// get the expected path of the checkpoint file
-	path, e = getCheckpointPath(work_dir, repo_url, commit_id)
+// CheckpointSet() function saves the Checkpoint data to the store for the
+// given repository and (optional) commit ID, replacing any existing
+// Checkpoint. Returns a non-nil error if unable to write the Checkpoint data
+// to the store.
+func CheckpointSet(ctx context.Context, store CheckpointStore, repo_url, commit_id string, c *Checkpoint) (e error) {
+	logger := zerolog.Ctx(ctx)
+	var key string
+	key, e = getCheckpointKey(repo_url, commit_id)
 	if e != nil {
 		e = errors.Wrap(e, ErrMsgCheckpointSaveFailed)
 		return
 	}
-	// create the parent directories as needed
-	if e = os.MkdirAll(filepath.Dir(path), os.ModePerm); e != nil {
+	if e = store.Set(key, c); e != nil {
 		e = errors.Wrap(e, ErrMsgCheckpointSaveFailed)
 		return
 	}
+	logger.Info().Msgf("saved scan checkpoint: %s", key)
+	return
+}
 
-	// create the file if it does not exist
-	file, err := os.Create(path)
-	if err != nil {
-		e = errors.Wrap(err, ErrMsgCheckpointSaveFailed)
+// DecodeCheckpoint() function decodes the data of a Checkpoint, which is
+// either gzip compressed JSON, or base64 encoded JSON written by the legacy
+// format of checkpoint files, and migrates the Checkpoint to CheckpointVersion.
+func DecodeCheckpoint(data []byte) (cpoint *Checkpoint, e error) {
+	if len(data) == 0 {
+		e = errors.Wrap(ErrCheckpointFileReadFailed, "file size is 0")
 		return
 	}
-	return
-}
 
-// getCheckpointPath() function is used to get the expected filesystem path of
-// the checkpoint file for a given repository URL and commit ID, where the
-// commit ID is optional. Returns a non-nil error if any required input is
-// empty or if the path lookup fails.
-func getCheckpointPath(work_dir, repo_url, commit_id string) (path string, e error) {
+	var data_json []byte
+	if bytes.HasPrefix(data, checkpoint_gzip_header) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			e = errors.Wrap(ErrCheckpointFileReadFailed, err.Error())
+			return
+		}
+		defer reader.Close()
+		if data_json, e = io.ReadAll(reader); e != nil {
+			e = errors.Wrap(ErrCheckpointFileReadFailed, e.Error())
+			return
+		}
+	} else {
+		data_json, e = base64.StdEncoding.DecodeString(string(data))
+		if e != nil {
+			e = errors.Wrap(ErrCheckpointFileReadFailed, e.Error())
+			return
+		}
+	}
 
-	if work_dir == "" {
-		e = errors.Wrap(ErrCheckpointPathLookupFailed, "work_dir is empty")
+	// initialize the pointer to the Checkpoint struct
+	cpoint = &Checkpoint{}
+	// unmarshal the JSON data into the Checkpoint struct
+	if e = json.Unmarshal(data_json, cpoint); e != nil {
+		cpoint = nil
+		e = errors.Wrap(e, ErrCheckpointDataUnmarshalFailed.Error())
 		return
 	}
+	if e = migrateCheckpoint(cpoint); e != nil {
+		cpoint = nil
+	}
+	return
+}
+
+// EncodeCheckpoint() function writes the Checkpoint to w as gzip compressed
+// JSON, which is the format read by DecodeCheckpoint().
+func EncodeCheckpoint(w io.Writer, c *Checkpoint) error {
+	writer := gzip.NewWriter(w)
+	if err := json.NewEncoder(writer).Encode(c); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// getCheckpointKey() function returns the key of the Checkpoint for a given
+// repository URL and commit ID, where the commit ID is optional. Returns a
+// non-nil error if the repository URL is empty or cannot be parsed.
+func getCheckpointKey(repo_url, commit_id string) (key string, e error) {
 	if repo_url == "" {
-		e = errors.Wrap(ErrCheckpointPathLookupFailed, "repo_url is empty")
+		e = errors.Wrap(ErrCheckpointKeyLookupFailed, "repo_url is empty")
 		return
 	}
 
 	var org_name string
 	org_name, e = nogit.ParseOrgNameFromURL(repo_url)
 	if e != nil {
-		e = errors.Wrap(ErrCheckpointPathLookupFailed, e.Error())
+		e = errors.Wrap(ErrCheckpointKeyLookupFailed, e.Error())
 		return
 	}
 	var repo_name string
 	repo_name, e = nogit.ParseRepoNameFromURL(repo_url)
 	if e != nil {
-		e = errors.Wrap(ErrCheckpointPathLookupFailed, e.Error())
+		e = errors.Wrap(ErrCheckpointKeyLookupFailed, e.Error())
 		return
 	}
 
-	// use the org_name and repo_name as the base name of the file
+	// use the org_name and repo_name as the base of the key
 	name_list := []string{org_name, repo_name}
-	// append the commit_id to the file name if it is not empty
+	// append the commit_id to the key if it is not empty
 	if commit_id != "" {
 		name_list = append(name_list, commit_id)
 	}
-	file_name := strings.Join(name_list, "_") + CheckpointFileExtension
-	path_list := []string{work_dir, cfg.WorkDirCheckpoints, file_name}
-	path = strings.Join(path_list, "/")
+	key = strings.Join(name_list, "_")
 	return
 }
 
-// openCheckpointFile() function is used to open the checkpoint file from its
-// expected filesystem path.
-func openCheckpointFile(work_dir, repo_url, commit_id string) (file *os.File, e error) {
-	path, e := getCheckpointPath(work_dir, repo_url, commit_id)
-	if e != nil {
-		return
-	}
-	file, e = os.OpenFile(path, os.O_CREATE|os.O_RDWR, os.ModePerm)
-	if e != nil {
-		e = errors.Wrap(ErrCheckpointFileOpenFailed, e.Error())
-		return
+// restoreResultsText() function restores the text of each result that was
+// left out of a Checkpoint by the SetResults() method, by reading the text
+// from the blob of the result in the repository at the (code point) offset
+// and length of the result.
+func restoreResultsText(repository *git.Repository, records []rrr.ResultRecord) error {
+	contents := make(map[string][]rune)
+	for i := range records {
+		record := &records[i]
+		if record.Text != "" || record.Length <= 0 {
+			continue
+		}
+		content, exists := contents[record.Object.ID]
+		if !exists {
+			blob, err := repository.BlobObject(plumbing.NewHash(record.Object.ID))
+			if err != nil {
+				return errors.Wrapf(err, "failed to read blob %s", record.Object.ID)
+			}
+			reader, err := blob.Reader()
+			if err != nil {
+				return errors.Wrapf(err, "failed to read blob %s", record.Object.ID)
+			}
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				return errors.Wrapf(err, "failed to read blob %s", record.Object.ID)
+			}
+			content = []rune(string(data))
+			contents[record.Object.ID] = content
+		}
+		start := record.Location.Offset
+		end := start + record.Length
+		if start < 0 || end > len(content) {
+			return errors.Errorf(
+				"result %s is out of range of blob %s",
+				record.Hash,
+				record.Object.ID,
+			)
+		}
+		record.Text = string(content[start:end])
 	}
-	return
+	return nil
 }
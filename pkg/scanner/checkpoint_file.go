@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+)
+
+// FileCheckpointStore struct provides an implementation of the
+// CheckpointStore interface that writes each Checkpoint to the file
+// "<work_dir>/checkpoints/<key>.checkpoint", and keeps the previous
+// CheckpointGenerations-1 generations of the file.
+type FileCheckpointStore struct {
+	CheckpointStore
+
+	logger   *zerolog.Logger
+	work_dir string
+}
+
+// NewFileCheckpointStore() function initializes a new FileCheckpointStore
+// object that stores checkpoint files in the given work dir.
+func NewFileCheckpointStore(ctx context.Context, work_dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{
+		logger:   zerolog.Ctx(ctx),
+		work_dir: work_dir,
+	}
+}
+
+// Delete() method deletes every generation of the checkpoint file for the
+// key. Returns a non-nil error if unable to delete an existing file.
+func (fs *FileCheckpointStore) Delete(key string) error {
+	file_path, err := fs.GetPath(key)
+	if err != nil {
+		return err
+	}
+	for generation := 0; generation < CheckpointGenerations; generation++ {
+		err = os.Remove(getCheckpointGenerationPath(file_path, generation))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get() method reads the Checkpoint from the checkpoint file for the key. If
+// the latest generation of the file cannot be read, e.g. due to a crash while
+// the file was written, then the previous generations are tried in order.
+// Returns ErrCheckpointNotFound if no checkpoint file exists, or another
+// non-nil error if unable to read valid Checkpoint data from any generation
+// of the file.
+func (fs *FileCheckpointStore) Get(key string) (cpoint *Checkpoint, e error) {
+	var file_path string
+	file_path, e = fs.GetPath(key)
+	if e != nil {
+		return
+	}
+
+	e = ErrCheckpointNotFound
+	for generation := 0; generation < CheckpointGenerations; generation++ {
+		generation_path := getCheckpointGenerationPath(file_path, generation)
+		c, err := readCheckpointFile(generation_path)
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		}
+		if err != nil {
+			fs.logger.Warn().Err(err).Msgf("skipping invalid scan checkpoint file: %s", generation_path)
+			e = err
+			continue
+		}
+		cpoint, e = c, nil
+		fs.logger.Debug().Msgf("read scan checkpoint file: %s", generation_path)
+		return
+	}
+	return
+}
+
+// GetPath() method returns the path of the checkpoint file for the key.
+// Returns a non-nil error if the work dir or the key is empty.
+func (fs *FileCheckpointStore) GetPath(key string) (string, error) {
+	if fs.work_dir == "" {
+		return "", errors.Wrap(ErrCheckpointPathLookupFailed, "work_dir is empty")
+	}
+	if key == "" {
+		return "", errors.Wrap(ErrCheckpointPathLookupFailed, "key is empty")
+	}
+	return filepath.Join(fs.work_dir, cfg.WorkDirCheckpoints, key+CheckpointFileExtension), nil
+}
+
+// Set() method writes the Checkpoint to the checkpoint file for the key. The
+// data is written to a temporary file that is synced to disk before it
+// replaces the checkpoint file, such that a crash never leaves a partially
+// written checkpoint file, and the previous generations of the file are kept.
+func (fs *FileCheckpointStore) Set(key string, c *Checkpoint) (e error) {
+	var file_path string
+	file_path, e = fs.GetPath(key)
+	if e != nil {
+		return
+	}
+	dir := filepath.Dir(file_path)
+	if e = os.MkdirAll(dir, os.ModePerm); e != nil {
+		return
+	}
+
+	// write the Checkpoint to a temporary file in the same directory, such
+	// that the file can be renamed to the checkpoint file
+	var temp_path string
+	temp_path, e = writeCheckpointTempFile(dir, filepath.Base(file_path), c)
+	if e != nil {
+		return
+	}
+	defer os.Remove(temp_path)
+
+	// shift the previous generations of the checkpoint file, discarding the
+	// oldest generation, then replace the checkpoint file with the new file
+	for generation := CheckpointGenerations - 1; generation > 0; generation-- {
+		err := os.Rename(
+			getCheckpointGenerationPath(file_path, generation-1),
+			getCheckpointGenerationPath(file_path, generation),
+		)
+		if err != nil && !os.IsNotExist(err) {
+			e = err
+			return
+		}
+	}
+	if e = os.Rename(temp_path, file_path); e != nil {
+		return
+	}
+	if e = syncDir(dir); e != nil {
+		return
+	}
+	fs.logger.Debug().Msgf("wrote scan checkpoint file: %s", file_path)
+	return
+}
+
+// getCheckpointGenerationPath() function returns the path of the generation
+// of the checkpoint file, where generation 0 is the latest checkpoint file,
+// and each older generation has the generation number as a suffix.
+func getCheckpointGenerationPath(file_path string, generation int) string {
+	if generation == 0 {
+		return file_path
+	}
+	return file_path + "." + strconv.Itoa(generation)
+}
+
+// getCheckpointPath() function is used to get the expected filesystem path of
+// the checkpoint file for a given repository URL and commit ID, where the
+// commit ID is optional. Returns a non-nil error if any required input is
+// empty or if the path lookup fails.
+func getCheckpointPath(work_dir, repo_url, commit_id string) (string, error) {
+	key, err := getCheckpointKey(repo_url, commit_id)
+	if err != nil {
+		return "", errors.Wrap(ErrCheckpointPathLookupFailed, err.Error())
+	}
+	return NewFileCheckpointStore(context.Background(), work_dir).GetPath(key)
+}
+
+// readCheckpointFile() function reads and decodes the Checkpoint from the
+// file at the given path. Returns an error that wraps os.ErrNotExist if the
+// file does not exist.
+func readCheckpointFile(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, errors.Wrap(ErrCheckpointFileOpenFailed, err.Error())
+	}
+	return DecodeCheckpoint(data)
+}
+
+// syncDir() function syncs the directory to disk, such that a file renamed
+// within the directory is persisted.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// writeCheckpointTempFile() function writes the Checkpoint to a new temporary
+// file in the directory and syncs the file to disk. Returns the path of the
+// temporary file, which is removed if the write fails.
+func writeCheckpointTempFile(dir, name string, c *Checkpoint) (path string, e error) {
+	file, err := os.CreateTemp(dir, name+CheckpointTempFilePattern)
+	if err != nil {
+		e = err
+		return
+	}
+	path = file.Name()
+	defer func() {
+		if e != nil {
+			os.Remove(path)
+			path = ""
+		}
+	}()
+	if e = EncodeCheckpoint(file, c); e != nil {
+		file.Close()
+		return
+	}
+	if e = file.Sync(); e != nil {
+		file.Close()
+		return
+	}
+	e = file.Close()
+	return
+}
@@ -0,0 +1,557 @@
+package scanner
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// testCheckpoint() helper function returns a new Checkpoint with one key in
+// the commits tracker data, where the key is the given commit ID.
+func testCheckpoint(t *testing.T, commit_id string) *Checkpoint {
+	t.Helper()
+	key_data, err := tracker.NewKeyData(tracker.KeyCodeComplete, "", []string{})
+	require.NoError(t, err)
+	return NewCheckpoint(
+		tracker.KeyDataMap{commit_id: key_data},
+		tracker.KeyDataMap{},
+		tracker.KeyDataMap{},
+	)
+}
+
+// TestCheckpoint() unit test function tests that a Checkpoint is saved to,
+// read from, and deleted from the checkpoint file, and that the previous
+// generations of the file are kept.
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	work_dir := t.TempDir()
+	store := NewFileCheckpointStore(test_context, work_dir)
+	file_path, err := getCheckpointPath(work_dir, test_repo_url, "")
+	require.NoError(t, err)
+
+	_, err = CheckpointGet(test_context, store, test_repo_url, "")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+
+	for i, commit_id := range []string{"commit-1", "commit-2", "commit-3", "commit-4"} {
+		require.NoError(t, CheckpointSet(test_context, store, test_repo_url, "", testCheckpoint(t, commit_id)))
+		cpoint, err := CheckpointGet(test_context, store, test_repo_url, "")
+		require.NoError(t, err)
+		assert.Equal(t, CheckpointVersion, cpoint.Version)
+		assert.Contains(t, cpoint.TrackerCommitsData, commit_id)
+		if i > 0 {
+			assert.FileExists(t, getCheckpointGenerationPath(file_path, 1))
+		}
+	}
+	// only the configured number of generations is kept, and no temporary
+	// files are left in the checkpoint directory
+	entries, err := os.ReadDir(filepath.Dir(file_path))
+	require.NoError(t, err)
+	assert.Len(t, entries, CheckpointGenerations)
+	assert.NoFileExists(t, getCheckpointGenerationPath(file_path, CheckpointGenerations))
+
+	// the checkpoint file is compressed instead of base64 encoded
+	data, err := os.ReadFile(file_path)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint_gzip_header, data[:2])
+
+	require.NoError(t, CheckpointDelete(test_context, store, test_repo_url, ""))
+	for generation := 0; generation < CheckpointGenerations; generation++ {
+		assert.NoFileExists(t, getCheckpointGenerationPath(file_path, generation))
+	}
+	_, err = CheckpointGet(test_context, store, test_repo_url, "")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+	assert.NoError(t, CheckpointDelete(test_context, store, test_repo_url, ""))
+}
+
+// TestCheckpointGet_Corrupted() unit test function tests that CheckpointGet()
+// falls back to the previous generation of a corrupted checkpoint file.
+func TestCheckpointGet_Corrupted(t *testing.T) {
+	t.Parallel()
+
+	work_dir := t.TempDir()
+	store := NewFileCheckpointStore(test_context, work_dir)
+	file_path, err := getCheckpointPath(work_dir, test_repo_url, "")
+	require.NoError(t, err)
+
+	require.NoError(t, CheckpointSet(test_context, store, test_repo_url, "", testCheckpoint(t, "commit-1")))
+	require.NoError(t, CheckpointSet(test_context, store, test_repo_url, "", testCheckpoint(t, "commit-2")))
+	// simulate a partial write of the latest generation
+	data, err := os.ReadFile(file_path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file_path, data[:len(data)/2], 0644))
+
+	cpoint, err := CheckpointGet(test_context, store, test_repo_url, "")
+	require.NoError(t, err)
+	assert.Contains(t, cpoint.TrackerCommitsData, "commit-1")
+
+	// every generation is corrupted
+	require.NoError(t, os.WriteFile(getCheckpointGenerationPath(file_path, 1), []byte{}, 0644))
+	_, err = CheckpointGet(test_context, store, test_repo_url, "")
+	assert.ErrorIs(t, err, ErrCheckpointFileReadFailed)
+}
+
+// TestCheckpointGet_Legacy() unit test function tests that a checkpoint file
+// in the legacy base64 encoded format is read and migrated.
+func TestCheckpointGet_Legacy(t *testing.T) {
+	t.Parallel()
+
+	work_dir := t.TempDir()
+	store := NewFileCheckpointStore(test_context, work_dir)
+	file_path, err := getCheckpointPath(work_dir, test_repo_url, "")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(file_path), os.ModePerm))
+
+	legacy := testCheckpoint(t, "commit-1")
+	legacy.Version = 0
+	data_json, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file_path, []byte(base64.StdEncoding.EncodeToString(data_json)), 0644))
+
+	cpoint, err := CheckpointGet(test_context, store, test_repo_url, "")
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointVersion, cpoint.Version)
+	assert.Contains(t, cpoint.TrackerCommitsData, "commit-1")
+}
+
+// Test_migrateCheckpoint() unit test function tests the migration of each
+// schema version of the Checkpoint.
+func Test_migrateCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	for version := 0; version <= CheckpointVersion; version++ {
+		c := &Checkpoint{Version: version}
+		assert.NoError(t, migrateCheckpoint(c))
+		assert.Equal(t, CheckpointVersion, c.Version)
+	}
+	assert.ErrorIs(t, migrateCheckpoint(&Checkpoint{Version: CheckpointVersion + 1}), ErrCheckpointVersionUnsupported)
+}
+
+// TestCheckpointFingerprint() unit test function tests that the fingerprint
+// only changes with the config values that affect the requests and results.
+func TestCheckpointFingerprint(t *testing.T) {
+	t.Parallel()
+
+	git_config := test_valid_git_config_func()
+	git_config.Scan.Extensions = []string{".md", ".csv"}
+	config := cfg.NewDefaultConfig()
+	config.AzureAI.AuthKey = "key"
+	fingerprint := CheckpointFingerprint(git_config, cfg.DetectorAzure, config)
+	assert.Len(t, fingerprint, 64)
+
+	// the order of extensions and config values that do not affect the
+	// requests do not change the fingerprint
+	git_config.Scan.Extensions = []string{".csv", ".md"}
+	git_config.Scan.Limits.MaxRequestsOutstanding++
+	config.AzureAI.AuthKey = "other-key"
+	assert.Equal(t, fingerprint, CheckpointFingerprint(git_config, cfg.DetectorAzure, config))
+	assert.NotEqual(t, fingerprint, CheckpointFingerprint(git_config, cfg.DetectorDryRun, config))
+	assert.NotEqual(t, fingerprint, CheckpointFingerprint(git_config, cfg.DetectorAzure, nil))
+
+	tests := []struct {
+		name   string
+		change func(git_config *cfg.GitConfig, config *cfg.Config)
+	}{
+		{name: "Branch", change: func(g *cfg.GitConfig, c *cfg.Config) { g.Scan.Branch = "feature" }},
+		{name: "ConfidenceThreshold", change: func(g *cfg.GitConfig, c *cfg.Config) { c.AzureAI.ConfidenceThreshold = 0.5 }},
+		{name: "IgnoreExtensions", change: func(g *cfg.GitConfig, c *cfg.Config) { g.Scan.IgnoreExtensions = []string{".csv"} }},
+		{name: "MaxRequestChunkSize", change: func(g *cfg.GitConfig, c *cfg.Config) { g.Scan.Limits.MaxRequestChunkSize++ }},
+		{name: "Mode", change: func(g *cfg.GitConfig, c *cfg.Config) { g.Scan.Mode = cfg.ScanModeHead }},
+		{name: "Range", change: func(g *cfg.GitConfig, c *cfg.Config) { g.Scan.Range = "v1..v2" }},
+		{name: "Rules", change: func(g *cfg.GitConfig, c *cfg.Config) {
+			c.Rules.Patterns = []cfg.RulePatternConfig{{Category: "Test", Pattern: "test"}}
+		}},
+		{name: "Since", change: func(g *cfg.GitConfig, c *cfg.Config) { g.Scan.Since = "2024-01-01" }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			git_config := test_valid_git_config_func()
+			git_config.Scan.Extensions = []string{".md", ".csv"}
+			config := cfg.NewDefaultConfig()
+			test.change(git_config, config)
+			assert.NotEqual(t, fingerprint, CheckpointFingerprint(git_config, cfg.DetectorAzure, config))
+		})
+	}
+}
+
+// testPersistentResultRecordIO struct wraps the in-memory store as a
+// persistent store at the given path.
+type testPersistentResultRecordIO struct {
+	memory.MemoryResultRecordIO
+	path string
+}
+
+// GetPath() method returns the path of the test store.
+func (io testPersistentResultRecordIO) GetPath() string {
+	return io.path
+}
+
+// TestCheckpoint_SetResults() unit test function tests that the results of a
+// non-persistent store are saved to and read from the checkpoint file without
+// their text, which is restored from the repository, while only the path of a
+// persistent store is saved.
+func TestCheckpoint_SetResults(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testModeRepository(t)
+	commit, err := repository.CommitObject(hashes[0])
+	require.NoError(t, err)
+	file, err := commit.File("a.md")
+	require.NoError(t, err)
+	record := func(hash, text string, offset int) rrr.ResultRecord {
+		return rrr.ResultRecord{
+			Hash:     hash,
+			Location: rrr.Location{Offset: offset, Path: "a.md"},
+			MetadataRequestResponse: rrr.MetadataRequestResponse{
+				Object: rrr.MetadataRequestResponseObject{ID: file.Hash.String()},
+			},
+			Result: rrr.Result{Category: "Test", Length: len(text), Text: text},
+		}
+	}
+
+	work_dir := t.TempDir()
+	store := NewFileCheckpointStore(test_context, work_dir)
+	result_io := memory.NewMemoryResultRecordIO(test_context)
+	results := []rrr.ResultRecord{
+		record("hash-1", "first", 0),
+		record("hash-2", "file", 6),
+	}
+	require.NoError(t, result_io.Write(results))
+
+	cpoint := testCheckpoint(t, "commit-1")
+	require.NoError(t, cpoint.SetResults(result_io))
+	assert.Empty(t, cpoint.ResultStore)
+	require.NoError(t, CheckpointSet(test_context, store, test_repo_url, "", cpoint))
+	cpoint, err = CheckpointGet(test_context, store, test_repo_url, "")
+	require.NoError(t, err)
+	require.Len(t, cpoint.Results, len(results))
+	for _, result := range cpoint.Results {
+		assert.Empty(t, result.Text)
+	}
+
+	// the text of the results is restored from the repository
+	require.NoError(t, restoreResultsText(repository, cpoint.Results))
+	assert.ElementsMatch(t, results, cpoint.Results)
+	out_of_range := []rrr.ResultRecord{record("hash-3", "", 8)}
+	out_of_range[0].Length = 10
+	assert.Error(t, restoreResultsText(repository, out_of_range))
+
+	// the results are restored to an empty store
+	restored_io := memory.NewMemoryResultRecordIO(test_context)
+	require.NoError(t, restored_io.Write(cpoint.Results))
+	restored, err := restored_io.List()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, results, restored)
+
+	persistent_io := testPersistentResultRecordIO{MemoryResultRecordIO: result_io, path: "results.db"}
+	require.NoError(t, cpoint.SetResults(persistent_io))
+	assert.Equal(t, "results.db", cpoint.ResultStore)
+	assert.Empty(t, cpoint.Results)
+}
+
+// TestScanner_checkResume() unit test function tests whether the scan resumes
+// from a Checkpoint with a different config or tip commit for each of the
+// resume config values.
+func TestScanner_checkResume(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		resume         string
+		fingerprint    string
+		head           string
+		expected       bool
+		expected_error error
+	}{
+		{name: "Match", resume: cfg.ResumeFail, fingerprint: "fingerprint", head: "head", expected: true},
+		{name: "Fingerprint_Fail", resume: cfg.ResumeFail, fingerprint: "other", head: "head", expected_error: ErrCheckpointMismatch},
+		{name: "Head_Fail", resume: cfg.ResumeFail, fingerprint: "fingerprint", head: "other", expected_error: ErrCheckpointMismatch},
+		{name: "Head_Default", resume: "", fingerprint: "fingerprint", head: "other", expected_error: ErrCheckpointMismatch},
+		{name: "Head_Discard", resume: cfg.ResumeDiscard, fingerprint: "fingerprint", head: "other", expected: false},
+		{name: "Head_Force", resume: cfg.ResumeForce, fingerprint: "fingerprint", head: "other", expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			git_config := test_valid_git_config_func()
+			git_config.Scan.Resume = test.resume
+			s, err := NewScanner(test_context, git_config, memory.NewMemoryResultRecordIO(test_context))
+			require.NoError(t, err)
+			s.fingerprint = test.fingerprint
+			s.head = test.head
+
+			cpoint := testCheckpoint(t, "commit-1")
+			cpoint.Fingerprint = "fingerprint"
+			cpoint.Head = "head"
+			resume, err := s.checkResume(cpoint)
+			if test.expected_error != nil {
+				assert.ErrorIs(t, err, test.expected_error)
+				assert.ErrorContains(t, err, "--resume="+cfg.ResumeForce)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, resume)
+		})
+	}
+
+	// a checkpoint without a fingerprint, e.g. from an older version of the
+	// app, never matches a scan
+	assert.NotEmpty(t, (&Checkpoint{}).Mismatch("fingerprint", "head", "", "", 0))
+
+	// a checkpoint with results in a persistent store only matches a scan
+	// that uses the same store
+	cpoint := &Checkpoint{Fingerprint: "fingerprint", Head: "head", ResultStore: "results.db"}
+	assert.Empty(t, cpoint.Mismatch("fingerprint", "head", "results.db", "", 0))
+	assert.NotEmpty(t, cpoint.Mismatch("fingerprint", "head", "other.db", "", 0))
+	assert.NotEmpty(t, cpoint.Mismatch("fingerprint", "head", "", "", 0))
+
+	// a checkpoint with tracker data in a tracker store only matches a scan
+	// that uses the same store at the same generation, whether or not the
+	// results are persistent
+	cpoint = &Checkpoint{Fingerprint: "fingerprint", Head: "head", TrackerGeneration: 2, TrackerStore: "trackers.db"}
+	assert.Empty(t, cpoint.Mismatch("fingerprint", "head", "", "trackers.db", 2))
+	assert.NotEmpty(t, cpoint.Mismatch("fingerprint", "head", "", "other.db", 2))
+	assert.NotEmpty(t, cpoint.Mismatch("fingerprint", "head", "", "", 0))
+	assert.NotEmpty(t, cpoint.Mismatch("fingerprint", "head", "", "trackers.db", 3))
+	cpoint.ResultStore = "results.db"
+	assert.Empty(t, cpoint.Mismatch("fingerprint", "head", "results.db", "trackers.db", 2))
+	assert.NotEmpty(t, cpoint.Mismatch("fingerprint", "head", "results.db", "trackers.db", 3))
+}
+
+// testKeyStore struct implements the tracker.KeyStore interface with maps.
+type testKeyStore struct {
+	generation int64
+	keys       map[string]tracker.KeyDataMap
+	mutex      sync.Mutex
+}
+
+func (ks *testKeyStore) Generation() (int64, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	return ks.generation, nil
+}
+
+func (ks *testKeyStore) Get(kind, key string) (tracker.KeyData, bool, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	key_data, exists := ks.keys[kind][key]
+	return key_data, exists, nil
+}
+
+func (ks *testKeyStore) GetPath() string {
+	return "trackers.db"
+}
+
+func (ks *testKeyStore) Range(kind string, fn func(key string, key_data tracker.KeyData) error) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	for key, key_data := range ks.keys[kind] {
+		if err := fn(key, key_data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *testKeyStore) Write(data map[string]tracker.KeyDataMap, reset []string, generation int64) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	for _, kind := range reset {
+		delete(ks.keys, kind)
+	}
+	for kind, kdm := range data {
+		if ks.keys[kind] == nil {
+			ks.keys[kind] = make(tracker.KeyDataMap)
+		}
+		for key, key_data := range kdm {
+			ks.keys[kind][key] = key_data
+		}
+	}
+	ks.generation = generation
+	return nil
+}
+
+// TestScanner_checkpointScan_TrackerStore() unit test function tests that the
+// Checkpoint of a scan with a tracker store only references the store, which
+// holds the tracker data, and that a new scan resumes from the store.
+func TestScanner_checkpointScan_TrackerStore(t *testing.T) {
+	t.Parallel()
+
+	git_config := test_valid_git_config_func()
+	git_config.WorkDir = t.TempDir()
+	key_store := &testKeyStore{keys: make(map[string]tracker.KeyDataMap)}
+	s, err := NewScanner(test_context, git_config, memory.NewMemoryResultRecordIO(test_context))
+	require.NoError(t, err)
+	require.NoError(t, s.SetTrackerStore(key_store))
+	s.fingerprint = "fingerprint"
+	s.head = "head"
+	_, err = s.TrackerCommits.Update("commit-1", tracker.KeyCodeInit, "", []string{})
+	require.NoError(t, err)
+	_, err = s.TrackerRequests.Update("request-1", tracker.KeyCodePending, "", []string{})
+	require.NoError(t, err)
+
+	// the first Checkpoint is saved before the ticker of checkpointScan()
+	chan_quit := make(chan struct{})
+	chan_errors := make(chan error, 1)
+	go s.checkpointScan(test_repo_url, "", chan_quit, chan_errors)
+	var cpoint *Checkpoint
+	require.Eventually(t, func() bool {
+		cpoint, err = CheckpointGet(test_context, s.checkpoint_store, test_repo_url, "")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	close(chan_quit)
+	assert.Equal(t, "trackers.db", cpoint.TrackerStore)
+	assert.Equal(t, int64(1), cpoint.TrackerGeneration)
+	assert.Empty(t, cpoint.TrackerCommitsData)
+	key_store.mutex.Lock()
+	assert.Contains(t, key_store.keys[tracker.ScanObjectTypeCommit], "commit-1")
+	key_store.mutex.Unlock()
+
+	// a new scan with the same tracker store resumes from the store
+	resumed, err := NewScanner(test_context, git_config, memory.NewMemoryResultRecordIO(test_context))
+	require.NoError(t, err)
+	require.NoError(t, resumed.SetTrackerStore(key_store))
+	resumed.fingerprint = "fingerprint"
+	resumed.head = "head"
+	resume, err := resumed.checkResume(cpoint)
+	require.NoError(t, err)
+	assert.True(t, resume)
+	require.NoError(t, resumed.restoreTrackers(cpoint))
+	_, exists := resumed.TrackerCommits.Get("commit-1")
+	assert.True(t, exists)
+	assert.Equal(t, 1, resumed.TrackerCommits.GetCounts().Init)
+	// the request without a response is re-queued
+	request_data, exists := resumed.TrackerRequests.Get("request-1")
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeInit, request_data.Code)
+
+	// a scan without a checkpoint resets the tracker store
+	require.NoError(t, resumed.restoreTrackers(nil))
+	_, exists = resumed.TrackerCommits.Get("commit-1")
+	assert.False(t, exists)
+}
+
+// testHoldDetector struct is a test detector that responds to the first
+// respond requests it receives, then holds every other request without a
+// response, i.e. the requests stay in flight until the scan is stopped.
+type testHoldDetector struct {
+	respond int
+}
+
+func (d *testHoldDetector) Run(ctx context.Context, requests <-chan rrr.Request, responses chan<- rrr.Response) {
+	var received int
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-requests:
+			received++
+			if received > d.respond {
+				continue
+			}
+			response := rrr.NewResponse(&request)
+			response.Results = []rrr.Result{{Category: "Test", Length: 1, Text: "t"}}
+			select {
+			case <-ctx.Done():
+				return
+			case responses <- response:
+			}
+		}
+	}
+}
+
+// TestScanner_Scan_Resume() unit test function tests that a scan which was
+// stopped with requests in flight resumes from its Checkpoint, where the
+// requests without a response are sent again, and the resumed scan finishes.
+func TestScanner_Scan_Resume(t *testing.T) {
+	t.Parallel()
+
+	repository, _ := testModeRepository(t)
+	git_config := test_valid_git_config_func()
+	git_config.WorkDir = t.TempDir()
+
+	// the first scan receives a response for one request only
+	ctx, cancel := context.WithCancel(test_context)
+	defer cancel()
+	stopped, err := NewScanner(ctx, git_config, memory.NewMemoryResultRecordIO(test_context))
+	require.NoError(t, err)
+	chan_requests := make(chan rrr.Request)
+	chan_responses := make(chan rrr.Response)
+	go (&testHoldDetector{respond: 1}).Run(ctx, chan_requests, chan_responses)
+	chan_stopped := make(chan struct{})
+	go func() {
+		defer close(chan_stopped)
+		stopped.Scan(ScanInput{
+			ChanErrorsSend:      make(chan error, 1),
+			ChanRequestSend:     chan_requests,
+			ChanResponseReceive: chan_responses,
+			Detector:            cfg.DetectorDryRun,
+			RepoID:              test_repo_url,
+			Repository:          repository,
+		})
+	}()
+	// wait for the first Checkpoint of the scan, such that it does not
+	// replace the Checkpoint that is saved below
+	require.Eventually(t, func() bool {
+		counts := stopped.TrackerRequests.GetCounts()
+		if counts.Complete != 1 || counts.Pending == 0 {
+			return false
+		}
+		_, err := CheckpointGet(test_context, stopped.checkpoint_store, test_repo_url, "")
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// save a Checkpoint with requests in flight, then stop the scan
+	chan_quit := make(chan struct{})
+	close(chan_quit)
+	stopped.checkpointScan(test_repo_url, "", chan_quit, make(chan error, 1))
+	cancel()
+	select {
+	case <-chan_stopped:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the first scan to stop")
+	}
+	cpoint, err := CheckpointGet(test_context, stopped.checkpoint_store, test_repo_url, "")
+	require.NoError(t, err)
+	var pending int
+	for _, key_data := range cpoint.TrackerRequestsData {
+		if key_data.Code == tracker.KeyCodePending {
+			pending++
+		}
+	}
+	assert.NotZero(t, pending)
+
+	// the resumed scan sends the requests without a response again
+	result_io := memory.NewMemoryResultRecordIO(test_context)
+	resumed, err := NewScanner(test_context, git_config, result_io)
+	require.NoError(t, err)
+	require.NoError(t, testRunScan(t, resumed, test_repo_url, repository))
+	for _, tr := range []tracker.Tracker{resumed.TrackerCommits, resumed.TrackerFiles, resumed.TrackerRequests} {
+		assert.True(t, tr.CheckAllComplete(), tr.GetKind())
+	}
+	assert.Zero(t, resumed.TrackerRequests.GetCounts().Error)
+
+	// the results of the first scan are restored from the Checkpoint, and
+	// the resumed scan has results for every file of the repository
+	records, err := result_io.List()
+	require.NoError(t, err)
+	objects := make(map[string]bool)
+	for _, record := range records {
+		objects[record.Object.ID] = true
+	}
+	assert.Len(t, objects, 4)
+	_, err = CheckpointGet(test_context, resumed.checkpoint_store, test_repo_url, "")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+}
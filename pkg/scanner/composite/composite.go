@@ -0,0 +1,209 @@
+package composite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// SuspiciousKeywords is the pattern of words that mark a chunk of text as
+// suspicious, even if the pre-filter did not detect any results, because the
+// surrounding text often contains PHI/PII that only the remote detector can
+// identify, such as the names of patients.
+var SuspiciousKeywords = regexp.MustCompile(
+	`(?i)\b(?:address|birth|diagnos[ie]s|dob|insurance|medical|mrn|name|patient|phone|prescri(?:be|ption)|ssn)\b`,
+)
+
+// Prefilter interface is implemented by any detector that can synchronously
+// detect results in a chunk of text without sending it over the network, e.g.
+// the rules.LocalRulesPhiDetector.
+type Prefilter interface {
+	Detect(text string) []rrr.Result
+}
+
+// CompositePhiDetector struct type runs a cheap pre-filter on every request
+// and only forwards suspicious requests to the (slower, more costly) remote
+// detector, then merges the results of both detectors.
+type CompositePhiDetector struct {
+	prefilter Prefilter
+	remote    rrr.RequestResponsePhiDetector
+}
+
+// NewCompositePhiDetector() function returns a new CompositePhiDetector that
+// uses the prefilter to decide which requests are sent to the remote detector.
+func NewCompositePhiDetector(prefilter Prefilter, remote rrr.RequestResponsePhiDetector) *CompositePhiDetector {
+	return &CompositePhiDetector{
+		prefilter: prefilter,
+		remote:    remote,
+	}
+}
+
+// Run() method listens for requests and runs the pre-filter on the text of
+// each request. Requests cleared by the pre-filter get an empty response, so
+// that the Scanner can mark them as complete, while suspicious requests are
+// forwarded to the remote detector and the responses of the remote detector
+// are merged with the results of the pre-filter.
+func (detector *CompositePhiDetector) Run(
+	ctx context.Context,
+	chan_requests_in <-chan rrr.Request,
+	chan_responses_out chan<- rrr.Response,
+) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().Msg("started composite detector")
+	defer logger.Info().Msg("finished composite detector")
+
+	chan_remote_requests := make(chan rrr.Request)
+	chan_remote_responses := make(chan rrr.Response)
+
+	// results of the pre-filter for requests sent to the remote detector
+	pending := make(map[string][]rrr.Result)
+	pending_mutex := &sync.Mutex{}
+
+	var wg sync.WaitGroup
+	// the remote detector closes chan_remote_responses when it returns
+	go detector.remote.Run(ctx, chan_remote_requests, chan_remote_responses)
+
+	// merge the responses of the remote detector with the pre-filter results
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for response := range chan_remote_responses {
+			pending_mutex.Lock()
+			prefilter_results, exists := pending[response.ID]
+			delete(pending, response.ID)
+			pending_mutex.Unlock()
+			if !exists {
+				logger.Warn().Msgf("composite detector received response for unknown request ID = %s", response.ID)
+			}
+			response.Results = MergeResults(prefilter_results, response.Results)
+			if !send(ctx, chan_responses_out, response) {
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(chan_remote_requests)
+		wg.Wait()
+		close(chan_responses_out)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Warn().Msg("stopping composite detector : context done")
+			return
+		case request, ok := <-chan_requests_in:
+			if !ok {
+				return
+			}
+			prefilter_results := detector.prefilter.Detect(request.Text)
+			if len(prefilter_results) == 0 && !SuspiciousKeywords.MatchString(request.Text) {
+				// the request was cleared by the pre-filter
+				logger.Trace().Msgf("composite detector cleared request ID = %s", request.ID)
+				if !send(ctx, chan_responses_out, rrr.NewResponse(&request)) {
+					return
+				}
+				continue
+			}
+
+			pending_mutex.Lock()
+			pending[request.ID] = prefilter_results
+			pending_mutex.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case chan_remote_requests <- request:
+			}
+		}
+	}
+}
+
+// MergeResults() function merges the results of several detectors into a
+// single slice, sorted by offset. Results with the same offset and length are
+// considered duplicates, where only the result with the highest confidence
+// score is kept. Results of the same category with overlapping spans are then
+// merged into a single result that spans both, with the highest confidence
+// score of the two, such that the same entity found by several detectors
+// (e.g. with or without a prefix) is only reported once.
+func MergeResults(result_sets ...[]rrr.Result) []rrr.Result {
+	deduped := make([]rrr.Result, 0)
+	index := make(map[string]int)
+	for _, results := range result_sets {
+		for _, result := range results {
+			key := fmt.Sprintf("%d:%d", result.Offset, result.Length)
+			i, exists := index[key]
+			if !exists {
+				index[key] = len(deduped)
+				deduped = append(deduped, result)
+				continue
+			}
+			if result.ConfidenceScore > deduped[i].ConfidenceScore {
+				deduped[i] = result
+			}
+		}
+	}
+	sort.SliceStable(deduped, func(i, j int) bool {
+		if deduped[i].Offset == deduped[j].Offset {
+			return deduped[i].Length < deduped[j].Length
+		}
+		return deduped[i].Offset < deduped[j].Offset
+	})
+
+	// merge each result into the last merged result of the same category if
+	// their spans overlap, where the merged result keeps the earlier offset
+	merged := make([]rrr.Result, 0, len(deduped))
+	last := make(map[string]int)
+	for _, result := range deduped {
+		i, exists := last[result.Category]
+		if exists && result.Offset < merged[i].Offset+merged[i].Length {
+			merged[i] = mergeSpans(merged[i], result)
+			continue
+		}
+		last[result.Category] = len(merged)
+		merged = append(merged, result)
+	}
+	return merged
+}
+
+// mergeSpans() function returns a single result that spans both of the
+// overlapping results, where a starts at or before b, with the category,
+// confidence score, service, and subcategory of the result with the higher
+// confidence score.
+func mergeSpans(a, b rrr.Result) rrr.Result {
+	out := a
+	if b.ConfidenceScore > a.ConfidenceScore {
+		out = b
+	}
+	out.Offset = a.Offset
+	out.Length = a.Length
+	out.Text = a.Text
+	if end := b.Offset + b.Length; end > a.Offset+a.Length {
+		out.Length = end - a.Offset
+		// append the text of b that follows the end of a, if the text of
+		// each result has the length of the result
+		a_text, b_text := []rune(a.Text), []rune(b.Text)
+		if len(a_text) == a.Length && len(b_text) == b.Length {
+			out.Text = string(append(a_text, b_text[a.Offset+a.Length-b.Offset:]...))
+		}
+	}
+	return out
+}
+
+// send() function sends the response to the channel, or returns false if the
+// context is done before the response can be sent.
+func send(ctx context.Context, chan_responses_out chan<- rrr.Response, response rrr.Response) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case chan_responses_out <- response:
+		return true
+	}
+}
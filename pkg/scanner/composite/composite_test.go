@@ -0,0 +1,149 @@
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rules"
+)
+
+// testRemoteDetector struct type is a fake remote detector that returns a
+// single, high confidence result for the first 11 characters of each request
+// and records the IDs of the requests that it received.
+type testRemoteDetector struct {
+	received []string
+}
+
+func (d *testRemoteDetector) Run(
+	ctx context.Context,
+	chan_requests_in <-chan rrr.Request,
+	chan_responses_out chan<- rrr.Response,
+) {
+	defer close(chan_responses_out)
+	for request := range chan_requests_in {
+		d.received = append(d.received, request.ID)
+		response := rrr.NewResponse(&request)
+		response.Results = append(response.Results, rrr.Result{
+			Category:        "Remote",
+			ConfidenceScore: 0.99,
+			Length:          11,
+			Offset:          0,
+			Service:         "remote",
+		})
+		chan_responses_out <- response
+	}
+}
+
+// TestCompositePhiDetector_Run() unit test function tests that requests
+// cleared by the pre-filter are not sent to the remote detector and that the
+// results of both detectors are merged.
+func TestCompositePhiDetector_Run(t *testing.T) {
+	t.Parallel()
+
+	prefilter, err := rules.NewLocalRulesPhiDetector(cfg.NewDefaultConfig())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	remote := &testRemoteDetector{}
+	detector := NewCompositePhiDetector(prefilter, remote)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chan_requests_in := make(chan rrr.Request)
+	chan_responses_out := make(chan rrr.Response)
+
+	go detector.Run(ctx, chan_requests_in, chan_responses_out)
+
+	requests := []rrr.Request{
+		{
+			MetadataRequestResponse: rrr.MetadataRequestResponse{ID: "cleared"},
+			Text:                    "func main() { fmt.Println(42) }",
+		},
+		{
+			MetadataRequestResponse: rrr.MetadataRequestResponse{ID: "ssn"},
+			Text:                    "123-45-6789 and jane.doe@example.com",
+		},
+		{
+			MetadataRequestResponse: rrr.MetadataRequestResponse{ID: "keyword"},
+			Text:                    "patient name: Jane Doe",
+		},
+	}
+	go func() {
+		for _, request := range requests {
+			chan_requests_in <- request
+		}
+		close(chan_requests_in)
+	}()
+
+	responses := make(map[string]rrr.Response)
+	for response := range chan_responses_out {
+		responses[response.ID] = response
+	}
+
+	if !assert.Len(t, responses, len(requests)) {
+		t.FailNow()
+	}
+	assert.ElementsMatch(t, []string{"ssn", "keyword"}, remote.received)
+
+	// cleared requests still get an (empty) response
+	assert.Empty(t, responses["cleared"].Results)
+
+	// the SSN result of the pre-filter has the same offset and length as the
+	// result of the remote detector, which has a higher confidence score
+	ssn_results := responses["ssn"].Results
+	if assert.Len(t, ssn_results, 2) {
+		assert.Equal(t, "Remote", ssn_results[0].Category)
+		assert.Equal(t, rules.CategoryEmail, ssn_results[1].Category)
+	}
+
+	keyword_results := responses["keyword"].Results
+	if assert.Len(t, keyword_results, 1) {
+		assert.Equal(t, "Remote", keyword_results[0].Category)
+	}
+}
+
+// TestMergeResults() unit test function tests the MergeResults() function,
+// which removes duplicate results and merges overlapping results.
+func TestMergeResults(t *testing.T) {
+	t.Parallel()
+
+	local := []rrr.Result{
+		{Category: "A", ConfidenceScore: 0.9, Length: 5, Offset: 10},
+		{Category: "B", ConfidenceScore: 0.5, Length: 4, Offset: 0},
+	}
+	remote := []rrr.Result{
+		{Category: "C", ConfidenceScore: 0.7, Length: 5, Offset: 10},
+		{Category: "D", ConfidenceScore: 0.8, Length: 4, Offset: 0},
+		{Category: "E", ConfidenceScore: 0.6, Length: 8, Offset: 10},
+	}
+
+	merged := MergeResults(local, remote)
+	assert.Equal(t, []rrr.Result{
+		{Category: "D", ConfidenceScore: 0.8, Length: 4, Offset: 0},
+		{Category: "A", ConfidenceScore: 0.9, Length: 5, Offset: 10},
+		{Category: "E", ConfidenceScore: 0.6, Length: 8, Offset: 10},
+	}, merged)
+
+	assert.Empty(t, MergeResults(nil, nil))
+
+	// overlapping results of the same category are merged into one result
+	// with the highest confidence score, unlike results of other categories
+	text := "MRN: 12345678!"
+	local = []rrr.Result{
+		{Category: "MRN", ConfidenceScore: 0.75, Length: 8, Offset: 5, Service: "local", Text: "12345678"},
+		{Category: "Phone", ConfidenceScore: 0.8, Length: 4, Offset: 9, Service: "local", Text: "5678"},
+	}
+	remote = []rrr.Result{
+		{Category: "MRN", ConfidenceScore: 0.9, Length: 9, Offset: 0, Service: "remote", Text: "MRN: 1234"},
+		{Category: "MRN", ConfidenceScore: 0.5, Length: 2, Offset: 12, Service: "remote", Text: "8!"},
+	}
+	merged = MergeResults(local, remote)
+	assert.Equal(t, []rrr.Result{
+		{Category: "MRN", ConfidenceScore: 0.9, Length: 14, Offset: 0, Service: "remote", Text: text},
+		{Category: "Phone", ConfidenceScore: 0.8, Length: 4, Offset: 9, Service: "local", Text: "5678"},
+	}, merged)
+}
@@ -3,7 +3,15 @@ package scanner
 import "time"
 
 const CheckpointFileExtension string = ".checkpoint"
+const CheckpointGenerations int = 3
 const CheckpointRefreshInterval time.Duration = ScanRefreshInterval * 2
+const CheckpointTempFilePattern string = ".tmp-*"
+const CheckpointVersion int = 6
+const CheckpointVersionLegacy int = 1
+
+const ErrorCodeRetryFailed string = "RetryFailed"
+
+const FailedChunksFileExtension string = ".json"
 
 const IgnoreReasonDefault string = "ignored_by_default"
 const IgnoreReasonDirPath string = "directory_path"
@@ -17,3 +25,4 @@ const IgnoreReasonFileName string = "file_name"
 const IgnoreReasonFilePath string = "file_path"
 
 const ScanRefreshInterval time.Duration = time.Second * 5
+const ScanSummaryMaxErrors int = 100
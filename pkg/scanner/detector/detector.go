@@ -0,0 +1,123 @@
+package detector
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/composite"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/dryrun"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rules"
+)
+
+// Detector type is an alias of the rrr.RequestResponsePhiDetector interface,
+// which is implemented by any detection backend that can read the
+// rrr.Requests sent by the Scanner and send rrr.Responses back to the Scanner.
+type Detector = rrr.RequestResponsePhiDetector
+
+// Factory func type is used to create a new Detector from the app config.
+type Factory func(ctx context.Context, config *cfg.Config) (Detector, error)
+
+var (
+	registry       = make(map[string]Factory)
+	registry_mutex = &sync.RWMutex{}
+)
+
+func init() {
+	Register(cfg.DetectorAzure, newAzureDetector)
+	Register(cfg.DetectorComposite, newCompositeDetector)
+	Register(cfg.DetectorDryRun, newDryRunDetector)
+	Register(cfg.DetectorRegex, newRegexDetector)
+}
+
+// New() function creates a new instance of the Detector selected by the
+// Detector value of the config.
+func New(ctx context.Context, config *cfg.Config) (Detector, error) {
+	return NewByName(ctx, config.Detector, config)
+}
+
+// NewByName() function creates a new instance of the named Detector, or
+// returns an error if no Detector has been registered with that name.
+func NewByName(ctx context.Context, name string, config *cfg.Config) (Detector, error) {
+	registry_mutex.RLock()
+	factory, exists := registry[name]
+	registry_mutex.RUnlock()
+	if !exists {
+		return nil, errors.Wrapf(ErrDetectorNotRegistered, "name = %s", name)
+	}
+
+	d, err := factory(ctx, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create detector %s", name)
+	}
+	return d, nil
+}
+
+// Names() function returns the sorted names of all registered Detectors.
+func Names() []string {
+	registry_mutex.RLock()
+	defer registry_mutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register() function makes a Detector available by name, such that it can
+// be selected via the config. Registering the same name twice replaces the
+// previously registered Factory.
+func Register(name string, factory Factory) {
+	registry_mutex.Lock()
+	defer registry_mutex.Unlock()
+
+	registry[name] = factory
+}
+
+// newAzureDetector() function is the Factory for the Detector that uses the
+// Azure AI Language service.
+func newAzureDetector(ctx context.Context, config *cfg.Config) (Detector, error) {
+	ai, err := az.NewEntityDetectionAI(config)
+	if err != nil {
+		return nil, err
+	}
+	return az.NewAzAiLanguagePhiDetector(ai), nil
+}
+
+// newCompositeDetector() function is the Factory for the Detector that uses
+// local rules as a pre-filter and only sends suspicious requests to the Azure
+// AI Language service.
+func newCompositeDetector(ctx context.Context, config *cfg.Config) (Detector, error) {
+	prefilter, err := rules.NewLocalRulesPhiDetector(config)
+	if err != nil {
+		return nil, err
+	}
+	ai, err := az.NewEntityDetectionAI(config)
+	if err != nil {
+		return nil, err
+	}
+	return composite.NewCompositePhiDetector(prefilter, az.NewAzAiLanguagePhiDetector(ai)), nil
+}
+
+// newDryRunDetector() function is the Factory for the Detector that returns
+// a dummy result for every request.
+func newDryRunDetector(ctx context.Context, config *cfg.Config) (Detector, error) {
+	return dryrun.NewDryRunPhiDetector(), nil
+}
+
+// newRegexDetector() function is the Factory for the Detector that uses local
+// rules (i.e. regular expressions and checksums) and requires no network.
+func newRegexDetector(ctx context.Context, config *cfg.Config) (Detector, error) {
+	d, err := rules.NewLocalRulesPhiDetector(config)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,84 @@
+package detector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/az"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/composite"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/dryrun"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rules"
+)
+
+// TestNew() unit test function tests the New() and NewByName() functions
+// for the built-in detectors.
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	config := cfg.NewDefaultConfig()
+	config.AzureAI.AuthKey = "test-key"
+	config.AzureAI.Service = "https://example.com"
+
+	tests := []struct {
+		expected_err  error
+		expected_type Detector
+		name          string
+	}{
+		{
+			expected_type: &az.AzAiLanguagePhiDetector{},
+			name:          cfg.DetectorAzure,
+		},
+		{
+			expected_type: &composite.CompositePhiDetector{},
+			name:          cfg.DetectorComposite,
+		},
+		{
+			expected_type: &dryrun.DryRunPhiDetector{},
+			name:          cfg.DetectorDryRun,
+		},
+		{
+			expected_type: &rules.LocalRulesPhiDetector{},
+			name:          cfg.DetectorRegex,
+		},
+		{
+			expected_err: ErrDetectorNotRegistered,
+			name:         "unknown",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.Detector = test.name
+			d, err := New(ctx, config)
+			if test.expected_err != nil {
+				assert.Equal(t, test.expected_err, errors.Cause(err))
+				assert.Nil(t, d)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, test.expected_type, d)
+		})
+	}
+
+	// the azure detector requires a valid config
+	config.AzureAI.AuthKey = ""
+	_, err := NewByName(ctx, cfg.DetectorAzure, config)
+	assert.Error(t, err)
+}
+
+// TestRegister() unit test function tests the Register() and Names() functions.
+func TestRegister(t *testing.T) {
+	Register("test", func(ctx context.Context, config *cfg.Config) (Detector, error) {
+		return dryrun.NewDryRunPhiDetector(), nil
+	})
+	assert.Contains(t, Names(), "test")
+	assert.Contains(t, Names(), cfg.DetectorAzure)
+	assert.Contains(t, Names(), cfg.DetectorDryRun)
+
+	d, err := NewByName(context.Background(), "test", cfg.NewDefaultConfig())
+	assert.NoError(t, err)
+	assert.NotNil(t, d)
+}
@@ -0,0 +1,7 @@
+package detector
+
+import "github.com/pkg/errors"
+
+var (
+	ErrDetectorNotRegistered = errors.New("no detector registered with name")
+)
@@ -3,27 +3,48 @@ package scanner
 import "github.com/pkg/errors"
 
 const (
-	ErrMsgAddScanRepository      = "failed to add ScanRepository"
-	ErrMsgCheckpointGetFailed    = "failed to get checkpoint data from file"
-	ErrMsgCheckpointSaveFailed   = "failed to save checkpoint data to file"
-	ErrMsgCheckpointScanProgress = "failed to update scan progress"
-	ErrMsgErrorChannelNil        = "received nil error channel as input"
-	ErrMsgResultWriteFailed      = "failed to write result"
-	ErrMsgScanRepositoryCreate   = "failed to create new ScanRepository object"
-	ErrMsgScanRepositoryScan     = "failed to scan repository"
-	ErrMsgScanTrackerUpdateFile  = "failed to update tracker for file %s"
-	ErrMsgScannerCreate          = "failed to create new Scanner"
-	ErrMsgTrackerUpdateCommit    = "failed to update tracker for commit %s"
+	ErrMsgAddScanRepository       = "failed to add ScanRepository"
+	ErrMsgCheckpointGetFailed     = "failed to get checkpoint data"
+	ErrMsgCheckpointResults       = "failed to restore results from checkpoint"
+	ErrMsgCheckpointSaveFailed    = "failed to save checkpoint data"
+	ErrMsgCheckpointScanProgress  = "failed to update scan progress"
+	ErrMsgCheckpointTrackers      = "failed to restore trackers from checkpoint"
+	ErrMsgErrorChannelNil         = "received nil error channel as input"
+	ErrMsgErrorPolicyExceeded     = "stopped scan after %d recoverable errors"
+	ErrMsgFailedChunksDelete      = "failed to delete failed chunks file"
+	ErrMsgFailedChunksGet         = "failed to get failed chunks from file"
+	ErrMsgFailedChunksSet         = "failed to save failed chunks to file"
+	ErrMsgRetryChunk              = "failed to retry chunk of request %s"
+	ErrMsgResultClassify          = "failed to classify results"
+	ErrMsgResultOccurrencesUpdate = "failed to update occurrences of result records"
+	ErrMsgResultWriteFailed       = "failed to write result"
+	ErrMsgScanCommitRange         = "failed to get commits in range %s..%s"
+	ErrMsgScanCommitTree          = "failed to get tree of commit %s"
+	ErrMsgScanFile                = "failed to scan file %s (%s)"
+	ErrMsgScanRepositoryCreate    = "failed to create new ScanRepository object"
+	ErrMsgScanRepositoryScan      = "failed to scan repository"
+	ErrMsgScanRevisionResolve     = "failed to resolve revision %s"
+	ErrMsgScanTrackerUpdateFile   = "failed to update tracker for file %s"
+	ErrMsgScannerCreate           = "failed to create new Scanner"
+	ErrMsgScannerTrackerStore     = "failed to set tracker store of Scanner"
+	ErrMsgTrackerUpdateCommit     = "failed to update tracker for commit %s"
 )
 
 var (
 	ErrCheckpointDataUnmarshalFailed    = errors.New("failed to unmarshal checkpoint data")
 	ErrCheckpointDeleteFailed           = errors.New("failed to delete checkpoint file")
 	ErrCheckpointFileOpenFailed         = errors.New("failed to open checkpoint file")
+	ErrCheckpointMismatch               = errors.New("checkpoint does not match scan")
 	ErrCheckpointFileReadFailed         = errors.New("failed to read checkpoint file")
+	ErrCheckpointKeyLookupFailed        = errors.New("failed to lookup checkpoint key")
+	ErrCheckpointNotFound               = errors.New("checkpoint file not found")
 	ErrCheckpointPathLookupFailed       = errors.New("failed to lookup checkpoint path")
+	ErrCheckpointVersionUnsupported     = errors.New("unsupported checkpoint version")
+	ErrFailedChunksPathLookupFailed     = errors.New("failed to lookup failed chunks path")
+	ErrRetryChunkOutOfRange             = errors.New("chunk is out of range of the file contents")
 	ErrProcessRequestNoID               = errors.New("cannot process a request without a valid ID")
 	ErrProcessResponseNoID              = errors.New("cannot process a response without a valid ID")
+	ErrScanModeInvalid                  = errors.New("invalid scan mode")
 	ErrScannerAddScanRepositoryEmptyID  = errors.New("cannot add a ScanRepository with an empty ID")
 	ErrScannerAddScanRepositoryNil      = errors.New("cannot add a nil ScanRepository to scanner")
 	ErrScannerGetScanRepositoryNotFound = errors.New("ScanRepository not found")
@@ -20,10 +20,30 @@ func TestErrors(t *testing.T) {
 			err:  ErrCheckpointFileOpenFailed,
 			name: "ErrCheckpointFileOpenFailed",
 		},
+		{
+			err:  ErrCheckpointKeyLookupFailed,
+			name: "ErrCheckpointKeyLookupFailed",
+		},
+		{
+			err:  ErrCheckpointMismatch,
+			name: "ErrCheckpointMismatch",
+		},
+		{
+			err:  ErrCheckpointNotFound,
+			name: "ErrCheckpointNotFound",
+		},
 		{
 			err:  ErrCheckpointPathLookupFailed,
 			name: "ErrCheckpointPathLookupFailed",
 		},
+		{
+			err:  ErrCheckpointVersionUnsupported,
+			name: "ErrCheckpointVersionUnsupported",
+		},
+		{
+			err:  ErrFailedChunksPathLookupFailed,
+			name: "ErrFailedChunksPathLookupFailed",
+		},
 		{
 			err:  ErrProcessRequestNoID,
 			name: "ErrProcessRequestNoID",
@@ -32,6 +52,10 @@ func TestErrors(t *testing.T) {
 			err:  ErrProcessResponseNoID,
 			name: "ErrProcessResponseNoID",
 		},
+		{
+			err:  ErrRetryChunkOutOfRange,
+			name: "ErrRetryChunkOutOfRange",
+		},
 		{
 			err:  ErrScannerAddScanRepositoryEmptyID,
 			name: "ErrScannerAddScanRepositoryEmptyID",
@@ -0,0 +1,298 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// FailedChunk struct describes a chunk of a file (i.e. the text of a request)
+// for which the detector returned an error response, such that the chunk can
+// be located in the repository and retried by a later scan.
+type FailedChunk struct {
+	// embed the MetadataRequestResponse struct of the failed request
+	rrr.MetadataRequestResponse
+	// Error is the error returned by the detector for the failed request.
+	Error rrr.ResponseError `json:"error"`
+}
+
+// NewFailedChunk() function creates a new FailedChunk from the error response
+// of a failed request.
+func NewFailedChunk(r *rrr.Response) FailedChunk {
+	chunk := FailedChunk{MetadataRequestResponse: r.MetadataRequestResponse}
+	// occurrences are recorded again when the chunk is retried
+	chunk.Occurrences = nil
+	if r.Error != nil {
+		chunk.Error = *r.Error
+	}
+	return chunk
+}
+
+// FailedChunksDelete() function deletes the file of failed chunks for the
+// given repository, if the file exists.
+func FailedChunksDelete(ctx context.Context, work_dir, repo_url string) error {
+	file_path, err := getFailedChunksPath(work_dir, repo_url)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgFailedChunksDelete)
+	}
+	if err = os.Remove(file_path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, ErrMsgFailedChunksDelete)
+	}
+	zerolog.Ctx(ctx).Debug().Msgf("deleted failed chunks file: %s", file_path)
+	return nil
+}
+
+// FailedChunksGet() function reads the failed chunks for the given repository
+// from the file written by FailedChunksSet(). Returns an empty slice if the
+// file does not exist, i.e. if no chunks failed in the last scan.
+func FailedChunksGet(ctx context.Context, work_dir, repo_url string) (chunks []FailedChunk, e error) {
+	var file_path string
+	file_path, e = getFailedChunksPath(work_dir, repo_url)
+	if e != nil {
+		e = errors.Wrap(e, ErrMsgFailedChunksGet)
+		return
+	}
+	data, err := os.ReadFile(file_path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return
+		}
+		e = errors.Wrap(err, ErrMsgFailedChunksGet)
+		return
+	}
+	if e = json.Unmarshal(data, &chunks); e != nil {
+		e = errors.Wrap(e, ErrMsgFailedChunksGet)
+		return
+	}
+	zerolog.Ctx(ctx).Debug().Msgf("read %d failed chunks from file: %s", len(chunks), file_path)
+	return
+}
+
+// FailedChunksSet() function writes the failed chunks for the given repository
+// to the file "<work_dir>/failed/<org>_<repo>.json".
+func FailedChunksSet(ctx context.Context, work_dir, repo_url string, chunks []FailedChunk) error {
+	file_path, err := getFailedChunksPath(work_dir, repo_url)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgFailedChunksSet)
+	}
+	data, err := json.MarshalIndent(chunks, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, ErrMsgFailedChunksSet)
+	}
+	if err = os.MkdirAll(filepath.Dir(file_path), os.ModePerm); err != nil {
+		return errors.Wrap(err, ErrMsgFailedChunksSet)
+	}
+	if err = os.WriteFile(file_path, data, 0644); err != nil {
+		return errors.Wrap(err, ErrMsgFailedChunksSet)
+	}
+	zerolog.Ctx(ctx).Info().Msgf("saved %d failed chunks to file: %s", len(chunks), file_path)
+	return nil
+}
+
+// getFailedChunksPath() function returns the expected filesystem path of the
+// file of failed chunks for the given repository URL.
+func getFailedChunksPath(work_dir, repo_url string) (string, error) {
+	if work_dir == "" {
+		return "", errors.Wrap(ErrFailedChunksPathLookupFailed, "work_dir is empty")
+	}
+	name, err := nogit.ParseOrgRepoNameFromURL(repo_url)
+	if err != nil {
+		return "", errors.Wrap(ErrFailedChunksPathLookupFailed, err.Error())
+	}
+	return filepath.Join(work_dir, cfg.WorkDirFailed, name+FailedChunksFileExtension), nil
+}
+
+// failedChunks struct records the failed chunks of a scan, keyed by the ID of
+// the failed request, and is safe for concurrent use.
+type failedChunks struct {
+	chunks map[string]FailedChunk
+	mutex  *sync.RWMutex
+}
+
+// newFailedChunks() function initializes a new, empty failedChunks struct.
+func newFailedChunks() *failedChunks {
+	return &failedChunks{
+		chunks: make(map[string]FailedChunk),
+		mutex:  &sync.RWMutex{},
+	}
+}
+
+// Add() method records the failed chunk, replacing any failed chunk of the
+// same request ID.
+func (fc *failedChunks) Add(chunk FailedChunk) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.chunks[chunk.ID] = chunk
+}
+
+// List() method returns the recorded failed chunks, sorted by commit ID, path,
+// and offset.
+func (fc *failedChunks) List() []FailedChunk {
+	fc.mutex.RLock()
+	defer fc.mutex.RUnlock()
+	chunks := make([]FailedChunk, 0, len(fc.chunks))
+	for _, chunk := range fc.chunks {
+		chunks = append(chunks, chunk)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].Commit.ID != chunks[j].Commit.ID {
+			return chunks[i].Commit.ID < chunks[j].Commit.ID
+		}
+		if chunks[i].Object.Path != chunks[j].Object.Path {
+			return chunks[i].Object.Path < chunks[j].Object.Path
+		}
+		return chunks[i].Object.Offset < chunks[j].Object.Offset
+	})
+	return chunks
+}
+
+// propagateChildErrors() function marks the key of the parent tracker as
+// tracker.KeyCodeError if any of its children in the child tracker is in the
+// error state, e.g. a file for which any request failed. Returns the code of
+// the key after any update.
+func propagateChildErrors(parent tracker.Tracker, key string, children tracker.Tracker) int {
+	key_data, exists := parent.Get(key)
+	if !exists {
+		return tracker.KeyCodeInit
+	}
+	var failed int
+	for child_key := range key_data.Children {
+		if child_data, child_exists := children.Get(child_key); child_exists && child_data.Code == tracker.KeyCodeError {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return key_data.Code
+	}
+	code, _ := parent.Fail(key, fmt.Sprintf(
+		"%d of %d children of kind %s failed",
+		failed,
+		len(key_data.Children),
+		children.GetKind(),
+	))
+	return code
+}
+
+// retryChunk() method reads the text of the failed chunk from the blob of its
+// file in the repository, then creates and sends a new request for the text
+// after marking the file and commit of the chunk as pending.
+func (s *Scanner) retryChunk(chunk FailedChunk) error {
+	blob, err := s.repository.BlobObject(plumbing.NewHash(chunk.Object.ID))
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgRetryChunk, chunk.ID)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgRetryChunk, chunk.ID)
+	}
+	defer reader.Close()
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgRetryChunk, chunk.ID)
+	}
+	// offsets and lengths of chunks are counted in characters (code points)
+	chars := []rune(string(contents))
+	start, end := chunk.Object.Offset, chunk.Object.Offset+chunk.Object.Length
+	if start < 0 || chunk.Object.Length < 1 || end > len(chars) {
+		return errors.Wrapf(ErrRetryChunkOutOfRange, ErrMsgRetryChunk, chunk.ID)
+	}
+
+	request, err := rrr.NewRequest(rrr.NewRequestInput{
+		Column:   chunk.Object.Column,
+		CommitID: chunk.Commit.ID,
+		Length:   chunk.Object.Length,
+		Line:     chunk.Object.Line,
+		ObjectID: chunk.Object.ID,
+		Offset:   chunk.Object.Offset,
+		Path:     chunk.Object.Path,
+		RepoID:   s.ID,
+		Text:     string(chars[start:end]),
+	})
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgRetryChunk, chunk.ID)
+	}
+	request.Commit = chunk.Commit
+
+	// mark the file and commit as pending before sending the request, such
+	// that the response for the request can mark both as done
+	if _, err = s.TrackerFiles.Update(chunk.Object.ID, tracker.KeyCodePending, "", []string{request.ID}); err != nil {
+		return errors.Wrapf(err, ErrMsgScanTrackerUpdateFile, chunk.Object.ID)
+	}
+	if _, err = s.TrackerCommits.Update(chunk.Commit.ID, tracker.KeyCodePending, "", []string{chunk.Object.ID}); err != nil {
+		return errors.Wrapf(err, ErrMsgTrackerUpdateCommit, chunk.Commit.ID)
+	}
+	s.occurrences.Add(chunk.Object.ID, rrr.MetadataRequestResponseOccurrence{
+		Commit: chunk.Commit,
+		Path:   chunk.Object.Path,
+	})
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case s.chan_requests <- request:
+	}
+	return nil
+}
+
+// retryChunks() method sends a new request for each of the failed chunks of a
+// previous scan of the repository, in place of the scanRepository() method.
+// A chunk that cannot be retried, e.g. because the repository no longer
+// contains its file, is recorded as failed again instead of stopping the
+// retry of the other chunks.
+func (s *Scanner) retryChunks(
+	repo_url string,
+	repository *git.Repository,
+	chunks []FailedChunk,
+	errors_out chan<- error,
+	done chan struct{},
+) {
+	s.logger.Debug().Msgf("started retry of %d failed chunks of repository %s", len(chunks), repo_url)
+	defer s.logger.Debug().Msgf("finished retry of failed chunks of repository %s", repo_url)
+	defer close(done)
+
+	if repository == nil {
+		s.sendError(errors_out, ErrScannerRepositoryNil)
+		return
+	}
+
+	s.scan_mutex.Lock()
+	s.repository = repository
+	s.URL = repo_url
+	s.scan_mutex.Unlock()
+
+	for _, chunk := range chunks {
+		if err := s.retryChunk(chunk); err != nil {
+			s.logger.Warn().Err(err).Msgf("skipping retry of chunk of request %s", chunk.ID)
+			chunk.Error = rrr.ResponseError{Code: ErrorCodeRetryFailed, Message: err.Error()}
+			s.failed.Add(chunk)
+		}
+	}
+
+	// set the scan complete flag to true
+	s.is_scan_complete = true
+}
+
+// saveFailedChunks() method saves the failed chunks of the scan to the file of
+// failed chunks for the repository, or deletes the file if no chunks failed.
+func (s *Scanner) saveFailedChunks() error {
+	chunks := s.FailedChunks()
+	if len(chunks) == 0 {
+		return FailedChunksDelete(s.ctx, s.git_config.WorkDir, s.URL)
+	}
+	s.logger.Warn().Msgf("%d chunks failed in scan of repository %s", len(chunks), s.URL)
+	return FailedChunksSet(s.ctx, s.git_config.WorkDir, s.URL, chunks)
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestFailedChunks() unit test function tests that failed chunks are saved
+// to, read from, and deleted from the file of failed chunks for a repository.
+func TestFailedChunks(t *testing.T) {
+	t.Parallel()
+
+	work_dir := t.TempDir()
+
+	// no file of failed chunks exists before the first scan
+	chunks, err := FailedChunksGet(test_context, work_dir, test_repo_url)
+	assert.NoError(t, err)
+	assert.Empty(t, chunks)
+	assert.NoError(t, FailedChunksDelete(test_context, work_dir, test_repo_url))
+
+	_, err = FailedChunksGet(test_context, "", test_repo_url)
+	assert.ErrorIs(t, err, ErrFailedChunksPathLookupFailed)
+
+	failed := newFailedChunks()
+	for _, offset := range []int{20, 0} {
+		request := rrr.Request{
+			MetadataRequestResponse: rrr.MetadataRequestResponse{
+				ID:     "request-" + string(rune('a'+offset)),
+				Commit: rrr.MetadataRequestResponseCommit{ID: "commit-1"},
+				Object: rrr.MetadataRequestResponseObject{ID: "object-1", Length: 10, Offset: offset, Path: "a.md"},
+			},
+		}
+		response := rrr.NewErrorResponse(&request, "InvalidDocument", "test error")
+		failed.Add(NewFailedChunk(&response))
+	}
+	chunks = failed.List()
+	require.Len(t, chunks, 2)
+	assert.Equal(t, 0, chunks[0].Object.Offset)
+	assert.Equal(t, "InvalidDocument", chunks[0].Error.Code)
+
+	require.NoError(t, FailedChunksSet(test_context, work_dir, test_repo_url, chunks))
+	saved, err := FailedChunksGet(test_context, work_dir, test_repo_url)
+	assert.NoError(t, err)
+	assert.Equal(t, chunks, saved)
+
+	require.NoError(t, FailedChunksDelete(test_context, work_dir, test_repo_url))
+	saved, err = FailedChunksGet(test_context, work_dir, test_repo_url)
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+}
+
+// TestScanner_retryChunk() unit test function tests that the retryChunk()
+// method sends a new request for the text of a failed chunk, and that an
+// error response for the request propagates to the file and commit trackers.
+func TestScanner_retryChunk(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testModeRepository(t)
+	commit, err := repository.CommitObject(hashes[1])
+	require.NoError(t, err)
+	file, err := commit.File("b.md")
+	require.NoError(t, err)
+
+	s, s_err := NewScanner(
+		test_context,
+		test_valid_git_config_func(),
+		memory.NewMemoryResultRecordIO(test_context),
+	)
+	require.NoError(t, s_err)
+	s.ID = rrr.RepositoryID(test_repo_url)
+	s.repository = repository
+	s.chan_requests = make(chan rrr.Request, 1)
+
+	chunk := FailedChunk{
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			ID:     "failed-request",
+			Commit: rrr.NewMetadataCommit(commit),
+			Object: rrr.MetadataRequestResponseObject{
+				Column: 1,
+				ID:     file.Hash.String(),
+				Length: len("file"),
+				Line:   1,
+				Offset: len("second "),
+				Path:   file.Name,
+			},
+		},
+	}
+	require.NoError(t, s.retryChunk(chunk))
+	request := <-s.chan_requests
+	assert.Equal(t, "file", request.Text)
+	assert.Equal(t, "blackiq/learn", request.Repository.ID)
+	assert.Equal(t, commit.Hash.String(), request.Commit.ID)
+
+	file_data, exists := s.TrackerFiles.Get(file.Hash.String())
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodePending, file_data.Code)
+
+	// an error response for the retried chunk fails the file and commit
+	chan_errors_out := make(chan error, 10)
+	s.processResponse(rrr.NewErrorResponse(&request, "InvalidDocument", "test error"), chan_errors_out)
+	assert.Empty(t, chan_errors_out)
+	file_data, _ = s.TrackerFiles.Get(file.Hash.String())
+	assert.Equal(t, tracker.KeyCodeError, file_data.Code)
+	commit_data, _ := s.TrackerCommits.Get(commit.Hash.String())
+	assert.Equal(t, tracker.KeyCodeError, commit_data.Code)
+	if assert.Len(t, s.FailedChunks(), 1) {
+		assert.Equal(t, request.ID, s.FailedChunks()[0].ID)
+	}
+
+	// a chunk outside of the file contents cannot be retried
+	chunk.Object.Offset = 1000
+	assert.ErrorIs(t, s.retryChunk(chunk), ErrRetryChunkOutOfRange)
+}
@@ -0,0 +1,13 @@
+package head
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgBlame             = "failed to blame file %s"
+	ErrMsgClassifierCreate  = "failed to create head classifier for revision %s"
+	ErrMsgClassifierReadTip = "failed to read file %s at tip commit"
+)
+
+var (
+	ErrClassifierRepositoryNil = errors.New("head classifier requires a non-nil repository")
+)
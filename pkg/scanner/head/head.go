@@ -0,0 +1,240 @@
+package head
+
+import (
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// Classifier struct classifies result records by whether each result is
+// still present in the tree of the tip commit of a repository, and caches
+// the files and blame results of the tip commit across result records.
+type Classifier struct {
+	blames     map[string]*git.BlameResult
+	blob_paths map[string][]string
+	commits    map[plumbing.Hash]*object.Commit
+	contents   map[string]string
+	repository *git.Repository
+	tip        *object.Commit
+	tree       *object.Tree
+}
+
+// NewClassifier() function creates a new Classifier for the tip commit that
+// the revision (e.g. "HEAD", branch, or commit hash) resolves to.
+func NewClassifier(repository *git.Repository, revision string) (*Classifier, error) {
+	if repository == nil {
+		return nil, ErrClassifierRepositoryNil
+	}
+	hash, err := repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgClassifierCreate, revision)
+	}
+	tip, err := repository.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgClassifierCreate, revision)
+	}
+	tree, err := tip.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgClassifierCreate, revision)
+	}
+
+	// index the paths of each blob in the tree of the tip commit
+	blob_paths := make(map[string][]string)
+	err = tree.Files().ForEach(func(file *object.File) error {
+		blob_paths[file.Hash.String()] = append(blob_paths[file.Hash.String()], file.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgClassifierCreate, revision)
+	}
+
+	return &Classifier{
+		blames:     make(map[string]*git.BlameResult),
+		blob_paths: blob_paths,
+		commits:    make(map[plumbing.Hash]*object.Commit),
+		contents:   make(map[string]string),
+		repository: repository,
+		tip:        tip,
+		tree:       tree,
+	}, nil
+}
+
+// Classify() method returns the classification of the result record by
+// whether the result is still present in the tree of the tip commit, which
+// includes the blame information of the line of any present result.
+func (c *Classifier) Classify(record rrr.ResultRecord) (*rrr.ResultRecordHead, error) {
+	head := &rrr.ResultRecordHead{
+		CommitID: c.tip.Hash.String(),
+		Status:   rrr.HeadStatusOnlyInHistory,
+	}
+	paths := recordPaths(record)
+
+	// the result is present if the same blob still exists at the tip, where
+	// the path of the result is preferred over any other path of the blob
+	if blob_paths, exists := c.blob_paths[record.Object.ID]; exists {
+		path := blob_paths[0]
+		for _, p := range blob_paths {
+			if len(paths) > 0 && p == paths[0] {
+				path = p
+				break
+			}
+		}
+		head.Path = path
+		head.Status = rrr.HeadStatusPresentAtHead
+		head.Line = record.Location.StartLine
+		if head.Line == 0 {
+			content, err := c.content(path)
+			if err != nil {
+				return nil, err
+			}
+			head.Line = textLine(content, record.Text, record.Location.Offset)
+		}
+		return head, c.blame(head)
+	}
+
+	// otherwise the result is present if a file at one of the paths of the
+	// result still contains the text of the result, where the occurrence of
+	// the text closest to the offset of the result is taken as the result
+	for _, path := range paths {
+		if _, err := c.tree.File(path); err != nil {
+			continue
+		}
+		content, err := c.content(path)
+		if err != nil {
+			return nil, err
+		}
+		if record.Text != "" && strings.Contains(content, record.Text) {
+			head.Line = textLine(content, record.Text, record.Location.Offset)
+			head.Path = path
+			head.Status = rrr.HeadStatusPresentAtHead
+			return head, c.blame(head)
+		}
+		if head.Status != rrr.HeadStatusRemoved {
+			head.Path = path
+			head.Status = rrr.HeadStatusRemoved
+		}
+	}
+	return head, nil
+}
+
+// blame() method sets the blame information of the line of the result at
+// the tip, if the line is known.
+func (c *Classifier) blame(head *rrr.ResultRecordHead) error {
+	if head.Line == 0 {
+		return nil
+	}
+	result, exists := c.blames[head.Path]
+	if !exists {
+		var err error
+		result, err = git.Blame(c.tip, head.Path)
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgBlame, head.Path)
+		}
+		c.blames[head.Path] = result
+	}
+	if head.Line > len(result.Lines) {
+		return nil
+	}
+	line := result.Lines[head.Line-1]
+	head.Blame = &rrr.ResultRecordBlame{
+		AuthorEmail: line.Author,
+		CommitID:    line.Hash.String(),
+		Time:        line.Date.Unix(),
+	}
+	// the blame line only contains the email of the author
+	commit, exists := c.commits[line.Hash]
+	if !exists {
+		commit, _ = c.repository.CommitObject(line.Hash)
+		c.commits[line.Hash] = commit
+	}
+	if commit != nil {
+		head.Blame.AuthorName = commit.Author.Name
+	}
+	return nil
+}
+
+// content() method returns the content of the file at the path in the tree
+// of the tip commit.
+func (c *Classifier) content(path string) (string, error) {
+	if content, exists := c.contents[path]; exists {
+		return content, nil
+	}
+	file, err := c.tree.File(path)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrMsgClassifierReadTip, path)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return "", errors.Wrapf(err, ErrMsgClassifierReadTip, path)
+	}
+	c.contents[path] = content
+	return content, nil
+}
+
+// recordPaths() function returns the unique paths of the result record,
+// starting with the path of the location of the result.
+func recordPaths(record rrr.ResultRecord) []string {
+	paths := make([]string, 0, len(record.Object.Paths)+1)
+	seen := make(map[string]bool)
+	for _, path := range append([]string{record.Location.Path, record.Object.Path}, record.Object.Paths...) {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// textLine() function returns the (1-based) line of the occurrence of the
+// text within the content that is closest to the offset, in code points, of
+// the result, or 0 if the text is empty or not found. When the content is the
+// content that was scanned, the occurrence at the offset is the result itself.
+func textLine(content, text string, offset int) int {
+	if text == "" {
+		return 0
+	}
+	// convert the code point offset into a byte offset within the content
+	target := len(content)
+	n := 0
+	for i := range content {
+		if n == offset {
+			target = i
+			break
+		}
+		n++
+	}
+
+	closest := -1
+	for start := 0; start <= len(content); {
+		i := strings.Index(content[start:], text)
+		if i < 0 {
+			break
+		}
+		i += start
+		if closest < 0 || absInt(i-target) < absInt(closest-target) {
+			closest = i
+		}
+		if i >= target {
+			break
+		}
+		start = i + 1
+	}
+	if closest < 0 {
+		return 0
+	}
+	return strings.Count(content[:closest], "\n") + 1
+}
+
+// absInt() function returns the absolute value of the integer.
+func absInt(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
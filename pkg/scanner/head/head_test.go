@@ -0,0 +1,202 @@
+package head
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gitmemory "github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testRepository() helper function creates an in-memory repository with two
+// commits, where the second commit removes PHI from one file, deletes
+// another file, and adds a line to two other files, one of which repeats the
+// same text. Returns the repository, the commit hashes, and the blob hashes of
+// the files in the first commit.
+func testRepository(t *testing.T) (*git.Repository, []plumbing.Hash, map[string]string) {
+	repository, err := git.Init(gitmemory.NewStorage(), memfs.New())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	worktree, err := repository.Worktree()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	hashes := make([]plumbing.Hash, 0)
+	commit := func(files map[string]string, removed ...string) {
+		for name, content := range files {
+			file, err := worktree.Filesystem.Create(name)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			file.Write([]byte(content))
+			file.Close()
+			_, err = worktree.Add(name)
+			assert.NoError(t, err)
+		}
+		for _, name := range removed {
+			_, err := worktree.Remove(name)
+			assert.NoError(t, err)
+		}
+		hash, err := worktree.Commit("commit", &git.CommitOptions{
+			Author: &object.Signature{
+				Email: "dev@example.com",
+				Name:  "Dev",
+				When:  time.Unix(int64(1700000000+len(hashes)), 0),
+			},
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		hashes = append(hashes, hash)
+	}
+	commit(map[string]string{
+		"a.md": "name: Jane Doe\n",
+		"b.md": "email: jane@example.com\n",
+		"c.md": "phone: 555-0100\n",
+		"e.md": "a\nJohn Doe\n",
+		"f.md": "Jane\nx\nJane\n",
+		"g.md": "Jane\nJane\n",
+	})
+
+	blobs := make(map[string]string)
+	first, err := repository.CommitObject(hashes[0])
+	assert.NoError(t, err)
+	first_tree, err := first.Tree()
+	assert.NoError(t, err)
+	assert.NoError(t, first_tree.Files().ForEach(func(file *object.File) error {
+		blobs[file.Name] = file.Hash.String()
+		return nil
+	}))
+
+	commit(map[string]string{
+		"b.md": "email: removed\n",
+		"e.md": "new line\na\nJohn Doe\n",
+		"f.md": "y\nJane\nx\nJane\n",
+	}, "c.md")
+	return repository, hashes, blobs
+}
+
+// TestClassifier_Classify() unit test function tests the classification of
+// result records by the Classify() method.
+func TestClassifier_Classify(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes, blobs := testRepository(t)
+	classifier, err := NewClassifier(repository, "HEAD")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	record := func(path, text string, line, offset int) rrr.ResultRecord {
+		return rrr.ResultRecord{
+			Location: rrr.Location{Offset: offset, Path: path, StartLine: line},
+			MetadataRequestResponse: rrr.MetadataRequestResponse{
+				Commit: rrr.MetadataRequestResponseCommit{ID: hashes[0].String()},
+				Object: rrr.MetadataRequestResponseObject{ID: blobs[path], Path: path},
+			},
+			Result: rrr.Result{Text: text},
+		}
+	}
+	blame := &rrr.ResultRecordBlame{
+		AuthorEmail: "dev@example.com",
+		AuthorName:  "Dev",
+		CommitID:    hashes[0].String(),
+		Time:        1700000000,
+	}
+
+	tests := []struct {
+		expected *rrr.ResultRecordHead
+		name     string
+		record   rrr.ResultRecord
+	}{
+		{
+			expected: &rrr.ResultRecordHead{
+				Blame:    blame,
+				CommitID: hashes[1].String(),
+				Line:     1,
+				Path:     "a.md",
+				Status:   rrr.HeadStatusPresentAtHead,
+			},
+			name:   "same_blob",
+			record: record("a.md", "Jane Doe", 1, 6),
+		},
+		{
+			expected: &rrr.ResultRecordHead{
+				Blame:    blame,
+				CommitID: hashes[1].String(),
+				Line:     3,
+				Path:     "e.md",
+				Status:   rrr.HeadStatusPresentAtHead,
+			},
+			name:   "changed_blob",
+			record: record("e.md", "John Doe", 2, 2),
+		},
+		{
+			expected: &rrr.ResultRecordHead{
+				Blame:    blame,
+				CommitID: hashes[1].String(),
+				Line:     4,
+				Path:     "f.md",
+				Status:   rrr.HeadStatusPresentAtHead,
+			},
+			name:   "changed_blob_offset",
+			record: record("f.md", "Jane", 3, 7),
+		},
+		{
+			expected: &rrr.ResultRecordHead{
+				Blame:    blame,
+				CommitID: hashes[1].String(),
+				Line:     2,
+				Path:     "g.md",
+				Status:   rrr.HeadStatusPresentAtHead,
+			},
+			name:   "same_blob_offset",
+			record: record("g.md", "Jane", 0, 5),
+		},
+		{
+			expected: &rrr.ResultRecordHead{
+				CommitID: hashes[1].String(),
+				Path:     "b.md",
+				Status:   rrr.HeadStatusRemoved,
+			},
+			name:   "removed",
+			record: record("b.md", "jane@example.com", 1, 7),
+		},
+		{
+			expected: &rrr.ResultRecordHead{
+				CommitID: hashes[1].String(),
+				Status:   rrr.HeadStatusOnlyInHistory,
+			},
+			name:   "only_in_history",
+			record: record("c.md", "555-0100", 1, 7),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			head, err := classifier.Classify(test.record)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, head)
+		})
+	}
+}
+
+// TestNewClassifier() unit test function tests the errors returned by the
+// NewClassifier() function.
+func TestNewClassifier(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClassifier(nil, "HEAD")
+	assert.ErrorIs(t, err, ErrClassifierRepositoryNil)
+
+	repository, _, _ := testRepository(t)
+	_, err = NewClassifier(repository, "missing")
+	assert.Error(t, err)
+}
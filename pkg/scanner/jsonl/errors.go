@@ -0,0 +1,16 @@
+package jsonl
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgJSONLResultRecordIOCreate = "failed to create jsonl store"
+	ErrMsgJSONLResultRecordIOLoad   = "failed to load jsonl store"
+	ErrMsgJSONLResultRecordIOWrite  = "failed to write to jsonl store"
+)
+
+var (
+	ErrJSONLResultRecordIOClosed        = errors.New("jsonl store is closed")
+	ErrJSONLResultRecordIODeleteEmptyID = errors.New("jsonl store failed to delete result record : empty ID")
+	ErrJSONLResultRecordIOReadEmptyID   = errors.New("jsonl store failed to read result record : empty ID")
+	ErrJSONLResultRecordIOReadFailed    = errors.New("jsonl store failed to read result record")
+)
@@ -0,0 +1,343 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+const FileExtension string = ".jsonl"
+
+// JSONLResultRecordIO struct provides an implementation of the ResultRecordIO
+// interface that appends each result record as a single line of JSON to a
+// file, such that results are persisted across runs of the app. All records
+// are also indexed in memory to serve reads.
+type JSONLResultRecordIO struct {
+	rrr.ResultRecordIO
+
+	file           *os.File
+	hashes         []string
+	logger         *zerolog.Logger
+	mutex          *sync.RWMutex
+	path           string
+	result_records map[string]rrr.ResultRecord
+}
+
+// NewJSONLResultRecordIO() function initializes a new JSONLResultRecordIO
+// object backed by the file at the given path, loading any result records
+// that already exist in the file.
+func NewJSONLResultRecordIO(ctx context.Context, path string) (*JSONLResultRecordIO, error) {
+	io := &JSONLResultRecordIO{
+		logger:         zerolog.Ctx(ctx),
+		mutex:          &sync.RWMutex{},
+		path:           path,
+		result_records: make(map[string]rrr.ResultRecord),
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, ErrMsgJSONLResultRecordIOCreate)
+	}
+	if err := io.repair(); err != nil {
+		return nil, err
+	}
+	if err := io.load(); err != nil {
+		return nil, err
+	}
+	if err := io.open(); err != nil {
+		return nil, err
+	}
+	io.logger.Debug().Msgf("loaded %d result(s) from jsonl store %s", len(io.hashes), path)
+	return io, nil
+}
+
+// NewJSONLResultRecordIOForRepo() function initializes a new JSONLResultRecordIO
+// object backed by the file "<work_dir>/results/<org>_<repo>.jsonl".
+func NewJSONLResultRecordIOForRepo(ctx context.Context, work_dir, repo_url string) (*JSONLResultRecordIO, error) {
+	name, err := nogit.ParseOrgRepoNameFromURL(repo_url)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgJSONLResultRecordIOCreate)
+	}
+	return NewJSONLResultRecordIO(ctx, filepath.Join(work_dir, cfg.WorkDirResults, name+FileExtension))
+}
+
+// Close() method closes the underlying file of the jsonl store.
+func (io *JSONLResultRecordIO) Close() error {
+	io.mutex.Lock()
+	defer io.mutex.Unlock()
+
+	if io.file == nil {
+		return nil
+	}
+	err := io.file.Close()
+	io.file = nil
+	return err
+}
+
+// Delete() method deletes the result with matching id from the jsonl store,
+// which requires rewriting the file without the deleted result.
+func (io *JSONLResultRecordIO) Delete(id string) error {
+	if id == "" {
+		return ErrJSONLResultRecordIODeleteEmptyID
+	}
+	io.logger.Debug().Msgf("deleting result id=%s from jsonl store", id)
+	io.mutex.Lock()
+	defer io.mutex.Unlock()
+
+	if _, exists := io.result_records[id]; !exists {
+		return nil
+	}
+	delete(io.result_records, id)
+	for i, hash := range io.hashes {
+		if hash == id {
+			io.hashes = append(io.hashes[:i], io.hashes[i+1:]...)
+			break
+		}
+	}
+	return io.rewrite()
+}
+
+// GetPath() method returns the path of the file that backs the jsonl store.
+func (io *JSONLResultRecordIO) GetPath() string {
+	return io.path
+}
+
+// List() method returns a list of all results in the jsonl store, in the
+// order in which they were first written.
+func (io *JSONLResultRecordIO) List() ([]rrr.ResultRecord, error) {
+	io.logger.Debug().Msg("listing results from jsonl store")
+	io.mutex.RLock()
+	defer io.mutex.RUnlock()
+
+	out := make([]rrr.ResultRecord, 0, len(io.hashes))
+	for _, hash := range io.hashes {
+		out = append(out, io.result_records[hash])
+	}
+	return out, nil
+}
+
+// Query() method returns the page of results in the jsonl store that match
+// the filter, in the order in which they were first written.
+func (io *JSONLResultRecordIO) Query(filter rrr.ResultRecordFilter) (rrr.ResultRecordPage, error) {
+	io.logger.Debug().Msgf("querying results from jsonl store : %+v", filter)
+	records, err := io.List()
+	if err != nil {
+		return rrr.ResultRecordPage{}, err
+	}
+	return rrr.QueryResultRecords(records, filter)
+}
+
+// Read() method returns the result with matching id from the jsonl store.
+// Returns a non-nil error if unable to find a result with matching id.
+func (io *JSONLResultRecordIO) Read(id string) (rrr.ResultRecord, error) {
+	if id == "" {
+		return rrr.ResultRecord{}, ErrJSONLResultRecordIOReadEmptyID
+	}
+	io.logger.Debug().Msgf("reading result id=%s from jsonl store", id)
+	io.mutex.RLock()
+	defer io.mutex.RUnlock()
+
+	r, ok := io.result_records[id]
+	if !ok {
+		return rrr.ResultRecord{}, ErrJSONLResultRecordIOReadFailed
+	}
+	return r, nil
+}
+
+// Write() method appends the slice of results to the jsonl store, skipping
+// any results that already exist unchanged in the store, and syncs the file
+// to disk. A changed result is appended again and replaces the existing
+// result when the store is loaded. Returns a non-nil error if unable to write
+// any result to the store, in which case any partly written line is removed.
+func (io *JSONLResultRecordIO) Write(result_records []rrr.ResultRecord) error {
+	io.logger.Debug().Msgf("writing %d result(s) to jsonl store", len(result_records))
+	io.mutex.Lock()
+	defer io.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	new_records := make([]rrr.ResultRecord, 0, len(result_records))
+	for _, r := range result_records {
+		if existing, exists := io.result_records[r.Hash]; exists && reflect.DeepEqual(existing, r) {
+			continue
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+		new_records = append(new_records, r)
+	}
+	if buffer.Len() == 0 {
+		return nil
+	}
+	if io.file == nil {
+		return ErrJSONLResultRecordIOClosed
+	}
+	info, err := io.file.Stat()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	if _, err := io.file.Write(buffer.Bytes()); err != nil {
+		io.file.Truncate(info.Size())
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	if err := io.file.Sync(); err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	// only index the new records once they have been written to the file
+	for _, r := range new_records {
+		if _, exists := io.result_records[r.Hash]; !exists {
+			io.hashes = append(io.hashes, r.Hash)
+		}
+		io.result_records[r.Hash] = r
+	}
+	return nil
+}
+
+// load() method reads all result records from the file, if it exists. A line
+// that cannot be parsed is skipped with a warning.
+func (io *JSONLResultRecordIO) load() error {
+	file, err := os.Open(io.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line_number := 0
+	for scanner.Scan() {
+		line_number++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r rrr.ResultRecord
+		if err := json.Unmarshal(line, &r); err != nil || r.Hash == "" {
+			io.logger.Warn().Msgf("skipping invalid line %d of jsonl store %s", line_number, io.path)
+			continue
+		}
+		if _, exists := io.result_records[r.Hash]; !exists {
+			io.hashes = append(io.hashes, r.Hash)
+		}
+		io.result_records[r.Hash] = r
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+	}
+	return nil
+}
+
+// repair() method removes the trailing line of the file, if it exists, when
+// the line does not end with a newline, i.e. a partial line written before a
+// crash, such that the next result record is not appended to the line.
+func (io *JSONLResultRecordIO) repair() error {
+	file, err := os.OpenFile(io.path, os.O_RDWR, 0o644)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+	}
+	// read the file backwards until the last newline is found
+	end := info.Size()
+	buffer := make([]byte, 4096)
+	for end > 0 {
+		start := end - int64(len(buffer))
+		if start < 0 {
+			start = 0
+		}
+		n, err := file.ReadAt(buffer[:end-start], start)
+		if err != nil {
+			return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+		}
+		if i := bytes.LastIndexByte(buffer[:n], '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == info.Size() {
+		return nil
+	}
+	io.logger.Warn().Msgf("removing partial line of %d byte(s) from jsonl store %s", info.Size()-end, io.path)
+	if err := file.Truncate(end); err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+	}
+	if err := file.Sync(); err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOLoad)
+	}
+	return nil
+}
+
+// open() method opens the file for appending new result records.
+func (io *JSONLResultRecordIO) open() error {
+	file, err := os.OpenFile(io.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOCreate)
+	}
+	io.file = file
+	return nil
+}
+
+// rewrite() method replaces the file with the current set of result records,
+// by writing to a temporary file that is renamed over the original file. The
+// caller must hold the write lock.
+func (io *JSONLResultRecordIO) rewrite() error {
+	temp, err := os.CreateTemp(filepath.Dir(io.path), filepath.Base(io.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+	for _, hash := range io.hashes {
+		line, err := json.Marshal(io.result_records[hash])
+		if err != nil {
+			temp.Close()
+			return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+		}
+		writer.Write(line)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	if err := temp.Close(); err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+
+	if io.file != nil {
+		io.file.Close()
+		io.file = nil
+	}
+	if err := os.Rename(temp.Name(), io.path); err != nil {
+		return errors.Wrap(err, ErrMsgJSONLResultRecordIOWrite)
+	}
+	return io.open()
+}
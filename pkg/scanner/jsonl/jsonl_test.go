@@ -0,0 +1,265 @@
+package jsonl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testResultRecord() helper function returns a result record with the
+// given hash for use in tests.
+func testResultRecord(hash string) rrr.ResultRecord {
+	return rrr.ResultRecord{
+		Hash: hash,
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			ID:         "request-" + hash,
+			Repository: rrr.MetadataRequestResponseRepository{ID: "https://github.com/org/repo.git"},
+		},
+		Result: rrr.Result{Category: "Email", ConfidenceScore: 0.9, Length: 5, Offset: 1},
+	}
+}
+
+// TestJSONLResultRecordIO() unit test function tests writing, reading,
+// listing, and deleting result records, and that the result records are
+// loaded again when the store is reopened.
+func TestJSONLResultRecordIO(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "results", "org_repo.jsonl")
+
+	io, err := NewJSONLResultRecordIO(ctx, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, path, io.GetPath())
+	assert.Implements(t, (*rrr.PersistentResultRecordIO)(nil), io)
+
+	assert.NoError(t, io.Write([]rrr.ResultRecord{
+		testResultRecord("hash-1"),
+		testResultRecord("hash-2"),
+	}))
+	// writing an existing result record is a no-op
+	assert.NoError(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-1")}))
+
+	r, err := io.Read("hash-2")
+	assert.NoError(t, err)
+	assert.Equal(t, testResultRecord("hash-2"), r)
+
+	_, err = io.Read("")
+	assert.ErrorIs(t, err, ErrJSONLResultRecordIOReadEmptyID)
+	_, err = io.Read("missing")
+	assert.ErrorIs(t, err, ErrJSONLResultRecordIOReadFailed)
+
+	assert.ErrorIs(t, io.Delete(""), ErrJSONLResultRecordIODeleteEmptyID)
+	assert.NoError(t, io.Delete("hash-1"))
+	assert.NoError(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-3")}))
+	assert.NoError(t, io.Close())
+	assert.ErrorIs(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-4")}), ErrJSONLResultRecordIOClosed)
+
+	// reopen the store and check that the result records were persisted
+	reopened, err := NewJSONLResultRecordIO(ctx, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer reopened.Close()
+
+	records, err := reopened.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []rrr.ResultRecord{
+		testResultRecord("hash-2"),
+		testResultRecord("hash-3"),
+	}, records)
+}
+
+// TestJSONLResultRecordIO_WriteChanged() unit test function tests that a
+// changed result record replaces the existing result record, including when
+// the store is reopened.
+func TestJSONLResultRecordIO_WriteChanged(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "org_repo.jsonl")
+
+	io, err := NewJSONLResultRecordIO(ctx, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-1"), testResultRecord("hash-2")}))
+
+	changed := testResultRecord("hash-1")
+	changed.SetOccurrences([]rrr.MetadataRequestResponseOccurrence{
+		{Commit: rrr.MetadataRequestResponseCommit{ID: "commit-1"}, Path: "a.txt"},
+		{Commit: rrr.MetadataRequestResponseCommit{ID: "commit-2"}, Path: "b.txt"},
+	})
+	assert.NoError(t, io.Write([]rrr.ResultRecord{changed}))
+	r, err := io.Read("hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, changed, r)
+	assert.NoError(t, io.Close())
+
+	reopened, err := NewJSONLResultRecordIO(ctx, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer reopened.Close()
+	records, err := reopened.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []rrr.ResultRecord{changed, testResultRecord("hash-2")}, records)
+}
+
+// TestNewJSONLResultRecordIO_InvalidLines() unit test function tests that
+// invalid lines in the file are skipped when loading the store.
+func TestNewJSONLResultRecordIO_InvalidLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "org_repo.jsonl")
+	content := `{"hash":"hash-1"}` + "\n\n" + `{"hash":""}` + "\n" + `{"hash":"hash-2"` + "\n"
+	if !assert.NoError(t, os.WriteFile(path, []byte(content), 0o644)) {
+		t.FailNow()
+	}
+
+	io, err := NewJSONLResultRecordIO(context.TODO(), path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer io.Close()
+
+	records, err := io.List()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "hash-1", records[0].Hash)
+	}
+}
+
+// TestNewJSONLResultRecordIO_PartialLine() unit test function tests that a
+// partial trailing line of the file is removed when opening the store, such
+// that the next result record written to the store is loaded again.
+func TestNewJSONLResultRecordIO_PartialLine(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "org_repo.jsonl")
+	content := `{"hash":"hash-1"}` + "\n" + strings.Repeat(" ", 5000) + `{"hash":"hash-`
+	if !assert.NoError(t, os.WriteFile(path, []byte(content), 0o644)) {
+		t.FailNow()
+	}
+
+	io, err := NewJSONLResultRecordIO(context.TODO(), path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-2")}))
+	assert.NoError(t, io.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `{"hash":"hash-{`)
+
+	io, err = NewJSONLResultRecordIO(context.TODO(), path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer io.Close()
+	records, err := io.List()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "hash-1", records[0].Hash)
+		assert.Equal(t, "hash-2", records[1].Hash)
+	}
+
+	// a file without any newline is truncated to an empty file
+	path = filepath.Join(t.TempDir(), "org_repo.jsonl")
+	if !assert.NoError(t, os.WriteFile(path, []byte(`{"hash"`), 0o644)) {
+		t.FailNow()
+	}
+	io_partial, err := NewJSONLResultRecordIO(context.TODO(), path)
+	if assert.NoError(t, err) {
+		io_partial.Close()
+	}
+	info, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(0), info.Size())
+	}
+}
+
+// TestNewJSONLResultRecordIOForRepo() unit test function tests that the path
+// of the store is derived from the work dir and repository URL.
+func TestNewJSONLResultRecordIOForRepo(t *testing.T) {
+	t.Parallel()
+
+	work_dir := t.TempDir()
+	io, err := NewJSONLResultRecordIOForRepo(context.TODO(), work_dir, "https://github.com/org/repo.git")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer io.Close()
+	assert.Equal(t, filepath.Join(work_dir, "results", "org_repo.jsonl"), io.GetPath())
+
+	_, err = NewJSONLResultRecordIOForRepo(context.TODO(), work_dir, "")
+	assert.Error(t, err)
+}
+
+// TestJSONLResultRecordIO_Query() unit test function tests the Query() method of the
+// JSONLResultRecordIO struct.
+func TestJSONLResultRecordIO_Query(t *testing.T) {
+	t.Parallel()
+
+	io, err := NewJSONLResultRecordIO(context.TODO(), filepath.Join(t.TempDir(), "org_repo.jsonl"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer io.Close()
+
+	records := make([]rrr.ResultRecord, 0)
+	for i, category := range []string{"Email", "Person", "Email", "Email"} {
+		r := testResultRecord(fmt.Sprintf("hash-%d", i))
+		r.Category = category
+		r.Commit.ID = fmt.Sprintf("commit-%d", i%2)
+		r.ConfidenceScore = 0.5 + float64(i)/10
+		r.Time = rrr.MetadataRequestResponseTime{Start: int64(i * 100), Stop: int64(i*100 + 10)}
+		records = append(records, r)
+	}
+	if !assert.NoError(t, io.Write(records)) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		expected_hashes []string
+		expected_total  int
+		filter          rrr.ResultRecordFilter
+		name            string
+	}{
+		{[]string{"hash-0", "hash-1", "hash-2", "hash-3"}, 4, rrr.ResultRecordFilter{}, "empty filter"},
+		{[]string{"hash-0", "hash-2", "hash-3"}, 3, rrr.ResultRecordFilter{Category: "Email"}, "category"},
+		{[]string{"hash-1", "hash-3"}, 2, rrr.ResultRecordFilter{CommitID: "commit-1"}, "commit"},
+		{[]string{"hash-2", "hash-3"}, 2, rrr.ResultRecordFilter{MinConfidenceScore: 0.7}, "min confidence"},
+		{[]string{}, 0, rrr.ResultRecordFilter{RepositoryID: "other"}, "repository"},
+		{[]string{"hash-1", "hash-2"}, 2, rrr.ResultRecordFilter{TimeStart: 100, TimeStop: 210}, "time range"},
+		{[]string{"hash-2"}, 3, rrr.ResultRecordFilter{Category: "Email", Limit: 1, Offset: 1}, "page"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := io.Query(test.filter)
+			if !assert.NoError(t, err) {
+				return
+			}
+			hashes := make([]string, 0)
+			for _, r := range page.Records {
+				hashes = append(hashes, r.Hash)
+			}
+			assert.Equal(t, test.expected_hashes, hashes)
+			assert.Equal(t, test.expected_total, page.Total)
+		})
+	}
+
+	_, err = io.Query(rrr.ResultRecordFilter{Offset: -1})
+	assert.ErrorIs(t, err, rrr.ErrResultRecordFilterInvalid)
+}
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/rs/zerolog"
@@ -45,17 +46,35 @@ func (io MemoryResultRecordIO) Delete(id string) error {
 // List() method returns a list of all results in the memory store.
 func (io MemoryResultRecordIO) List() ([]rrr.ResultRecord, error) {
 	io.logger.Debug().Msg("listing results from memory store")
+	// copy the results while holding the lock, because the map may be
+	// written concurrently, e.g. while the scan is checkpointed
 	io.mutex.RLock()
-	current_results := io.result_records
-	io.mutex.RUnlock()
+	defer io.mutex.RUnlock()
 
 	var out []rrr.ResultRecord
-	for _, result := range current_results {
+	for _, result := range io.result_records {
 		out = append(out, result)
 	}
 	return out, nil
 }
 
+// Query() method returns the page of results in the memory store that match
+// the filter, where results are ordered by hash so that pagination is stable.
+func (io MemoryResultRecordIO) Query(filter rrr.ResultRecordFilter) (rrr.ResultRecordPage, error) {
+	io.logger.Debug().Msgf("querying results from memory store : %+v", filter)
+	io.mutex.RLock()
+	records := make([]rrr.ResultRecord, 0, len(io.result_records))
+	for _, r := range io.result_records {
+		records = append(records, r)
+	}
+	io.mutex.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Hash < records[j].Hash
+	})
+	return rrr.QueryResultRecords(records, filter)
+}
+
 // Read() method returns the result with matching id from the memory store.
 // Returns a non-nil error if unable to find a result with matching id.
 func (io MemoryResultRecordIO) Read(id string) (rrr.ResultRecord, error) {
@@ -205,3 +205,40 @@ func TestMemoryResultRecordIO_Write(t *testing.T) {
 	assert.Equal(t, result2, resultIO.result_records["hash-2"])
 	assert.Equal(t, result3, resultIO.result_records["hash-3"])
 }
+
+// TestMemoryResultRecordIO_Query() unit test function tests the
+// Query() method of MemoryResultRecordIO struct.
+func TestMemoryResultRecordIO_Query(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	// create a new MemoryResultRecordIO instance
+	resultIO := NewMemoryResultRecordIO(ctx)
+
+	// write result records in reverse order of their hashes
+	for _, hash := range []string{"hash-3", "hash-2", "hash-1"} {
+		assert.NoError(t, resultIO.Write([]rrr.ResultRecord{{
+			Hash:   hash,
+			Result: rrr.Result{Category: "Email", ConfidenceScore: 0.9},
+		}}))
+	}
+	assert.NoError(t, resultIO.Write([]rrr.ResultRecord{{
+		Hash:   "hash-0",
+		Result: rrr.Result{Category: "Person", ConfidenceScore: 0.9},
+	}}))
+
+	// call the Query method
+	page, err := resultIO.Query(rrr.ResultRecordFilter{Category: "Email", Limit: 2, Offset: 1})
+
+	// assert that the page is ordered by hash
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	if assert.Len(t, page.Records, 2) {
+		assert.Equal(t, "hash-2", page.Records[0].Hash)
+		assert.Equal(t, "hash-3", page.Records[1].Hash)
+	}
+
+	_, err = resultIO.Query(rrr.ResultRecordFilter{Limit: -1})
+	assert.ErrorIs(t, err, rrr.ErrResultRecordFilterInvalid)
+}
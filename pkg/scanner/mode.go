@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// commitIterator() method returns an iterator for the commits of the
+// repository that are scanned in the scan mode defined by the git config.
+// Each commit is still deduplicated by the TrackerCommits when scanned.
+func (s *Scanner) commitIterator() (object.CommitIter, error) {
+	scan_config := s.git_config.Scan
+	switch scan_config.Mode {
+	case "", cfg.ScanModeAll:
+		return s.repository.CommitObjects()
+	case cfg.ScanModeBranch:
+		tip, err := s.resolveCommit(scan_config.Branch)
+		if err != nil {
+			return nil, err
+		}
+		return object.NewFilterCommitIter(tip, nil, nil), nil
+	case cfg.ScanModeHead:
+		head, err := s.resolveCommit(string(plumbing.HEAD))
+		if err != nil {
+			return nil, err
+		}
+		// do not walk beyond the HEAD commit
+		is_limit := object.CommitFilter(func(*object.Commit) bool { return true })
+		return object.NewFilterCommitIter(head, nil, &is_limit), nil
+	case cfg.ScanModeRange:
+		from, to, err := cfg.ParseScanRange(scan_config.Range)
+		if err != nil {
+			return nil, err
+		}
+		return s.commitRangeIterator(from, to)
+	case cfg.ScanModeSince:
+		to := scan_config.Branch
+		if to == "" {
+			to = string(plumbing.HEAD)
+		}
+		return s.commitRangeIterator(scan_config.Since, to)
+	default:
+		return nil, errors.Wrapf(ErrScanModeInvalid, "mode = %s", scan_config.Mode)
+	}
+}
+
+// commitRangeIterator() method returns an iterator for the commits that are
+// reachable from the `to` revision, but not from the `from` revision. Files
+// in the tree of the `from` commit are marked as ignored in the TrackerFiles,
+// such that only files added or changed within the range are scanned.
+func (s *Scanner) commitRangeIterator(from, to string) (object.CommitIter, error) {
+	base, err := s.resolveCommit(from)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := s.resolveCommit(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect the commits reachable from the base commit
+	excluded := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(base, nil, nil).ForEach(func(c *object.Commit) error {
+		excluded[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgScanCommitRange, from, to)
+	}
+
+	// ignore files that already exist in the tree of the base commit
+	tree, err := base.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgScanCommitRange, from, to)
+	}
+	err = tree.Files().ForEach(func(file *object.File) error {
+		_, update_err := s.TrackerFiles.Update(
+			file.Hash.String(),
+			tracker.KeyCodeIgnore,
+			"file exists in base commit "+base.Hash.String(),
+			[]string{},
+		)
+		return update_err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgScanCommitRange, from, to)
+	}
+
+	is_valid := object.CommitFilter(func(c *object.Commit) bool { return !excluded[c.Hash] })
+	is_limit := object.CommitFilter(func(c *object.Commit) bool { return excluded[c.Hash] })
+	return object.NewFilterCommitIter(tip, &is_valid, &is_limit), nil
+}
+
+// resolveCommit() method returns the commit of the repository that the
+// revision (e.g. commit hash, branch, or tag) resolves to.
+func (s *Scanner) resolveCommit(revision string) (*object.Commit, error) {
+	hash, err := s.repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgScanRevisionResolve, revision)
+	}
+	commit, err := s.repository.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrMsgScanRevisionResolve, revision)
+	}
+	return commit, nil
+}
+
+// resolveHead() method returns the hash of the tip commit of the history that
+// is scanned in the repository, or an empty string if the tip cannot be
+// resolved, e.g. in an empty repository.
+func (s *Scanner) resolveHead(repository *git.Repository) string {
+	if repository == nil {
+		return ""
+	}
+	revision := s.tipRevision()
+	hash, err := repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		s.logger.Debug().Err(err).Msgf(ErrMsgScanRevisionResolve, revision)
+		return ""
+	}
+	return hash.String()
+}
+
+// tipRevision() method returns the revision of the tip of the history that
+// is scanned in the scan mode defined by the git config.
+func (s *Scanner) tipRevision() string {
+	scan_config := s.git_config.Scan
+	switch scan_config.Mode {
+	case cfg.ScanModeBranch, cfg.ScanModeSince:
+		if scan_config.Branch != "" {
+			return scan_config.Branch
+		}
+	case cfg.ScanModeRange:
+		if _, to, err := cfg.ParseScanRange(scan_config.Range); err == nil {
+			return to
+		}
+	}
+	return string(plumbing.HEAD)
+}
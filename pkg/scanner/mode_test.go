@@ -0,0 +1,238 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gitmemory "github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// testModeRepository() helper function creates an in-memory repository with
+// three commits on the default branch and one commit on the "feature" branch,
+// where each commit adds a single file, and returns the commit hashes in the
+// order they were committed.
+func testModeRepository(t *testing.T) (*git.Repository, []plumbing.Hash) {
+	repository, err := git.Init(gitmemory.NewStorage(), memfs.New())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	worktree, err := repository.Worktree()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	hashes := make([]plumbing.Hash, 0)
+	commit := func(name, content string) {
+		file, err := worktree.Filesystem.Create(name)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		file.Write([]byte(content))
+		file.Close()
+		_, err = worktree.Add(name)
+		assert.NoError(t, err)
+		hash, err := worktree.Commit("add "+name, &git.CommitOptions{
+			Author: &object.Signature{
+				Email: "dev@example.com",
+				Name:  "dev",
+				When:  time.Unix(int64(1700000000+len(hashes)), 0),
+			},
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		hashes = append(hashes, hash)
+	}
+	commit("a.md", "first file")
+	commit("b.md", "second file")
+	commit("c.md", "third file")
+
+	err = worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	commit("d.md", "feature file")
+	err = worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.Master})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return repository, hashes
+}
+
+// TestScanner_commitIterator() unit test function tests the commits returned
+// by the commitIterator() method for each scan mode.
+func TestScanner_commitIterator(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testModeRepository(t)
+
+	tests := []struct {
+		expected    []plumbing.Hash
+		err         bool
+		name        string
+		scan_config cfg.GitScanConfig
+	}{
+		{
+			expected:    hashes,
+			name:        "all",
+			scan_config: cfg.GitScanConfig{Mode: cfg.ScanModeAll},
+		},
+		{
+			expected:    hashes,
+			name:        "branch",
+			scan_config: cfg.GitScanConfig{Branch: "feature", Mode: cfg.ScanModeBranch},
+		},
+		{
+			expected:    hashes[2:3],
+			name:        "head",
+			scan_config: cfg.GitScanConfig{Mode: cfg.ScanModeHead},
+		},
+		{
+			expected:    hashes[1:],
+			name:        "range",
+			scan_config: cfg.GitScanConfig{Mode: cfg.ScanModeRange, Range: hashes[0].String() + "..feature"},
+		},
+		{
+			expected:    hashes[2:3],
+			name:        "since",
+			scan_config: cfg.GitScanConfig{Mode: cfg.ScanModeSince, Since: hashes[1].String()},
+		},
+		{
+			expected:    []plumbing.Hash{},
+			name:        "since_head",
+			scan_config: cfg.GitScanConfig{Mode: cfg.ScanModeSince, Since: "master"},
+		},
+		{
+			err:         true,
+			name:        "unknown_revision",
+			scan_config: cfg.GitScanConfig{Mode: cfg.ScanModeSince, Since: "missing"},
+		},
+		{
+			err:         true,
+			name:        "invalid",
+			scan_config: cfg.GitScanConfig{Mode: "invalid"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			git_config := test_valid_git_config_func()
+			git_config.Scan.Branch = test.scan_config.Branch
+			git_config.Scan.Mode = test.scan_config.Mode
+			git_config.Scan.Range = test.scan_config.Range
+			git_config.Scan.Since = test.scan_config.Since
+			s, s_err := NewScanner(test_context, git_config, memory.NewMemoryResultRecordIO(test_context))
+			if !assert.NoError(t, s_err) {
+				t.FailNow()
+			}
+			s.repository = repository
+
+			iterator, err := s.commitIterator()
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer iterator.Close()
+
+			found := make([]plumbing.Hash, 0)
+			assert.NoError(t, iterator.ForEach(func(c *object.Commit) error {
+				found = append(found, c.Hash)
+				return nil
+			}))
+			assert.ElementsMatch(t, test.expected, found)
+		})
+	}
+}
+
+// TestScanner_commitRangeIterator_IgnoreBaseFiles() unit test function tests
+// that files in the tree of the base commit of a range are not scanned.
+func TestScanner_commitRangeIterator_IgnoreBaseFiles(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testModeRepository(t)
+	s, s_err := NewScanner(test_context, test_valid_git_config_func(), memory.NewMemoryResultRecordIO(test_context))
+	if !assert.NoError(t, s_err) {
+		t.FailNow()
+	}
+	s.repository = repository
+
+	_, err := s.commitRangeIterator(hashes[1].String(), "HEAD")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	base, err := repository.CommitObject(hashes[1])
+	assert.NoError(t, err)
+	base_tree, err := base.Tree()
+	assert.NoError(t, err)
+	assert.NoError(t, base_tree.Files().ForEach(func(file *object.File) error {
+		key_data, exists := s.TrackerFiles.Get(file.Hash.String())
+		assert.True(t, exists)
+		assert.Equal(t, tracker.KeyCodeIgnore, key_data.Code)
+		return nil
+	}))
+	assert.Equal(t, 2, s.TrackerFiles.GetCounts().Ignore)
+}
+
+// TestScanner_classifyResults() unit test function tests that result records
+// are classified against the tip of the history scanned in the scan mode.
+func TestScanner_classifyResults(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testModeRepository(t)
+	commit, err := repository.CommitObject(hashes[3])
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	file, err := commit.File("d.md")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	result_io := memory.NewMemoryResultRecordIO(test_context)
+	assert.NoError(t, result_io.Write([]rrr.ResultRecord{{
+		Hash:     "hash-1",
+		Location: rrr.Location{Path: "d.md", StartLine: 1},
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			Object: rrr.MetadataRequestResponseObject{ID: file.Hash.String(), Path: "d.md"},
+		},
+		Result: rrr.Result{Text: "feature"},
+	}}))
+
+	tests := []struct {
+		branch string
+		mode   string
+		status string
+	}{
+		{mode: cfg.ScanModeAll, status: rrr.HeadStatusOnlyInHistory},
+		{branch: "feature", mode: cfg.ScanModeBranch, status: rrr.HeadStatusPresentAtHead},
+	}
+	for _, test := range tests {
+		git_config := test_valid_git_config_func()
+		git_config.Scan.Branch = test.branch
+		git_config.Scan.Mode = test.mode
+		s, s_err := NewScanner(test_context, git_config, result_io)
+		if !assert.NoError(t, s_err) {
+			t.FailNow()
+		}
+		s.repository = repository
+
+		assert.NoError(t, s.classifyResults())
+		record, err := result_io.Read("hash-1")
+		assert.NoError(t, err)
+		if assert.NotNil(t, record.Head) {
+			assert.Equal(t, test.status, record.Head.Status)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package multi
+
+import "github.com/pkg/errors"
+
+var (
+	ErrRouterRegisterEmptyID     = errors.New("router cannot register a route with an empty repository ID")
+	ErrRouterRegisterDuplicateID = errors.New("router cannot register a duplicate route for repository ID")
+	ErrRunWorkersInvalid         = errors.New("cannot scan repositories with fewer than one worker")
+)
@@ -0,0 +1,159 @@
+package multi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// ScanFunc func type is used to scan a single repository, where the returned
+// Summary describes the outcome of the scan. Any error returned is recorded
+// in the Summary instead of stopping the scans of other repositories.
+type ScanFunc func(ctx context.Context, repo_url string) (Summary, error)
+
+// Summary struct describes the outcome of the scan of a single repository.
+type Summary struct {
+	Commits  tracker.KeyDataCounts `json:"commits"`
+	Duration time.Duration         `json:"duration"`
+	Error    error                 `json:"-"`
+	Files    tracker.KeyDataCounts `json:"files"`
+	// Recovered is the number of recoverable errors that the scan of the
+	// repository continued after.
+	Recovered int                   `json:"recovered"`
+	RepoURL   string                `json:"repo_url"`
+	Requests  tracker.KeyDataCounts `json:"requests"`
+	Results   int                   `json:"results"`
+}
+
+// Failed() function returns the number of summaries with a non-nil Error.
+func Failed(summaries []Summary) (failed int) {
+	for _, summary := range summaries {
+		if summary.Error != nil {
+			failed++
+		}
+	}
+	return
+}
+
+// Run() function scans each of the repositories with the ScanFunc, using a
+// pool of up to workers goroutines to scan repositories in parallel. Returns
+// one Summary per repository, in the same order as repo_urls, after the scans
+// of all repositories are done. A failed scan does not stop the scans of the
+// other repositories, while repositories that have not started scanning are
+// skipped when the context is done.
+func Run(ctx context.Context, repo_urls []string, workers int, scan ScanFunc) ([]Summary, error) {
+	if workers < 1 {
+		return nil, ErrRunWorkersInvalid
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	summaries := make([]Summary, len(repo_urls))
+	chan_jobs := make(chan int)
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers && w < len(repo_urls); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chan_jobs {
+				summaries[i] = runScan(ctx, repo_urls[i], scan)
+			}
+		}()
+	}
+
+	// a repository is skipped if the context is done before a worker is
+	// free to scan it, including while waiting for a free worker
+	for i := range repo_urls {
+		if ctx.Err() != nil {
+			summaries[i] = Summary{Error: ctx.Err(), RepoURL: repo_urls[i]}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			summaries[i] = Summary{Error: ctx.Err(), RepoURL: repo_urls[i]}
+		case chan_jobs <- i:
+		}
+	}
+	close(chan_jobs)
+	wg.Wait()
+
+	return summaries, nil
+}
+
+// WriteSummaries() function writes a table of the summaries to w, with one
+// row per repository and a final row with the totals of all repositories.
+func WriteSummaries(w io.Writer, summaries []Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tSTATUS\tCOMMITS\tFILES\tREQUESTS\tERRORS\tRECOVERED\tRESULTS\tDURATION")
+
+	var total Summary
+	for _, summary := range summaries {
+		status := "ok"
+		if summary.Error != nil {
+			status = "failed: " + summary.Error.Error()
+		}
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			summary.RepoURL,
+			status,
+			summary.Commits.Complete,
+			summary.Files.Complete,
+			summary.Requests.Complete,
+			summary.Requests.Error,
+			summary.Recovered,
+			summary.Results,
+			summary.Duration.Round(time.Millisecond),
+		)
+		total.Commits.Complete += summary.Commits.Complete
+		total.Duration += summary.Duration
+		total.Files.Complete += summary.Files.Complete
+		total.Requests.Complete += summary.Requests.Complete
+		total.Requests.Error += summary.Requests.Error
+		total.Recovered += summary.Recovered
+		total.Results += summary.Results
+	}
+	fmt.Fprintf(
+		tw,
+		"TOTAL (%d)\t%d failed\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+		len(summaries),
+		Failed(summaries),
+		total.Commits.Complete,
+		total.Files.Complete,
+		total.Requests.Complete,
+		total.Requests.Error,
+		total.Recovered,
+		total.Results,
+		total.Duration.Round(time.Millisecond),
+	)
+	return tw.Flush()
+}
+
+// runScan() function runs the ScanFunc for a single repository and fills in
+// the RepoURL, Duration, and Error of the returned Summary.
+func runScan(ctx context.Context, repo_url string, scan ScanFunc) (summary Summary) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			summary.Error = errors.Errorf("scan of repository panicked : %v", r)
+		}
+		summary.Duration = time.Since(start)
+		summary.RepoURL = repo_url
+	}()
+
+	var err error
+	summary, err = scan(ctx, repo_url)
+	if err != nil {
+		summary.Error = err
+	}
+	return
+}
@@ -0,0 +1,281 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/dryrun"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// budgetDetector struct is a test detector that records the maximum number of
+// requests received before any response is sent, then responds to requests in
+// batches of batch_size.
+type budgetDetector struct {
+	batch_size int
+	max_seen   int
+	received   int32
+}
+
+func (d *budgetDetector) Run(ctx context.Context, requests <-chan rrr.Request, responses chan<- rrr.Response) {
+	defer close(responses)
+	batch := make([]rrr.Request, 0, d.batch_size)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-requests:
+			atomic.AddInt32(&d.received, 1)
+			batch = append(batch, request)
+			if len(batch) > d.max_seen {
+				d.max_seen = len(batch)
+			}
+			if len(batch) < d.batch_size {
+				continue
+			}
+			for i := range batch {
+				select {
+				case <-ctx.Done():
+					return
+				case responses <- rrr.NewResponse(&batch[i]):
+				}
+			}
+			batch = batch[:0]
+		}
+	}
+}
+
+func newTestRequest(repo_id string, n int) rrr.Request {
+	return rrr.Request{
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			ID: fmt.Sprintf("%s-request-%d", repo_id, n),
+			Repository: rrr.MetadataRequestResponseRepository{
+				ID: repo_id,
+			},
+		},
+	}
+}
+
+// TestRouter() unit test function tests that the Router routes responses of
+// a shared detector back to the route of each repository.
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	router := NewRouter(ctx, 4)
+	go router.Run(dryrun.NewDryRunPhiDetector())
+
+	_, err := router.Register("")
+	assert.ErrorIs(t, err, ErrRouterRegisterEmptyID)
+
+	repo_ids := []string{"repository-1", "repository-2", "repository-3"}
+	wg := &sync.WaitGroup{}
+	for _, repo_id := range repo_ids {
+		route, err := router.Register(repo_id)
+		require.NoError(t, err)
+		_, err = router.Register(repo_id)
+		assert.ErrorIs(t, err, ErrRouterRegisterDuplicateID)
+
+		wg.Add(1)
+		go func(repo_id string, route *Route) {
+			defer wg.Done()
+			defer router.Unregister(repo_id)
+			const count = 20
+			go func() {
+				for n := 0; n < count; n++ {
+					route.ChanRequests <- newTestRequest(repo_id, n)
+				}
+			}()
+			for n := 0; n < count; n++ {
+				select {
+				case response := <-route.ChanResponses:
+					assert.Equal(t, repo_id, response.Repository.ID)
+				case <-time.After(5 * time.Second):
+					t.Errorf("timed out waiting for responses of %s", repo_id)
+					return
+				}
+			}
+		}(repo_id, route)
+	}
+	wg.Wait()
+	assert.Equal(t, 0, router.Outstanding())
+}
+
+// TestRouter_Budget() unit test function tests that the Router never allows
+// more requests than the budget to be outstanding at the detector.
+func TestRouter_Budget(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const budget = 3
+	d := &budgetDetector{batch_size: budget}
+	router := NewRouter(ctx, budget)
+	go router.Run(d)
+
+	route_1, err := router.Register("repository-1")
+	require.NoError(t, err)
+	route_2, err := router.Register("repository-2")
+	require.NoError(t, err)
+
+	const count = 30
+	for _, route := range []*Route{route_1, route_2} {
+		go func(route *Route, repo_id string) {
+			for n := 0; n < count; n++ {
+				route.ChanRequests <- newTestRequest(repo_id, n)
+			}
+		}(route, fmt.Sprintf("repository-%d", map[*Route]int{route_1: 1, route_2: 2}[route]))
+	}
+	received := 0
+	timeout := time.After(5 * time.Second)
+	for received < 2*count {
+		select {
+		case <-route_1.ChanResponses:
+			received++
+		case <-route_2.ChanResponses:
+			received++
+		case <-timeout:
+			t.Fatalf("timed out after receiving %d responses", received)
+		}
+	}
+	assert.LessOrEqual(t, d.max_seen, budget)
+}
+
+// TestRouter_Unregister() unit test function tests that the Router drops late
+// responses for repositories that are no longer registered.
+func TestRouter_Unregister(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &budgetDetector{batch_size: 2}
+	router := NewRouter(ctx, 2)
+	go router.Run(d)
+
+	route_1, err := router.Register("repository-1")
+	require.NoError(t, err)
+	route_2, err := router.Register("repository-2")
+	require.NoError(t, err)
+
+	route_1.ChanRequests <- newTestRequest("repository-1", 0)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&d.received) == 1 }, 5*time.Second, time.Millisecond)
+	router.Unregister("repository-1")
+	router.Unregister("repository-1")
+
+	// the detector responds to both requests, where the response for
+	// repository-1 is dropped and still releases its slot in the budget
+	route_2.ChanRequests <- newTestRequest("repository-2", 0)
+	select {
+	case response := <-route_2.ChanResponses:
+		assert.Equal(t, "repository-2", response.Repository.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for response of repository-2")
+	}
+	assert.Eventually(t, func() bool { return router.Outstanding() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestRun() unit test function tests that Run() scans all repositories with
+// a bounded number of workers and records failures without stopping others.
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	_, err := Run(context.Background(), []string{"a"}, 0, nil)
+	assert.ErrorIs(t, err, ErrRunWorkersInvalid)
+
+	repo_urls := []string{"repo-0", "repo-1", "repo-2", "repo-3", "repo-4", "repo-5"}
+	const workers = 2
+	var running, max_running int32
+	summaries, err := Run(context.Background(), repo_urls, workers, func(ctx context.Context, repo_url string) (Summary, error) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			prev := atomic.LoadInt32(&max_running)
+			if now <= prev || atomic.CompareAndSwapInt32(&max_running, prev, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		switch repo_url {
+		case "repo-1":
+			return Summary{}, errors.New("clone failed")
+		case "repo-3":
+			panic("unexpected")
+		}
+		return Summary{Results: 2}, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, summaries, len(repo_urls))
+	assert.LessOrEqual(t, max_running, int32(workers))
+	for i, summary := range summaries {
+		assert.Equal(t, repo_urls[i], summary.RepoURL)
+		switch i {
+		case 1, 3:
+			assert.Error(t, summary.Error)
+		default:
+			assert.NoError(t, summary.Error)
+			assert.Equal(t, 2, summary.Results)
+		}
+	}
+	assert.Equal(t, 2, Failed(summaries))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSummaries(&buf, summaries))
+	assert.Contains(t, buf.String(), "failed: clone failed")
+	assert.Contains(t, buf.String(), "TOTAL (6)")
+	assert.Contains(t, buf.String(), "2 failed")
+}
+
+// TestRun_ContextDone() unit test function tests that Run() skips the scans
+// of repositories when the context is done.
+func TestRun_ContextDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	summaries, err := Run(ctx, []string{"repo-0", "repo-1"}, 1, func(ctx context.Context, repo_url string) (Summary, error) {
+		t.Errorf("unexpected scan of %s", repo_url)
+		return Summary{}, nil
+	})
+	require.NoError(t, err)
+	for _, summary := range summaries {
+		assert.ErrorIs(t, summary.Error, context.Canceled)
+	}
+}
+
+// TestRun_ContextDoneWhileWaiting() unit test function tests that Run() skips
+// the scans of repositories that are waiting for a free worker when the
+// context is done.
+func TestRun_ContextDoneWhileWaiting(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var scanned int32
+	summaries, err := Run(ctx, []string{"repo-0", "repo-1", "repo-2"}, 1, func(ctx context.Context, repo_url string) (Summary, error) {
+		atomic.AddInt32(&scanned, 1)
+		// give Run() time to wait for a free worker to scan the next repository
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		return Summary{Results: 1}, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&scanned))
+	assert.NoError(t, summaries[0].Error)
+	for _, summary := range summaries[1:] {
+		assert.ErrorIs(t, summary.Error, context.Canceled)
+	}
+}
@@ -0,0 +1,192 @@
+package multi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// Route struct contains the channels used by a single Scanner to send
+// requests to, and receive responses from, the Detector shared via a Router,
+// e.g. as the ChanRequestSend and ChanResponseReceive of a scanner.ScanInput.
+type Route struct {
+	ChanRequests  chan rrr.Request
+	ChanResponses chan rrr.Response
+
+	done      chan struct{}
+	done_once *sync.Once
+}
+
+// Router struct multiplexes the requests of many Scanners to one shared
+// Detector and routes each response back to the Scanner that sent the
+// request, using the repository ID of the request. The number of requests
+// outstanding at the Detector is limited to a global budget, which is shared
+// by all Scanners, such that each Scanner is blocked from sending more
+// requests while the budget is exhausted.
+type Router struct {
+	budget         chan struct{}
+	chan_requests  chan rrr.Request
+	chan_responses chan rrr.Response
+	ctx            context.Context
+	logger         *zerolog.Logger
+	routes         map[string]*Route
+	routes_mutex   *sync.RWMutex
+}
+
+// NewRouter() function returns a new Router that allows up to max_outstanding
+// requests to be outstanding at the Detector, or 1 if max_outstanding is not
+// a positive number.
+func NewRouter(ctx context.Context, max_outstanding int) *Router {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if max_outstanding < 1 {
+		max_outstanding = 1
+	}
+	return &Router{
+		budget:         make(chan struct{}, max_outstanding),
+		chan_requests:  make(chan rrr.Request),
+		chan_responses: make(chan rrr.Response),
+		ctx:            ctx,
+		logger:         zerolog.Ctx(ctx),
+		routes:         make(map[string]*Route),
+		routes_mutex:   &sync.RWMutex{},
+	}
+}
+
+// Outstanding() method returns the number of requests that have been sent to
+// the Detector without receiving a response.
+func (r *Router) Outstanding() int {
+	return len(r.budget)
+}
+
+// Register() method creates a Route for the repository ID used by a Scanner
+// in its requests, i.e. the ID of the Scanner, and starts forwarding the
+// requests of the Route to the Detector.
+func (r *Router) Register(repo_id string) (*Route, error) {
+	if repo_id == "" {
+		return nil, ErrRouterRegisterEmptyID
+	}
+
+	r.routes_mutex.Lock()
+	defer r.routes_mutex.Unlock()
+	if _, exists := r.routes[repo_id]; exists {
+		return nil, errors.Wrapf(ErrRouterRegisterDuplicateID, "ID = %s", repo_id)
+	}
+	route := &Route{
+		ChanRequests:  make(chan rrr.Request),
+		ChanResponses: make(chan rrr.Response),
+		done:          make(chan struct{}),
+		done_once:     &sync.Once{},
+	}
+	r.routes[repo_id] = route
+
+	go r.forwardRequests(route)
+	return route, nil
+}
+
+// Run() method runs the Detector with the shared channels of the Router and
+// routes the responses of the Detector to the registered Routes, until the
+// Detector returns or the context of the Router is done.
+func (r *Router) Run(d rrr.RequestResponsePhiDetector) {
+	r.logger.Debug().Msg("started multi-repository router")
+	defer r.logger.Debug().Msg("finished multi-repository router")
+
+	// the Detector closes r.chan_responses when it returns
+	go d.Run(r.ctx, r.chan_requests, r.chan_responses)
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case response, ok := <-r.chan_responses:
+			if !ok {
+				return
+			}
+			r.routeResponse(response)
+		}
+	}
+}
+
+// Unregister() method removes the Route for the repository ID, such that any
+// requests of the Route are no longer forwarded and any late responses for
+// the repository are dropped.
+func (r *Router) Unregister(repo_id string) {
+	r.routes_mutex.Lock()
+	route, exists := r.routes[repo_id]
+	delete(r.routes, repo_id)
+	r.routes_mutex.Unlock()
+
+	if exists {
+		route.done_once.Do(func() { close(route.done) })
+	}
+}
+
+// forwardRequests() method is intended to be run as a goroutine that forwards
+// the requests of the Route to the Detector, waiting for a slot in the budget
+// before forwarding each request.
+func (r *Router) forwardRequests(route *Route) {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-route.done:
+			return
+		case request := <-route.ChanRequests:
+			// acquire a slot in the budget, which is released when the
+			// response for the request is received
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-route.done:
+				return
+			case r.budget <- struct{}{}:
+			}
+			select {
+			case <-r.ctx.Done():
+				r.release()
+				return
+			case <-route.done:
+				r.release()
+				return
+			case r.chan_requests <- request:
+			}
+		}
+	}
+}
+
+// release() method releases a slot in the budget without blocking.
+func (r *Router) release() {
+	select {
+	case <-r.budget:
+	default:
+	}
+}
+
+// routeResponse() method releases the slot in the budget held by the request
+// of the response, then sends the response to the Route of the repository.
+func (r *Router) routeResponse(response rrr.Response) {
+	r.release()
+
+	r.routes_mutex.RLock()
+	route, exists := r.routes[response.Repository.ID]
+	r.routes_mutex.RUnlock()
+	if !exists {
+		r.logger.Warn().Msgf(
+			"router dropped response ID = %s for unregistered repository ID = %s",
+			response.ID,
+			response.Repository.ID,
+		)
+		return
+	}
+
+	select {
+	case <-r.ctx.Done():
+	case <-route.done:
+	case route.ChanResponses <- response:
+	}
+}
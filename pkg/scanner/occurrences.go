@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"sync"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// occurrenceIndex struct records every commit and path at which each object
+// (i.e. file blob) is found during a scan, because each object is only
+// scanned once regardless of how many times it appears in the repository.
+// Only the occurrences of objects that may still have results are kept, i.e.
+// objects that are not done yet and objects with results, such that the
+// index does not hold the occurrences of every object in the history.
+type occurrenceIndex struct {
+	findings    map[string]bool
+	mutex       *sync.RWMutex
+	occurrences map[string][]rrr.MetadataRequestResponseOccurrence
+	seen        map[string]map[string]bool
+}
+
+// newOccurrenceIndex() function initializes a new, empty occurrenceIndex.
+func newOccurrenceIndex() *occurrenceIndex {
+	return &occurrenceIndex{
+		findings:    make(map[string]bool),
+		mutex:       &sync.RWMutex{},
+		occurrences: make(map[string][]rrr.MetadataRequestResponseOccurrence),
+		seen:        make(map[string]map[string]bool),
+	}
+}
+
+// Add() method records an occurrence of the object with the given ID and
+// returns true if the occurrence was not previously recorded.
+func (oi *occurrenceIndex) Add(object_id string, o rrr.MetadataRequestResponseOccurrence) bool {
+	key := o.Commit.ID + rrr.ResultSeparatorUID + o.Path
+
+	oi.mutex.Lock()
+	defer oi.mutex.Unlock()
+
+	if oi.seen[object_id] == nil {
+		oi.seen[object_id] = make(map[string]bool)
+	}
+	if oi.seen[object_id][key] {
+		return false
+	}
+	oi.seen[object_id][key] = true
+	oi.occurrences[object_id] = append(oi.occurrences[object_id], o)
+	return true
+}
+
+// Done() method removes the occurrences of the object with the given ID when
+// the scan of the object is done, unless the object has results, which then
+// need every occurrence of the object.
+func (oi *occurrenceIndex) Done(object_id string) {
+	oi.mutex.Lock()
+	defer oi.mutex.Unlock()
+
+	if oi.findings[object_id] {
+		return
+	}
+	delete(oi.occurrences, object_id)
+	delete(oi.seen, object_id)
+}
+
+// Get() method returns a copy of the occurrences recorded for the object
+// with the given ID.
+func (oi *occurrenceIndex) Get(object_id string) []rrr.MetadataRequestResponseOccurrence {
+	oi.mutex.RLock()
+	defer oi.mutex.RUnlock()
+
+	occurrences := oi.occurrences[object_id]
+	if len(occurrences) == 0 {
+		return nil
+	}
+	return append([]rrr.MetadataRequestResponseOccurrence{}, occurrences...)
+}
+
+// GetAll() method returns a copy of the occurrences of every object in the
+// index, keyed by the ID of the object, e.g. to save them in a Checkpoint.
+func (oi *occurrenceIndex) GetAll() map[string][]rrr.MetadataRequestResponseOccurrence {
+	oi.mutex.RLock()
+	defer oi.mutex.RUnlock()
+
+	out := make(map[string][]rrr.MetadataRequestResponseOccurrence, len(oi.occurrences))
+	for object_id, occurrences := range oi.occurrences {
+		out[object_id] = append([]rrr.MetadataRequestResponseOccurrence{}, occurrences...)
+	}
+	return out
+}
+
+// HasFindings() method returns true if the object with the given ID has
+// results, as set by the SetFindings() method.
+func (oi *occurrenceIndex) HasFindings(object_id string) bool {
+	oi.mutex.RLock()
+	defer oi.mutex.RUnlock()
+
+	return oi.findings[object_id]
+}
+
+// SetFindings() method marks the object with the given ID as having results,
+// such that its occurrences are kept after the scan of the object is done.
+func (oi *occurrenceIndex) SetFindings(object_id string) {
+	oi.mutex.Lock()
+	defer oi.mutex.Unlock()
+
+	oi.findings[object_id] = true
+}
+
+// Restore() method restores the index from the occurrences saved in a
+// Checkpoint and from the result records of a previous scan, where each
+// object of the result records has results.
+func (oi *occurrenceIndex) Restore(
+	occurrences map[string][]rrr.MetadataRequestResponseOccurrence,
+	records []rrr.ResultRecord,
+) {
+	for _, record := range records {
+		oi.SetFindings(record.Object.ID)
+		for _, o := range record.Occurrences {
+			oi.Add(record.Object.ID, o)
+		}
+	}
+	for object_id, object_occurrences := range occurrences {
+		for _, o := range object_occurrences {
+			oi.Add(object_id, o)
+		}
+	}
+}
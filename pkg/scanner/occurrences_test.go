@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestOccurrenceIndex() unit test function tests the Add(), Get(), and Done()
+// methods of the occurrenceIndex struct.
+func TestOccurrenceIndex(t *testing.T) {
+	t.Parallel()
+
+	oi := newOccurrenceIndex()
+	o_1 := rrr.MetadataRequestResponseOccurrence{
+		Commit: rrr.MetadataRequestResponseCommit{ID: "commit-1"},
+		Path:   "a.txt",
+	}
+	o_2 := rrr.MetadataRequestResponseOccurrence{
+		Commit: rrr.MetadataRequestResponseCommit{ID: "commit-2"},
+		Path:   "b.txt",
+	}
+	assert.True(t, oi.Add("object-1", o_1))
+	assert.False(t, oi.Add("object-1", o_1))
+	assert.True(t, oi.Add("object-1", o_2))
+	assert.True(t, oi.Add("object-2", o_1))
+
+	assert.Equal(t, []rrr.MetadataRequestResponseOccurrence{o_1, o_2}, oi.Get("object-1"))
+	assert.Equal(t, []rrr.MetadataRequestResponseOccurrence{o_1}, oi.Get("object-2"))
+	assert.Nil(t, oi.Get("object-3"))
+
+	// only the occurrences of the done object with results are kept
+	oi.SetFindings("object-1")
+	oi.Done("object-1")
+	oi.Done("object-2")
+	assert.True(t, oi.HasFindings("object-1"))
+	assert.False(t, oi.HasFindings("object-2"))
+	assert.Equal(t, map[string][]rrr.MetadataRequestResponseOccurrence{"object-1": {o_1, o_2}}, oi.GetAll())
+	assert.True(t, oi.Add("object-2", o_1))
+}
+
+// TestOccurrenceIndex_Restore() unit test function tests that the Restore()
+// method rebuilds the occurrenceIndex from the occurrences of a Checkpoint
+// and the result records of a previous scan.
+func TestOccurrenceIndex_Restore(t *testing.T) {
+	t.Parallel()
+
+	o_1 := rrr.MetadataRequestResponseOccurrence{
+		Commit: rrr.MetadataRequestResponseCommit{ID: "commit-1"},
+		Path:   "a.txt",
+	}
+	o_2 := rrr.MetadataRequestResponseOccurrence{
+		Commit: rrr.MetadataRequestResponseCommit{ID: "commit-2"},
+		Path:   "b.txt",
+	}
+	record := rrr.ResultRecord{
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			Object:      rrr.MetadataRequestResponseObject{ID: "object-1"},
+			Occurrences: []rrr.MetadataRequestResponseOccurrence{o_1},
+		},
+	}
+
+	oi := newOccurrenceIndex()
+	oi.Restore(
+		map[string][]rrr.MetadataRequestResponseOccurrence{
+			"object-1": {o_1, o_2},
+			"object-2": {o_2},
+		},
+		[]rrr.ResultRecord{record},
+	)
+	assert.True(t, oi.HasFindings("object-1"))
+	assert.False(t, oi.HasFindings("object-2"))
+	assert.Equal(t, []rrr.MetadataRequestResponseOccurrence{o_1, o_2}, oi.Get("object-1"))
+	assert.Equal(t, []rrr.MetadataRequestResponseOccurrence{o_2}, oi.Get("object-2"))
+}
+
+// TestScanner_updateResultOccurrences() unit test function tests that result
+// records are updated with occurrences recorded after they were written.
+func TestScanner_updateResultOccurrences(t *testing.T) {
+	t.Parallel()
+
+	result_io := memory.NewMemoryResultRecordIO(test_context)
+	s, s_err := NewScanner(test_context, test_valid_git_config_func(), result_io)
+	if !assert.NoError(t, s_err) {
+		t.FailNow()
+	}
+
+	o_1 := rrr.MetadataRequestResponseOccurrence{
+		Commit: rrr.MetadataRequestResponseCommit{ID: "commit-1", Time: 100},
+		Path:   "a.txt",
+	}
+	o_2 := rrr.MetadataRequestResponseOccurrence{
+		Commit: rrr.MetadataRequestResponseCommit{ID: "commit-2", Time: 50},
+		Path:   "b.txt",
+	}
+	record := rrr.ResultRecord{
+		Hash: "hash-1",
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			Commit: o_1.Commit,
+			Object: rrr.MetadataRequestResponseObject{ID: "object-1", Path: "a.txt"},
+		},
+	}
+	record.SetOccurrences([]rrr.MetadataRequestResponseOccurrence{o_1})
+	assert.NoError(t, result_io.Write([]rrr.ResultRecord{record}))
+
+	s.occurrences.Add("object-1", o_1)
+	s.occurrences.Add("object-1", o_2)
+	assert.NoError(t, s.updateResultOccurrences())
+
+	updated, err := result_io.Read("hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []rrr.MetadataRequestResponseOccurrence{o_2, o_1}, updated.Occurrences)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, updated.Object.Paths)
+	assert.Equal(t, "commit-1", updated.Commit.ID)
+}
@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// ErrorPolicy struct determines whether the Scanner stops the scan of a
+// repository when an error occurs. Fatal errors, e.g. a repository that
+// cannot be read, always stop the scan, while recoverable errors, e.g. a
+// file that failed to chunk, only stop the scan once more than MaxErrors
+// recoverable errors have occurred.
+type ErrorPolicy struct {
+	// MaxErrors is the maximum number of recoverable errors after which the
+	// scan continues, where a negative value means that the scan always
+	// continues after a recoverable error.
+	MaxErrors int
+}
+
+// NewErrorPolicy() function parses the error policy config value, e.g.
+// "continue", "fail-fast", or "max-errors=N", and returns the ErrorPolicy.
+// An empty policy is replaced by cfg.DefaultErrorPolicy.
+func NewErrorPolicy(policy string) (ErrorPolicy, error) {
+	if policy == "" {
+		policy = cfg.DefaultErrorPolicy
+	}
+	max_errors, err := cfg.ParseErrorPolicy(policy)
+	if err != nil {
+		return ErrorPolicy{}, err
+	}
+	return ErrorPolicy{MaxErrors: max_errors}, nil
+}
+
+// Stop() method returns true if the scan should stop after the error, where
+// recoverable is the number of recoverable errors of the scan so far,
+// including the error itself if the error is recoverable.
+func (p ErrorPolicy) Stop(err error, recoverable int) bool {
+	if !IsRecoverable(err) {
+		return true
+	}
+	return p.MaxErrors >= 0 && recoverable > p.MaxErrors
+}
+
+// RecoverableError struct wraps an error that only affects part of the scan,
+// e.g. a single commit, file, or request, such that the rest of the scan can
+// continue according to the ErrorPolicy of the Scanner.
+type RecoverableError struct {
+	err error
+}
+
+// NewRecoverableError() function wraps the error as a RecoverableError, or
+// returns nil if the error is nil.
+func NewRecoverableError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RecoverableError{err: err}
+}
+
+// Error() method returns the message of the wrapped error.
+func (e *RecoverableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap() method returns the wrapped error.
+func (e *RecoverableError) Unwrap() error {
+	return e.err
+}
+
+// recoverable_errors lists the sentinel errors that only affect a single
+// request or response, and are therefore recoverable without being wrapped
+// as a RecoverableError.
+var recoverable_errors = []error{
+	ErrProcessRequestNoID,
+	ErrProcessResponseNoID,
+}
+
+// IsRecoverable() function returns true if the error only affects part of
+// the scan, or false if the error is fatal to the scan of the repository.
+// Errors are fatal unless classified as recoverable.
+func IsRecoverable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var recoverable *RecoverableError
+	if errors.As(err, &recoverable) {
+		return true
+	}
+	for _, target := range recoverable_errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanSummary struct summarizes the outcome of the scan of a repository,
+// including the recoverable errors that the scan continued after.
+type ScanSummary struct {
+	Commits tracker.KeyDataCounts `json:"commits"`
+	// Error is the error that stopped the scan, if any, which is either a
+	// fatal error or the recoverable error that exceeded the ErrorPolicy.
+	Error        error                 `json:"-"`
+	FailedChunks int                   `json:"failed_chunks"`
+	Files        tracker.KeyDataCounts `json:"files"`
+	// Recoverable lists the first ScanSummaryMaxErrors recoverable errors
+	// of the scan, while RecoverableCount counts all recoverable errors.
+	Recoverable      []error               `json:"-"`
+	RecoverableCount int                   `json:"recoverable_count"`
+	Requests         tracker.KeyDataCounts `json:"requests"`
+}
+
+// scanErrors struct collects the errors of a scan, and is safe for
+// concurrent use.
+type scanErrors struct {
+	err               error
+	mutex             *sync.RWMutex
+	recoverable       []error
+	recoverable_count int
+}
+
+// newScanErrors() function initializes a new, empty scanErrors struct.
+func newScanErrors() *scanErrors {
+	return &scanErrors{
+		mutex: &sync.RWMutex{},
+	}
+}
+
+// Add() method records the error if the error is recoverable, and returns
+// the number of recoverable errors recorded so far.
+func (se *scanErrors) Add(err error) int {
+	se.mutex.Lock()
+	defer se.mutex.Unlock()
+	if IsRecoverable(err) {
+		se.recoverable_count++
+		if len(se.recoverable) < ScanSummaryMaxErrors {
+			se.recoverable = append(se.recoverable, err)
+		}
+	}
+	return se.recoverable_count
+}
+
+// Stop() method records the error that stopped the scan.
+func (se *scanErrors) Stop(err error) {
+	se.mutex.Lock()
+	defer se.mutex.Unlock()
+	se.err = err
+}
+
+// summarize() method sets the errors of the ScanSummary.
+func (se *scanErrors) summarize(summary *ScanSummary) {
+	se.mutex.RLock()
+	defer se.mutex.RUnlock()
+	summary.Error = se.err
+	summary.Recoverable = append([]error{}, se.recoverable...)
+	summary.RecoverableCount = se.recoverable_count
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+)
+
+// TestErrorPolicy_Stop() unit test function tests whether each ErrorPolicy
+// stops the scan after fatal and recoverable errors.
+func TestErrorPolicy_Stop(t *testing.T) {
+	t.Parallel()
+
+	err_fatal := ErrScannerRepositoryNil
+	err_recoverable := NewRecoverableError(errors.New("failed to chunk file"))
+
+	tests := []struct {
+		name        string
+		policy      string
+		recoverable int
+		expected    bool
+	}{
+		{name: "Default", policy: "", recoverable: 100, expected: false},
+		{name: "Continue", policy: cfg.ErrorPolicyContinue, recoverable: 100, expected: false},
+		{name: "FailFast", policy: cfg.ErrorPolicyFailFast, recoverable: 1, expected: true},
+		{name: "MaxErrors_Below", policy: "max-errors=2", recoverable: 2, expected: false},
+		{name: "MaxErrors_Above", policy: "max-errors=2", recoverable: 3, expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := NewErrorPolicy(test.policy)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, policy.Stop(err_recoverable, test.recoverable))
+			// fatal errors always stop the scan
+			assert.True(t, policy.Stop(err_fatal, 0))
+		})
+	}
+
+	_, err := NewErrorPolicy("max-errors=some")
+	assert.Error(t, err)
+}
+
+// TestIsRecoverable() unit test function tests the classification of errors
+// as fatal or recoverable.
+func TestIsRecoverable(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, IsRecoverable(nil))
+	assert.False(t, IsRecoverable(ErrScannerRepositoryNil))
+	assert.False(t, IsRecoverable(errors.Wrap(ErrScannerRepositoryNil, "wrapped")))
+	assert.True(t, IsRecoverable(ErrProcessRequestNoID))
+	assert.True(t, IsRecoverable(errors.Wrap(ErrProcessResponseNoID, "wrapped")))
+
+	err := NewRecoverableError(ErrRetryChunkOutOfRange)
+	assert.True(t, IsRecoverable(err))
+	assert.True(t, IsRecoverable(errors.Wrap(err, "wrapped")))
+	assert.ErrorIs(t, err, ErrRetryChunkOutOfRange)
+	assert.Equal(t, ErrRetryChunkOutOfRange.Error(), err.Error())
+	assert.Nil(t, NewRecoverableError(nil))
+}
+
+// TestScanner_processErrors() unit test function tests that the processErrors()
+// method continues after recoverable errors until the ErrorPolicy is exceeded,
+// and records the errors in the summary of the scan.
+func TestScanner_processErrors(t *testing.T) {
+	t.Parallel()
+
+	git_config := test_valid_git_config_func()
+	git_config.Scan.ErrorPolicy = "max-errors=2"
+	s, s_err := NewScanner(test_context, git_config, memory.NewMemoryResultRecordIO(test_context))
+	require.NoError(t, s_err)
+
+	chan_quit := make(chan struct{})
+	defer close(chan_quit)
+	chan_errors_in := make(chan error)
+	chan_errors_out := make(chan error, 1)
+	go s.processErrors(chan_quit, chan_errors_in, chan_errors_out)
+
+	for i := 0; i < 3; i++ {
+		chan_errors_in <- NewRecoverableError(errors.Errorf("recoverable error %d", i))
+	}
+	err, ok := <-chan_errors_out
+	require.True(t, ok)
+	assert.True(t, IsRecoverable(err))
+	assert.ErrorContains(t, err, "stopped scan after 3 recoverable errors")
+	_, ok = <-chan_errors_out
+	assert.False(t, ok)
+
+	summary := s.Summary()
+	assert.Equal(t, err, summary.Error)
+	assert.Equal(t, 3, summary.RecoverableCount)
+	assert.Len(t, summary.Recoverable, 3)
+}
+
+// TestScanner_processErrors_Fatal() unit test function tests that the
+// processErrors() method stops at the first fatal error, even when the
+// ErrorPolicy continues after any recoverable error.
+func TestScanner_processErrors_Fatal(t *testing.T) {
+	t.Parallel()
+
+	git_config := test_valid_git_config_func()
+	git_config.Scan.ErrorPolicy = cfg.ErrorPolicyContinue
+	s, s_err := NewScanner(test_context, git_config, memory.NewMemoryResultRecordIO(test_context))
+	require.NoError(t, s_err)
+
+	chan_quit := make(chan struct{})
+	defer close(chan_quit)
+	chan_errors_in := make(chan error)
+	chan_errors_out := make(chan error, 1)
+	go s.processErrors(chan_quit, chan_errors_in, chan_errors_out)
+
+	chan_errors_in <- ErrProcessRequestNoID
+	chan_errors_in <- ErrScannerRepositoryNil
+	assert.Equal(t, ErrScannerRepositoryNil, <-chan_errors_out)
+
+	summary := s.Summary()
+	assert.Equal(t, ErrScannerRepositoryNil, summary.Error)
+	assert.Equal(t, 1, summary.RecoverableCount)
+	if assert.Len(t, summary.Recoverable, 1) {
+		assert.Equal(t, ErrProcessRequestNoID, summary.Recoverable[0])
+	}
+}
@@ -0,0 +1,20 @@
+package report
+
+import "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+
+const InformationURI string = "https://github.com/Pii-Hole-Engineering/no-phi-ai"
+const MaskRune rune = '*'
+const SARIFFingerprintKey string = "noPhiAiResultHash/v1"
+const SARIFLevel string = "warning"
+const SARIFLogicalLocationKind string = "resource"
+const SARIFRuleIDPrefix string = "phi/"
+const SARIFSchema string = "https://json.schemastore.org/sarif-2.1.0.json"
+const SARIFVersion string = "2.1.0"
+
+// FileExtensions maps each report format to the extension of its file.
+var FileExtensions = map[string]string{
+	cfg.ReportFormatCSV:      ".csv",
+	cfg.ReportFormatJSON:     ".json",
+	cfg.ReportFormatMarkdown: ".md",
+	cfg.ReportFormatSARIF:    ".sarif",
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CSVHeader is the header row of CSV reports.
+var CSVHeader = []string{
+	"repository",
+	"file",
+	"object_id",
+	"commit_id",
+	"category",
+	"subcategory",
+	"confidence_score",
+	"line",
+	"column",
+	"offset",
+	"length",
+	"service",
+	"text",
+	"hash",
+	"status",
+	"blame_author",
+	"blame_commit_id",
+}
+
+// writeCSV() method renders the report as CSV, with one row per finding.
+func (report *Report) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, finding := range report.Findings() {
+		if err := writer.Write([]string{
+			report.Repository,
+			finding.File,
+			finding.ObjectID,
+			finding.CommitID,
+			finding.Category,
+			finding.Subcategory,
+			strconv.FormatFloat(finding.ConfidenceScore, 'f', -1, 64),
+			strconv.Itoa(finding.Line),
+			strconv.Itoa(finding.Column),
+			strconv.Itoa(finding.Offset),
+			strconv.Itoa(finding.Length),
+			finding.Service,
+			finding.Text,
+			finding.Hash,
+			finding.Status,
+			finding.BlameAuthor,
+			finding.BlameCommitID,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
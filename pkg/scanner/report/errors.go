@@ -0,0 +1,12 @@
+package report
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgReportWrite     = "failed to write report"
+	ErrMsgReportWriteFile = "failed to write report file %s"
+)
+
+var (
+	ErrReportFormatInvalid = errors.New("invalid report format")
+)
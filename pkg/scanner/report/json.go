@@ -0,0 +1,13 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// writeJSON() method renders the report as indented JSON.
+func (report *Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
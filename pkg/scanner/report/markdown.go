@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// markdownEscaper escapes characters that would break a cell of a Markdown
+// table.
+var markdownEscaper = strings.NewReplacer(
+	"|", "\\|",
+	"\r", " ",
+	"\n", " ",
+)
+
+// writeMarkdown() method renders the report as a human-readable Markdown
+// summary, followed by the findings grouped by file and category.
+func (report *Report) writeMarkdown(w io.Writer) error {
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "# PHI/PII Scan Report : %s\n\n", report.Repository)
+	fmt.Fprintf(b, "- Generated at: %s\n", report.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(b, "- Total findings: %d\n", report.Total)
+	if report.Masked {
+		fmt.Fprintf(b, "- Detected text is masked\n")
+	} else {
+		fmt.Fprintf(b, "- **Detected text is NOT masked**\n")
+	}
+
+	if report.Total == 0 {
+		fmt.Fprintf(b, "\nNo PHI/PII was detected.\n")
+		return b.Flush()
+	}
+
+	categories, counts := report.Categories()
+	fmt.Fprintf(b, "\n## Summary\n\n")
+	fmt.Fprintf(b, "| Category | Findings |\n")
+	fmt.Fprintf(b, "| --- | ---: |\n")
+	for _, category := range categories {
+		fmt.Fprintf(b, "| %s | %d |\n", markdownEscaper.Replace(category), counts[category])
+	}
+
+	if statuses, status_counts := report.Statuses(); len(statuses) > 1 || statuses[0] != "" {
+		fmt.Fprintf(b, "\n| Status | Findings |\n")
+		fmt.Fprintf(b, "| --- | ---: |\n")
+		for _, status := range statuses {
+			name := status
+			if name == "" {
+				name = "-"
+			}
+			fmt.Fprintf(b, "| %s | %d |\n", markdownEscaper.Replace(name), status_counts[status])
+		}
+	}
+
+	fmt.Fprintf(b, "\n## Findings\n")
+	file := ""
+	for i, group := range report.Groups {
+		if i == 0 || group.File != file {
+			file = group.File
+			fmt.Fprintf(b, "\n### `%s`\n", file)
+		}
+		fmt.Fprintf(b, "\n#### %s (%d)\n\n", markdownEscaper.Replace(group.Category), len(group.Findings))
+		fmt.Fprintf(b, "| Commit | Status | Line | Offset | Length | Confidence | Subcategory | Text |\n")
+		fmt.Fprintf(b, "| --- | --- | ---: | ---: | ---: | ---: | --- | --- |\n")
+		for _, finding := range group.Findings {
+			fmt.Fprintf(
+				b,
+				"| %s | %s | %s | %d | %d | %.2f | %s | %s |\n",
+				markdownEscaper.Replace(finding.CommitID),
+				markdownStatus(finding),
+				markdownLine(finding),
+				finding.Offset,
+				finding.Length,
+				finding.ConfidenceScore,
+				markdownEscaper.Replace(finding.Subcategory),
+				markdownEscaper.Replace(finding.Text),
+			)
+		}
+	}
+	return b.Flush()
+}
+
+// markdownLine() function returns the "line:column" of the start of the
+// finding, or "-" if the line is unknown.
+func markdownLine(finding Finding) string {
+	if finding.Line == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", finding.Line, finding.Column)
+}
+
+// markdownStatus() function returns the status of the finding, including the
+// author of the line of findings present at the tip, or "-" if the status is
+// unknown.
+func markdownStatus(finding Finding) string {
+	if finding.Status == "" {
+		return "-"
+	}
+	if finding.BlameAuthor != "" {
+		return markdownEscaper.Replace(finding.Status + " (" + finding.BlameAuthor + ")")
+	}
+	return markdownEscaper.Replace(finding.Status)
+}
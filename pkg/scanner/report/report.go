@@ -0,0 +1,258 @@
+package report
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// Finding struct contains a single result record in a form that is suitable
+// for reports, where the offset is relative to the start of the file. The
+// (1-based) lines and columns are 0 when they are unknown, and EndColumn is
+// the column immediately after the last character of the finding.
+type Finding struct {
+	BlameAuthor     string  `json:"blameAuthor,omitempty"`
+	BlameCommitID   string  `json:"blameCommitId,omitempty"`
+	Category        string  `json:"category"`
+	Column          int     `json:"column,omitempty"`
+	CommitID        string  `json:"commitId"`
+	ConfidenceScore float64 `json:"confidenceScore"`
+	EndColumn       int     `json:"endColumn,omitempty"`
+	EndLine         int     `json:"endLine,omitempty"`
+	File            string  `json:"file"`
+	Hash            string  `json:"hash"`
+	Length          int     `json:"length"`
+	Line            int     `json:"line,omitempty"`
+	ObjectID        string  `json:"objectId"`
+	Offset          int     `json:"offset"`
+	Service         string  `json:"service"`
+	Status          string  `json:"status,omitempty"`
+	Subcategory     string  `json:"subcategory"`
+	Text            string  `json:"text"`
+}
+
+// Group struct contains all findings of a single category in a single file.
+type Group struct {
+	Category string    `json:"category"`
+	File     string    `json:"file"`
+	Findings []Finding `json:"findings"`
+}
+
+// Report struct contains the findings of a scan of a single repository,
+// grouped by file and category.
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Groups      []Group   `json:"groups"`
+	Masked      bool      `json:"masked"`
+	Repository  string    `json:"repository"`
+	Total       int       `json:"total"`
+}
+
+// NewReport() function creates a new Report from the result records of the
+// repository. The detected text of each finding is masked, unless unmask is
+// true.
+func NewReport(repository string, records []rrr.ResultRecord, unmask bool) *Report {
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Groups:      make([]Group, 0),
+		Masked:      !unmask,
+		Repository:  repository,
+		Total:       len(records),
+	}
+
+	index := make(map[string]int)
+	for _, r := range records {
+		finding := Finding{
+			Category:        r.Category,
+			Column:          r.Location.StartColumn,
+			CommitID:        r.Commit.ID,
+			ConfidenceScore: r.ConfidenceScore,
+			EndColumn:       r.Location.EndColumn,
+			EndLine:         r.Location.EndLine,
+			File:            findingFile(r),
+			Hash:            r.Hash,
+			Length:          r.Length,
+			Line:            r.Location.StartLine,
+			ObjectID:        r.Object.ID,
+			Offset:          r.Object.Offset + r.Offset,
+			Service:         r.Service,
+			Subcategory:     r.Subcategory,
+			Text:            r.Text,
+		}
+		if r.Head != nil {
+			finding.Status = r.Head.Status
+			if r.Head.Blame != nil {
+				finding.BlameAuthor = r.Head.Blame.AuthorEmail
+				finding.BlameCommitID = r.Head.Blame.CommitID
+			}
+		}
+		if report.Masked {
+			finding.Text = MaskText(finding.Text)
+		}
+
+		key := finding.File + rrr.ResultSeparatorUID + finding.Category
+		i, exists := index[key]
+		if !exists {
+			i = len(report.Groups)
+			index[key] = i
+			report.Groups = append(report.Groups, Group{
+				Category: finding.Category,
+				File:     finding.File,
+				Findings: make([]Finding, 0),
+			})
+		}
+		report.Groups[i].Findings = append(report.Groups[i].Findings, finding)
+	}
+
+	// sort the groups and findings such that reports are deterministic
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].File == report.Groups[j].File {
+			return report.Groups[i].Category < report.Groups[j].Category
+		}
+		return report.Groups[i].File < report.Groups[j].File
+	})
+	for _, group := range report.Groups {
+		sort.SliceStable(group.Findings, func(i, j int) bool {
+			if group.Findings[i].Offset == group.Findings[j].Offset {
+				return group.Findings[i].Hash < group.Findings[j].Hash
+			}
+			return group.Findings[i].Offset < group.Findings[j].Offset
+		})
+	}
+	return report
+}
+
+// Categories() method returns the sorted categories of all findings in the
+// report, mapped to the number of findings in each category.
+func (report *Report) Categories() ([]string, map[string]int) {
+	counts := make(map[string]int)
+	for _, group := range report.Groups {
+		counts[group.Category] += len(group.Findings)
+	}
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories, counts
+}
+
+// Statuses() method returns the sorted statuses of all findings in the
+// report, mapped to the number of findings with each status, where findings
+// that were not classified have an empty status.
+func (report *Report) Statuses() ([]string, map[string]int) {
+	counts := make(map[string]int)
+	for _, group := range report.Groups {
+		for _, finding := range group.Findings {
+			counts[finding.Status]++
+		}
+	}
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return statuses, counts
+}
+
+// Findings() method returns all findings in the report, in the order of
+// their groups.
+func (report *Report) Findings() []Finding {
+	findings := make([]Finding, 0, report.Total)
+	for _, group := range report.Groups {
+		findings = append(findings, group.Findings...)
+	}
+	return findings
+}
+
+// Write() method renders the report in the given format to the writer.
+func (report *Report) Write(w io.Writer, format string) error {
+	var err error
+	switch format {
+	case cfg.ReportFormatCSV:
+		err = report.writeCSV(w)
+	case cfg.ReportFormatJSON:
+		err = report.writeJSON(w)
+	case cfg.ReportFormatMarkdown:
+		err = report.writeMarkdown(w)
+	case cfg.ReportFormatSARIF:
+		err = report.writeSARIF(w)
+	default:
+		return errors.Wrapf(ErrReportFormatInvalid, "format = %s", format)
+	}
+	if err != nil {
+		return errors.Wrap(err, ErrMsgReportWrite)
+	}
+	return nil
+}
+
+// WriteFiles() method renders the report in each of the given formats to a
+// file "<dir>/<name><ext>", where the extension is defined by the format in
+// the FileExtensions map. Returns the paths of the written files.
+func (report *Report) WriteFiles(dir, name string, formats []string) ([]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, ErrMsgReportWriteFile, dir)
+	}
+	paths := make([]string, 0, len(formats))
+	for _, format := range formats {
+		extension, exists := FileExtensions[format]
+		if !exists {
+			return paths, errors.Wrapf(ErrReportFormatInvalid, "format = %s", format)
+		}
+		path := filepath.Join(dir, name+extension)
+		if err := report.writeFile(path, format); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeFile() method renders the report in the given format to the file at
+// the given path, replacing any existing file.
+func (report *Report) writeFile(path, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgReportWriteFile, path)
+	}
+	if err := report.Write(file, format); err != nil {
+		file.Close()
+		return errors.Wrapf(err, ErrMsgReportWriteFile, path)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, ErrMsgReportWriteFile, path)
+	}
+	return nil
+}
+
+// MaskText() function replaces every non-whitespace character of the text
+// with the MaskRune, such that reports do not expose the detected PHI/PII.
+func MaskText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return r
+		}
+		return MaskRune
+	}, text)
+}
+
+// findingFile() function returns the path of the file in which the result
+// of the record was found, or the ID of the object if the path is unknown.
+func findingFile(r rrr.ResultRecord) string {
+	if r.Location.Path != "" {
+		return r.Location.Path
+	}
+	if r.Object.Path != "" {
+		return r.Object.Path
+	}
+	return r.Object.ID
+}
@@ -0,0 +1,288 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+const testRepository string = "https://github.com/org/repo.git"
+
+// testResultRecords() helper function returns result records in two files
+// for use in tests.
+func testResultRecords() []rrr.ResultRecord {
+	record := func(hash, object_id, category, text string, object_offset, offset int) rrr.ResultRecord {
+		return rrr.ResultRecord{
+			Hash: hash,
+			MetadataRequestResponse: rrr.MetadataRequestResponse{
+				Commit:     rrr.MetadataRequestResponseCommit{ID: "commit-1"},
+				Object:     rrr.MetadataRequestResponseObject{ID: object_id, Offset: object_offset},
+				Repository: rrr.MetadataRequestResponseRepository{ID: testRepository},
+			},
+			Result: rrr.Result{
+				Category:        category,
+				ConfidenceScore: 0.95,
+				Length:          len(text),
+				Offset:          offset,
+				Service:         "test",
+				Text:            text,
+			},
+		}
+	}
+	return []rrr.ResultRecord{
+		record("hash-1", "object-b", "Email", "jane@example.com", 0, 4),
+		record("hash-2", "object-a", "Person", "Jane Doe", 100, 20),
+		record("hash-3", "object-a", "Email", "jd@example.com", 100, 0),
+		record("hash-4", "object-a", "Person", "John | Doe", 0, 10),
+	}
+}
+
+// TestNewReport() unit test function tests the grouping, ordering, and
+// masking of findings by the NewReport() function.
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+
+	r := NewReport(testRepository, testResultRecords(), false)
+	assert.True(t, r.Masked)
+	assert.Equal(t, 4, r.Total)
+	if !assert.Len(t, r.Groups, 3) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, Group{Category: "Email", File: "object-a"}, Group{Category: r.Groups[0].Category, File: r.Groups[0].File})
+	assert.Equal(t, "object-a", r.Groups[1].File)
+	assert.Equal(t, "Person", r.Groups[1].Category)
+	assert.Equal(t, "object-b", r.Groups[2].File)
+
+	// findings are sorted by their offset relative to the start of the file
+	person := r.Groups[1].Findings
+	if assert.Len(t, person, 2) {
+		assert.Equal(t, "hash-4", person[0].Hash)
+		assert.Equal(t, 10, person[0].Offset)
+		assert.Equal(t, "hash-2", person[1].Hash)
+		assert.Equal(t, 120, person[1].Offset)
+		assert.Equal(t, "**** ***", person[1].Text)
+	}
+
+	categories, counts := r.Categories()
+	assert.Equal(t, []string{"Email", "Person"}, categories)
+	assert.Equal(t, map[string]int{"Email": 2, "Person": 2}, counts)
+
+	unmasked := NewReport(testRepository, testResultRecords(), true)
+	assert.False(t, unmasked.Masked)
+	assert.Equal(t, "jd@example.com", unmasked.Groups[0].Findings[0].Text)
+}
+
+// TestMaskText() unit test function tests the MaskText() function.
+func TestMaskText(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", MaskText(""))
+	assert.Equal(t, "***********", MaskText("123-45-6789"))
+	assert.Equal(t, "*** **", MaskText("Zoë Li"))
+}
+
+// TestReport_Write() unit test function tests the rendering of the report
+// in each format.
+func TestReport_Write(t *testing.T) {
+	t.Parallel()
+
+	r := NewReport(testRepository, testResultRecords(), false)
+	r.GeneratedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run(cfg.ReportFormatCSV, func(t *testing.T) {
+		var buffer bytes.Buffer
+		if !assert.NoError(t, r.Write(&buffer, cfg.ReportFormatCSV)) {
+			return
+		}
+		rows, err := csv.NewReader(&buffer).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, rows, 5) {
+			assert.Equal(t, CSVHeader, rows[0])
+			assert.Equal(t, []string{
+				testRepository, "object-a", "object-a", "commit-1", "Email", "",
+				"0.95", "0", "0", "100", "14", "test", "**************", "hash-3", "", "", "",
+			}, rows[1])
+		}
+	})
+
+	t.Run(cfg.ReportFormatJSON, func(t *testing.T) {
+		var buffer bytes.Buffer
+		if !assert.NoError(t, r.Write(&buffer, cfg.ReportFormatJSON)) {
+			return
+		}
+		var decoded Report
+		assert.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded))
+		assert.Equal(t, *r, decoded)
+	})
+
+	t.Run(cfg.ReportFormatMarkdown, func(t *testing.T) {
+		var buffer bytes.Buffer
+		if !assert.NoError(t, r.Write(&buffer, cfg.ReportFormatMarkdown)) {
+			return
+		}
+		markdown := buffer.String()
+		assert.Contains(t, markdown, "# PHI/PII Scan Report : "+testRepository)
+		assert.Contains(t, markdown, "- Generated at: 2024-01-02T03:04:05Z")
+		assert.Contains(t, markdown, "| Email | 2 |")
+		assert.Contains(t, markdown, "### `object-a`")
+		assert.Contains(t, markdown, "#### Person (2)")
+		assert.Contains(t, markdown, "| commit-1 | - | - | 10 | 10 | 0.95 |  | **** * *** |")
+		assert.NotContains(t, markdown, "Jane")
+		assert.NotContains(t, markdown, "| Status | Findings |")
+
+		// pipes in the detected text are escaped when the text is not masked
+		buffer.Reset()
+		unmasked := NewReport(testRepository, testResultRecords(), true)
+		assert.NoError(t, unmasked.Write(&buffer, cfg.ReportFormatMarkdown))
+		assert.Contains(t, buffer.String(), "| commit-1 | - | - | 10 | 10 | 0.95 |  | John \\| Doe |")
+	})
+
+	t.Run(cfg.ReportFormatSARIF, func(t *testing.T) {
+		var buffer bytes.Buffer
+		if !assert.NoError(t, r.Write(&buffer, cfg.ReportFormatSARIF)) {
+			return
+		}
+		var decoded sarifLog
+		if !assert.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded)) {
+			return
+		}
+		assert.Equal(t, SARIFVersion, decoded.Version)
+		if !assert.Len(t, decoded.Runs, 1) {
+			return
+		}
+		run := decoded.Runs[0]
+		assert.Equal(t, cfg.DefaultAppName, run.Tool.Driver.Name)
+		if assert.Len(t, run.Tool.Driver.Rules, 2) {
+			assert.Equal(t, SARIFRuleIDPrefix+"Email", run.Tool.Driver.Rules[0].ID)
+			assert.Equal(t, SARIFRuleIDPrefix+"Person", run.Tool.Driver.Rules[1].ID)
+		}
+		if assert.Len(t, run.Results, 4) {
+			result := run.Results[1]
+			assert.Equal(t, SARIFRuleIDPrefix+"Person", result.RuleID)
+			assert.Equal(t, 1, result.RuleIndex)
+			assert.Equal(t, "hash-4", result.PartialFingerprints[SARIFFingerprintKey])
+			// the path of the object is unknown, so the object is only a
+			// logical location instead of an artifact location
+			assert.Nil(t, result.Locations[0].PhysicalLocation)
+			assert.Equal(t, []sarifLogicalLocation{{
+				FullyQualifiedName: "object-a",
+				Kind:               SARIFLogicalLocationKind,
+			}}, result.Locations[0].LogicalLocations)
+			assert.Equal(t, "object-a", result.Properties["objectId"])
+			assert.Equal(t, float64(10), result.Properties["charOffset"])
+			assert.Equal(t, float64(10), result.Properties["charLength"])
+			assert.NotContains(t, buffer.String(), `"uri": "object-a"`)
+		}
+	})
+
+	assert.ErrorIs(t, r.Write(&bytes.Buffer{}, "invalid"), ErrReportFormatInvalid)
+}
+
+// TestReport_Write_Location() unit test function tests that findings are
+// reported with the path, line, and column of the location of the result.
+func TestReport_Write_Location(t *testing.T) {
+	t.Parallel()
+
+	records := testResultRecords()[:1]
+	records[0].Object.Path = "docs/contacts.md"
+	records[0].Head = &rrr.ResultRecordHead{
+		Blame:    &rrr.ResultRecordBlame{AuthorEmail: "dev@example.com", CommitID: "commit-0"},
+		CommitID: "commit-2",
+		Line:     3,
+		Path:     "docs/contacts.md",
+		Status:   rrr.HeadStatusPresentAtHead,
+	}
+	records[0].Location = rrr.Location{
+		EndColumn:   21,
+		EndLine:     3,
+		Offset:      4,
+		Path:        "docs/contacts.md",
+		StartColumn: 5,
+		StartLine:   3,
+	}
+	r := NewReport(testRepository, records, false)
+	findings := r.Findings()
+	if !assert.Len(t, findings, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "docs/contacts.md", findings[0].File)
+	assert.Equal(t, "object-b", findings[0].ObjectID)
+	assert.Equal(t, 3, findings[0].Line)
+	assert.Equal(t, 5, findings[0].Column)
+	assert.Equal(t, rrr.HeadStatusPresentAtHead, findings[0].Status)
+	assert.Equal(t, "dev@example.com", findings[0].BlameAuthor)
+	assert.Equal(t, "commit-0", findings[0].BlameCommitID)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, r.Write(&buffer, cfg.ReportFormatMarkdown))
+	assert.Contains(t, buffer.String(), "### `docs/contacts.md`")
+	assert.Contains(t, buffer.String(), "| present_at_head | 1 |")
+	assert.Contains(t, buffer.String(), "| commit-1 | present_at_head (dev@example.com) | 3:5 | 4 | 16 | 0.95 |")
+
+	buffer.Reset()
+	assert.NoError(t, r.Write(&buffer, cfg.ReportFormatSARIF))
+	var decoded sarifLog
+	if assert.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded)) {
+		location := decoded.Runs[0].Results[0].Locations[0].PhysicalLocation
+		if !assert.NotNil(t, location) {
+			return
+		}
+		assert.Empty(t, decoded.Runs[0].Results[0].Locations[0].LogicalLocations)
+		assert.Equal(t, "docs/contacts.md", location.ArtifactLocation.URI)
+		assert.Equal(t, 3, location.Region.StartLine)
+		assert.Equal(t, 5, location.Region.StartColumn)
+		assert.Equal(t, 3, location.Region.EndLine)
+		assert.Equal(t, 21, location.Region.EndColumn)
+		assert.Equal(t, rrr.HeadStatusPresentAtHead, decoded.Runs[0].Results[0].Properties["status"])
+		assert.Equal(t, "commit-0", decoded.Runs[0].Results[0].Properties["blameCommitId"])
+	}
+}
+
+// TestReport_Write_Empty() unit test function tests the Markdown rendering
+// of a report without findings.
+func TestReport_Write_Empty(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	r := NewReport(testRepository, nil, true)
+	assert.NoError(t, r.Write(&buffer, cfg.ReportFormatMarkdown))
+	assert.Contains(t, buffer.String(), "No PHI/PII was detected.")
+	assert.Contains(t, buffer.String(), "**Detected text is NOT masked**")
+}
+
+// TestReport_WriteFiles() unit test function tests that a file is written
+// for each report format.
+func TestReport_WriteFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "reports")
+	r := NewReport(testRepository, testResultRecords(), false)
+
+	paths, err := r.WriteFiles(dir, "org_repo", cfg.DefaultReportFormats)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "org_repo.csv"),
+		filepath.Join(dir, "org_repo.json"),
+		filepath.Join(dir, "org_repo.md"),
+		filepath.Join(dir, "org_repo.sarif"),
+	}, paths)
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, strings.TrimSpace(string(content)))
+	}
+
+	_, err = r.WriteFiles(dir, "org_repo", []string{"invalid"})
+	assert.ErrorIs(t, err, ErrReportFormatInvalid)
+}
@@ -0,0 +1,184 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+)
+
+// The sarif* struct types contain the subset of the SARIF 2.1.0 format that
+// is used by reports, which is sufficient for upload to GitHub code scanning.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+// sarifLocation struct has either a physical location, when the path of the
+// file of the finding is known, or else a logical location that identifies
+// the object (i.e. file blob) of the finding.
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine,omitempty"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndLine     int           `json:"endLine,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	CharOffset  int           `json:"charOffset"`
+	CharLength  int           `json:"charLength"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// writeSARIF() method renders the report as a SARIF 2.1.0 log with a single
+// run, where each category is a rule and each finding is a result.
+func (report *Report) writeSARIF(w io.Writer) error {
+	categories, _ := report.Categories()
+	rule_index := make(map[string]int)
+	rules := make([]sarifRule, 0, len(categories))
+	for i, category := range categories {
+		rule_index[category] = i
+		rules = append(rules, sarifRule{
+			ID:   SARIFRuleIDPrefix + category,
+			Name: category,
+			ShortDescription: sarifMessage{
+				Text: fmt.Sprintf("Potential PHI/PII detected: %s", category),
+			},
+		})
+	}
+
+	results := make([]sarifResult, 0, report.Total)
+	for _, finding := range report.Findings() {
+		result := sarifResult{
+			RuleID:    SARIFRuleIDPrefix + finding.Category,
+			RuleIndex: rule_index[finding.Category],
+			Level:     SARIFLevel,
+			Message: sarifMessage{
+				Text: fmt.Sprintf(
+					"Potential PHI/PII detected: %s (confidence %.2f)",
+					finding.Category,
+					finding.ConfidenceScore,
+				),
+			},
+			PartialFingerprints: map[string]string{SARIFFingerprintKey: finding.Hash},
+			Properties: map[string]any{
+				"commitId":        finding.CommitID,
+				"confidenceScore": finding.ConfidenceScore,
+				"objectId":        finding.ObjectID,
+				"service":         finding.Service,
+			},
+		}
+		if finding.File != "" && finding.File != finding.ObjectID {
+			location := &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: finding.File},
+				Region: sarifRegion{
+					StartLine:   finding.Line,
+					StartColumn: finding.Column,
+					EndLine:     finding.EndLine,
+					EndColumn:   finding.EndColumn,
+					CharOffset:  finding.Offset,
+					CharLength:  finding.Length,
+				},
+			}
+			if finding.Text != "" {
+				location.Region.Snippet = &sarifMessage{Text: finding.Text}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		} else {
+			// the ID of the object is not a path, so it is not an artifact
+			// location, e.g. of a file that was only scanned by its blob
+			result.Locations = []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{
+					FullyQualifiedName: finding.ObjectID,
+					Kind:               SARIFLogicalLocationKind,
+				}},
+			}}
+			result.Properties["charOffset"] = finding.Offset
+			result.Properties["charLength"] = finding.Length
+		}
+		if finding.Subcategory != "" {
+			result.Properties["subcategory"] = finding.Subcategory
+		}
+		if finding.Status != "" {
+			result.Properties["status"] = finding.Status
+		}
+		if finding.BlameCommitID != "" {
+			result.Properties["blameAuthor"] = finding.BlameAuthor
+			result.Properties["blameCommitId"] = finding.BlameCommitID
+		}
+		results = append(results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           cfg.DefaultAppName,
+					InformationURI: InformationURI,
+					Version:        cfg.AppVersion,
+					Rules:          rules,
+				},
+			},
+			Results: results,
+		}},
+	})
+}
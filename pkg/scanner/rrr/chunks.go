@@ -2,7 +2,10 @@ package rrr
 
 import (
 	"bufio"
+	"io"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/pkg/errors"
@@ -20,6 +23,11 @@ type ChunkFileInput struct {
 // ChunkFileToRequests() function reads the input object.File and
 // generates a slice of requests, where the text in each request is
 // limited to MaxChunkSize characters.
+//
+// The text of each request is an exact substring of the file, such that the
+// offset, line, and column of each request (and each result) can be mapped
+// back to the file. Offsets are counted in characters (code points) and
+// include line breaks.
 func ChunkFileToRequests(in ChunkFileInput) (requests []Request, e error) {
 	if in.File == nil {
 		e = ErrChunkFileToRequestsInFileNil
@@ -37,93 +45,110 @@ func ChunkFileToRequests(in ChunkFileInput) (requests []Request, e error) {
 		e = errors.Wrapf(err, ErrMsgScanFileRequestsGenerate, in.File.Hash.String())
 		return
 	}
+	defer file_reader.Close()
 
-	file_scanner := bufio.NewScanner(file_reader)
-	file_scanner.Split(bufio.ScanLines)
+	reader := bufio.NewReader(file_reader)
 
-	var current_offset int
-	var current_text string
+	// state of the current chunk of the file, which is made up of one or
+	// more whole lines
+	var chunk_line int
+	var chunk_offset int
+	var chunk_text string
+	// line break (e.g. "\n" or "\r\n") at the end of the last line of the
+	// current chunk, which is only included if another line is added
+	var chunk_line_break string
 
-	for file_scanner.Scan() {
-		line := file_scanner.Text()
-		next_length := len(line) + len(current_text) + len("\n")
+	// position of the start of the current line within the file
+	var line_number int
+	var line_offset int
 
-		if next_length < in.MaxChunkSize {
-			if current_text == "" {
-				current_text = line
-			} else {
-				current_text += "\n" + line
-			}
-			continue
-		}
-
-		if len(current_text) == 0 {
-			continue
+	flush := func() error {
+		if chunk_text == "" {
+			return nil
 		}
-
-		// create a request from the current_text
 		request, err := NewRequest(NewRequestInput{
+			Column:   1,
 			CommitID: in.CommitID,
-			Length:   len(current_text),
+			Length:   runeCount(chunk_text),
+			Line:     chunk_line,
 			ObjectID: in.File.ID().String(),
-			Offset:   current_offset,
+			Offset:   chunk_offset,
+			Path:     in.File.Name,
 			RepoID:   in.RepoID,
-			Text:     current_text,
+			Text:     chunk_text,
 		})
 		if err != nil {
-			e = errors.Wrap(err, ErrMsgScanFileRequestsGenerate)
-			return
+			return errors.Wrapf(err, ErrMsgScanFileRequestsGenerate, in.File.Hash.String())
 		}
 		requests = append(requests, request)
-		// increment the current_offset by the length of the current_text
-		current_offset += len(current_text)
-		// reset the current_text variable to prepare for the next
-		// chunk of the file
-		current_text = ""
-
-		if len(line) < in.MaxChunkSize {
-			current_text = line
-			continue
-		}
-		// chunk the line into smaller pieces of MaxChunkSize
-		var line_requests []Request
-		var req_err error
-		// pass the current_offset to the ChunkLineToRequests() function
-		// and get back the updated current_offset, a slice of requests
-		// generated just for this line, and any error in generating the
-		// requests
-		current_offset, line_requests, req_err = ChunkLineToRequests(ChunkLineInput{
-			CommitID:     in.CommitID,
-			Line:         line,
-			MaxChunkSize: in.MaxChunkSize,
-			ObjectID:     in.File.ID().String(),
-			Offset:       current_offset,
-			RepoID:       in.RepoID,
-		})
-		if req_err != nil {
-			e = errors.Wrap(req_err, ErrMsgScanFileRequestsGenerate)
+		chunk_text = ""
+		chunk_line_break = ""
+		return nil
+	}
+
+	for {
+		raw, read_err := reader.ReadString('\n')
+		if read_err != nil && read_err != io.EOF {
+			e = errors.Wrapf(read_err, ErrMsgScanFileRequestsGenerate, in.File.Hash.String())
 			return
 		}
-		requests = append(requests, line_requests...)
-		continue
+		if raw == "" {
+			break
+		}
+		line_number++
+		line := strings.TrimRight(raw, "\r\n")
+		line_break := raw[len(line):]
+
+		next_length := len(chunk_text) + len(chunk_line_break) + len(line)
+		switch {
+		case chunk_text != "" && next_length < in.MaxChunkSize:
+			// add the line to the current chunk
+			chunk_text += chunk_line_break + line
+			chunk_line_break = line_break
+		default:
+			if e = flush(); e != nil {
+				return
+			}
+			if line == "" {
+				// empty lines never start a new chunk
+				break
+			}
+			if len(line) < in.MaxChunkSize {
+				// start a new chunk with the line
+				chunk_line = line_number
+				chunk_offset = line_offset
+				chunk_text = line
+				chunk_line_break = line_break
+				break
+			}
+			// chunk the line into smaller pieces of MaxChunkSize
+			_, line_requests, req_err := ChunkLineToRequests(ChunkLineInput{
+				CommitID:     in.CommitID,
+				Line:         line,
+				LineNumber:   line_number,
+				MaxChunkSize: in.MaxChunkSize,
+				ObjectID:     in.File.ID().String(),
+				Offset:       line_offset,
+				Path:         in.File.Name,
+				RepoID:       in.RepoID,
+			})
+			if req_err != nil {
+				e = errors.Wrap(req_err, ErrMsgScanFileRequestsGenerate)
+				return
+			}
+			requests = append(requests, line_requests...)
+		}
+
+		// advance to the start of the next line, including the line break
+		line_offset += runeCount(raw)
+		if read_err == io.EOF {
+			break
+		}
 	}
 
 	// ensure that the last chunk of the file is included in the requests
-	if current_text != "" {
-		// create a new request for the remaining text
-		request, err := NewRequest(NewRequestInput{
-			CommitID: in.CommitID,
-			Length:   len(current_text),
-			ObjectID: in.File.ID().String(),
-			Offset:   current_offset,
-			RepoID:   in.RepoID,
-			Text:     current_text,
-		})
-		if err != nil {
-			e = err
-			return
-		}
-		requests = append(requests, request)
+	if e = flush(); e != nil {
+		return
 	}
 
 	// validate that the chunking process produced requests if the file
@@ -139,17 +164,25 @@ func ChunkFileToRequests(in ChunkFileInput) (requests []Request, e error) {
 // ChunkLineInput struct contains the input parameters required for the
 // ChunkLineToRequests() function.
 type ChunkLineInput struct {
-	CommitID     string
-	Line         string
+	CommitID string
+	Line     string
+	// LineNumber is the (1-based) line number of the line within the file,
+	// or 0 if the line number is unknown.
+	LineNumber   int
 	MaxChunkSize int
 	ObjectID     string
-	Offset       int
-	RepoID       string
+	// Offset is the character (code point) offset of the start of the line
+	// within the file.
+	Offset int
+	Path   string
+	RepoID string
 }
 
 // ChunkLineToRequests() function chunks the input line (string) of text
 // into smaller pieces of MaxChunkSize and sends requests to the channel
-// for processing.
+// for processing. Lines are split between words where possible, where the
+// text of each request is an exact substring of the line. Returns the offset
+// of the end of the line.
 func ChunkLineToRequests(in ChunkLineInput) (offset int, requests []Request, e error) {
 	offset = in.Offset
 
@@ -161,65 +194,122 @@ func ChunkLineToRequests(in ChunkLineInput) (offset int, requests []Request, e e
 		return
 	}
 
-	line_reader := strings.NewReader(in.Line)
-	// scan the words of the line
-	line_scanner := bufio.NewScanner(line_reader)
-	line_scanner.Split(bufio.ScanWords)
+	// position of the start of the current piece of the line
+	piece_start := -1
+	piece_end := 0
 
-	var current_text string
-
-	for line_scanner.Scan() {
-		word := line_scanner.Text()
-		next_length := len(current_text) + len(" ") + len(word)
-
-		if next_length < in.MaxChunkSize {
-			if current_text == "" {
-				current_text = word
-			} else {
-				current_text += (" " + word)
+	// position of the character at byte index `position` within the file,
+	// which is advanced through the line as pieces are created
+	position := 0
+	line := in.LineNumber
+	column := 1
+	char_offset := in.Offset
+	advance := func(to int) {
+		for _, c := range in.Line[position:to] {
+			char_offset++
+			column++
+			if c == '\n' {
+				line++
+				column = 1
 			}
-			continue
 		}
+		position = to
+	}
 
-		// create a new request when current text is within a word of
-		// the MaxChunkSize
+	emit := func(start, end int) error {
+		advance(start)
+		text := in.Line[start:end]
 		request, err := NewRequest(NewRequestInput{
+			Column:   column,
 			CommitID: in.CommitID,
-			Length:   len(current_text),
+			Length:   runeCount(text),
+			Line:     line,
 			ObjectID: in.ObjectID,
-			Offset:   offset,
+			Offset:   char_offset,
+			Path:     in.Path,
 			RepoID:   in.RepoID,
-			Text:     current_text,
+			Text:     text,
 		})
 		if err != nil {
-			e = err
-			return
+			return err
+		}
+		if in.LineNumber == 0 {
+			request.Object.Column = 0
+			request.Object.Line = 0
 		}
 		requests = append(requests, request)
-		// increment the offset by the length of the current_text
-		offset += len(current_text) + len(" ")
-		// reset the current_text to the value of the current word
-		current_text = word
+		return nil
 	}
 
-	if current_text != "" {
-		// create a new request for the remaining text
-		request, err := NewRequest(NewRequestInput{
-			CommitID: in.CommitID,
-			Length:   len(current_text),
-			ObjectID: in.ObjectID,
-			Offset:   offset,
-			RepoID:   in.RepoID,
-			Text:     current_text,
-		})
-		if err != nil {
-			e = err
+	for _, word := range wordSpans(in.Line) {
+		word_start, word_end := word[0], word[1]
+		if piece_start >= 0 && word_end-piece_start < in.MaxChunkSize {
+			// add the word to the current piece
+			piece_end = word_end
+			continue
+		}
+		if piece_start >= 0 {
+			if e = emit(piece_start, piece_end); e != nil {
+				return
+			}
+		}
+		// split words that are too long for a single piece
+		for word_end-word_start >= in.MaxChunkSize {
+			split := splitIndex(in.Line[word_start:word_end], in.MaxChunkSize-1) + word_start
+			if e = emit(word_start, split); e != nil {
+				return
+			}
+			word_start = split
+		}
+		piece_start, piece_end = word_start, word_end
+	}
+	if piece_start >= 0 {
+		if e = emit(piece_start, piece_end); e != nil {
 			return
 		}
-		requests = append(requests, request)
-		// increment the offset by the length of the current_text
-		offset += len(current_text)
 	}
 
+	offset = in.Offset + runeCount(in.Line)
 	return
 }
+
+// splitIndex() function returns the largest byte index of a character
+// boundary within the text that is not greater than max_size, and is at
+// least the size of the first character.
+func splitIndex(text string, max_size int) int {
+	if max_size >= len(text) {
+		return len(text)
+	}
+	i := max_size
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	if i == 0 {
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+	}
+	return i
+}
+
+// wordSpans() function returns the start and end byte indexes of each word
+// in the text, where words are separated by whitespace.
+func wordSpans(text string) [][2]int {
+	spans := make([][2]int, 0)
+	start := -1
+	for i, c := range text {
+		if unicode.IsSpace(c) {
+			if start >= 0 {
+				spans = append(spans, [2]int{start, i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
@@ -203,7 +203,8 @@ func TestChunkFileToRequests(t *testing.T) {
 						Object: MetadataRequestResponseObject{
 							ID:     "c8f1d8c61f9da76f4cb49fd86322b6e685dba956",
 							Length: 276,
-							Offset: 428,
+							// 428 characters plus the line break between chunks
+							Offset: 429,
 						},
 						Repository: MetadataRequestResponseRepository{
 							ID:  "https://github.com/git-fixtures/basic.git",
@@ -256,7 +257,8 @@ func TestChunkFileToRequests(t *testing.T) {
 						Object: MetadataRequestResponseObject{
 							ID:     "49c6bb89b17060d7b4deacb7b338fcc6ea2352a9",
 							Length: 99947,
-							Offset: 99997,
+							// 99997 characters plus the line break between chunks
+							Offset: 99998,
 						},
 						Repository: MetadataRequestResponseRepository{
 							ID:  "https://github.com/git-fixtures/basic.git",
@@ -277,7 +279,8 @@ func TestChunkFileToRequests(t *testing.T) {
 						Object: MetadataRequestResponseObject{
 							ID:     "49c6bb89b17060d7b4deacb7b338fcc6ea2352a9",
 							Length: 17901,
-							Offset: 199944,
+							// 99997 + 99947 characters plus the two line breaks
+							Offset: 199946,
 						},
 						Repository: MetadataRequestResponseRepository{
 							ID:  "https://github.com/git-fixtures/basic.git",
@@ -2,6 +2,9 @@ package rrr
 
 const (
 	ErrMsgScanFileRequestsGenerate        = "failed to generate new requests for file %s"
+	HeadStatusOnlyInHistory        string = "only_in_history"
+	HeadStatusPresentAtHead        string = "present_at_head"
+	HeadStatusRemoved              string = "removed"
 	ResultReplaceEmptyElement      string = "###@@@###"
 	ResultSeparatorUID             string = "__"
 )
@@ -10,4 +10,5 @@ var (
 	ErrNewRequestEmptyObjectID      = errors.New("cannot create a new request with an empty object ID")
 	ErrNewRequestEmptyRepositoryID  = errors.New("cannot create a new request with an empty repository ID")
 	ErrNewRequestEmptyText          = errors.New("cannot create a new request with an empty text")
+	ErrResultRecordFilterInvalid    = errors.New("invalid result record filter")
 )
@@ -32,6 +32,10 @@ func TestErrors(t *testing.T) {
 			err:  ErrNewRequestEmptyText,
 			name: "ErrNewRequestEmptyText",
 		},
+		{
+			err:  ErrResultRecordFilterInvalid,
+			name: "ErrResultRecordFilterInvalid",
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,39 @@
+package rrr
+
+// ResultRecordHead struct contains the classification of a result record by
+// whether the result is still present in the tree of the tip (HEAD) commit
+// of the scanned history, which determines how the result is remediated:
+//   - HeadStatusPresentAtHead if the text of the result still exists in a
+//     file at the tip, which can be fixed by a new commit;
+//   - HeadStatusRemoved if a file at one of the paths of the result still
+//     exists at the tip, but no longer contains the text of the result;
+//   - HeadStatusOnlyInHistory if none of the paths of the result exist at the
+//     tip, such that the result only exists in the history.
+//
+// Results that are not present at the tip can only be remediated by
+// rewriting the history of the repository.
+type ResultRecordHead struct {
+	// Blame contains the blame information of the line of the result at the
+	// tip, which is only set if Status is HeadStatusPresentAtHead.
+	Blame *ResultRecordBlame `json:"blame,omitempty"`
+	// CommitID is the ID of the tip commit used for the classification.
+	CommitID string `json:"commitId"`
+	// Line is the (1-based) line of the result within the file at Path at
+	// the tip, or 0 if unknown or not present.
+	Line int `json:"line,omitempty"`
+	// Path is the path of the file at the tip that contains the result, or
+	// the path of the file that no longer contains the result.
+	Path string `json:"path,omitempty"`
+	// Status is one of HeadStatusOnlyInHistory, HeadStatusPresentAtHead, or
+	// HeadStatusRemoved.
+	Status string `json:"status"`
+}
+
+// ResultRecordBlame struct contains the blame information of a single line,
+// i.e. the commit that last changed the line.
+type ResultRecordBlame struct {
+	AuthorEmail string `json:"authorEmail"`
+	AuthorName  string `json:"authorName"`
+	CommitID    string `json:"commitId"`
+	Time        int64  `json:"time"`
+}
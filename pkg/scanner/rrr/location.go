@@ -0,0 +1,76 @@
+package rrr
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Location struct contains the absolute position of a result within a file,
+// where lines and columns are 1-based and columns are counted in characters
+// (code points). The end column is exclusive, i.e. it is the column after
+// the last character of the result. Lines and columns are 0 if unknown.
+type Location struct {
+	EndColumn int `json:"endColumn"`
+	EndLine   int `json:"endLine"`
+	// Offset is the character (code point) offset of the result from the
+	// start of the file.
+	Offset      int    `json:"offset"`
+	Path        string `json:"path"`
+	StartColumn int    `json:"startColumn"`
+	StartLine   int    `json:"startLine"`
+}
+
+// Locate() method returns the absolute Location within the file of the
+// result, whose offset is relative to the text of the associated request.
+func (r *Response) Locate(result Result) Location {
+	location := Location{
+		Offset: r.Object.Offset + result.Offset,
+		Path:   r.Object.Path,
+	}
+	if r.Object.Line == 0 {
+		return location
+	}
+	location.StartLine, location.StartColumn = r.position(result.Offset)
+	if result.Length <= 0 {
+		location.EndLine, location.EndColumn = location.StartLine, location.StartColumn
+		return location
+	}
+	location.EndLine, location.EndColumn = r.position(result.Offset + result.Length - 1)
+	location.EndColumn++
+	return location
+}
+
+// position() method maps an offset within the text of the associated request
+// to the line and column of the same character within the file.
+func (r *Response) position(offset int) (line int, column int) {
+	// count the line breaks at or before the offset
+	i := sort.SearchInts(r.LineBreaks, offset+1)
+	if i == 0 {
+		column = r.Object.Column
+		if column == 0 {
+			column = 1
+		}
+		return r.Object.Line, column + offset
+	}
+	return r.Object.Line + i, offset - r.LineBreaks[i-1] + 1
+}
+
+// lineBreaks() function returns the (code point) offsets of the characters
+// within the text that follow a "\n" line break.
+func lineBreaks(text string) []int {
+	var breaks []int
+	offset := 0
+	for _, c := range text {
+		offset++
+		if c == '\n' {
+			breaks = append(breaks, offset)
+		}
+	}
+	return breaks
+}
+
+// runeCount() function returns the number of characters (code points) in
+// the text.
+func runeCount(text string) int {
+	return utf8.RuneCountInString(text)
+}
@@ -0,0 +1,198 @@
+package rrr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFileObject() helper function returns an object.File with the given
+// path and content for use in tests.
+func testFileObject(t *testing.T, path, content string) *object.File {
+	o := &plumbing.MemoryObject{}
+	o.SetType(plumbing.BlobObject)
+	o.SetSize(int64(len(content)))
+	writer, err := o.Writer()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	writer.Write([]byte(content))
+	assert.NoError(t, writer.Close())
+
+	blob := &object.Blob{}
+	assert.NoError(t, blob.Decode(o))
+	return object.NewFile(path, filemode.Regular, blob)
+}
+
+// TestChunkFileToRequests_Location() unit test function tests that the text
+// of each request is an exact substring of the file at the offset, line, and
+// column of the request, and that results are located within the file.
+func TestChunkFileToRequests_Location(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		"first line with Zoë",
+		"",
+		"second\tline\r",
+		"   indented line",
+		strings.Repeat("wörd ", 12) + "jane.doe@example.com trailing",
+		"",
+		"",
+		"last line",
+	}
+	content := strings.Join(lines, "\n") + "\n"
+	file := testFileObject(t, "dir/test.txt", content)
+
+	requests, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		File:         file,
+		MaxChunkSize: 40,
+		RepoID:       "test_repo",
+	})
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, requests) {
+		t.FailNow()
+	}
+
+	content_runes := []rune(content)
+	content_lines := strings.Split(content, "\n")
+	for _, request := range requests {
+		assert.Equal(t, "dir/test.txt", request.Object.Path)
+		// the text is found at the offset of the request
+		text_runes := []rune(request.Text)
+		assert.Equal(t, len(text_runes), request.Object.Length)
+		assert.Equal(
+			t,
+			request.Text,
+			string(content_runes[request.Object.Offset:request.Object.Offset+len(text_runes)]),
+		)
+		// the first line of the text is found at the line and column of the request
+		first_line := strings.SplitN(request.Text, "\n", 2)[0]
+		line_runes := []rune(content_lines[request.Object.Line-1])
+		column := request.Object.Column - 1
+		assert.Equal(t, first_line, string(line_runes[column:column+len([]rune(first_line))]))
+	}
+
+	// locate the email address within the file
+	var located bool
+	for _, request := range requests {
+		i := strings.Index(request.Text, "jane.doe@example.com")
+		if i < 0 {
+			continue
+		}
+		response := NewResponse(&request)
+		location := response.Locate(Result{
+			Length: len("jane.doe@example.com"),
+			Offset: len([]rune(request.Text[:i])),
+		})
+		assert.Equal(t, Location{
+			EndColumn:   81,
+			EndLine:     5,
+			Offset:      len([]rune(strings.Join(lines[:4], "\n"))) + 1 + 60,
+			Path:        "dir/test.txt",
+			StartColumn: 61,
+			StartLine:   5,
+		}, location)
+		located = true
+	}
+	assert.True(t, located)
+}
+
+// TestResponse_Locate() unit test function tests the Locate() method of the
+// Response struct for results that span several lines.
+func TestResponse_Locate(t *testing.T) {
+	t.Parallel()
+
+	request, err := NewRequest(NewRequestInput{
+		Column:   5,
+		CommitID: "test_commit",
+		Line:     10,
+		ObjectID: "test_object",
+		Offset:   100,
+		Path:     "test.txt",
+		RepoID:   "test_repo",
+		Text:     "abc\r\nZoë Doe\nxyz",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []int{5, 13}, request.LineBreaks)
+	response := NewResponse(&request)
+
+	tests := []struct {
+		expected Location
+		name     string
+		result   Result
+	}{
+		{
+			expected: Location{EndColumn: 7, EndLine: 10, Offset: 100, Path: "test.txt", StartColumn: 5, StartLine: 10},
+			name:     "first line",
+			result:   Result{Length: 2, Offset: 0},
+		},
+		{
+			expected: Location{EndColumn: 8, EndLine: 11, Offset: 105, Path: "test.txt", StartColumn: 1, StartLine: 11},
+			name:     "second line",
+			result:   Result{Length: 7, Offset: 5},
+		},
+		{
+			expected: Location{EndColumn: 3, EndLine: 12, Offset: 109, Path: "test.txt", StartColumn: 5, StartLine: 11},
+			name:     "spans lines",
+			result:   Result{Length: 6, Offset: 9},
+		},
+		{
+			expected: Location{EndColumn: 2, EndLine: 12, Offset: 113, Path: "test.txt", StartColumn: 1, StartLine: 12},
+			name:     "last line",
+			result:   Result{Length: 1, Offset: 13},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, response.Locate(test.result))
+		})
+	}
+
+	// the line and column are unknown if the request has no line
+	response.Object.Line = 0
+	assert.Equal(t, Location{Offset: 105, Path: "test.txt"}, response.Locate(Result{Length: 3, Offset: 5}))
+}
+
+// TestChunkLineToRequests_Location() unit test function tests that pieces
+// of a long line keep their exact text, offset, and column.
+func TestChunkLineToRequests_Location(t *testing.T) {
+	t.Parallel()
+
+	line := "alpha  béta\tgamma " + strings.Repeat("x", 25) + " omega"
+	final_offset, requests, err := ChunkLineToRequests(ChunkLineInput{
+		CommitID:     "test_commit",
+		Line:         line,
+		LineNumber:   3,
+		MaxChunkSize: 12,
+		ObjectID:     "test_object",
+		Offset:       50,
+		RepoID:       "test_repo",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 50+len([]rune(line)), final_offset)
+
+	texts := make([]string, 0)
+	line_runes := []rune(line)
+	for _, request := range requests {
+		texts = append(texts, request.Text)
+		assert.Equal(t, 3, request.Object.Line)
+		assert.Equal(t, request.Object.Offset-50+1, request.Object.Column)
+		start := request.Object.Offset - 50
+		assert.Equal(t, request.Text, string(line_runes[start:start+request.Object.Length]))
+	}
+	assert.Equal(t, []string{
+		"alpha",
+		"béta\tgamma",
+		"xxxxxxxxxxx",
+		"xxxxxxxxxxx",
+		"xxx omega",
+	}, texts)
+}
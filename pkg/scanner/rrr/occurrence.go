@@ -0,0 +1,71 @@
+package rrr
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// NewMetadataCommit() function returns the metadata of the commit that is
+// recorded alongside requests, responses, and result records.
+func NewMetadataCommit(commit *object.Commit) MetadataRequestResponseCommit {
+	if commit == nil {
+		return MetadataRequestResponseCommit{}
+	}
+	return MetadataRequestResponseCommit{
+		AuthorEmail:    commit.Author.Email,
+		AuthorName:     commit.Author.Name,
+		CommitterEmail: commit.Committer.Email,
+		CommitterName:  commit.Committer.Name,
+		ID:             commit.Hash.String(),
+		Message:        strings.TrimSpace(commit.Message),
+		Time:           commit.Committer.When.Unix(),
+	}
+}
+
+// SetOccurrences() method sets the Occurrences of the object to a sorted and
+// de-duplicated copy of the provided occurrences, and sets Object.Paths to the
+// unique paths of those occurrences. Occurrences are sorted by commit time,
+// then commit ID, then path.
+func (m *MetadataRequestResponse) SetOccurrences(occurrences []MetadataRequestResponseOccurrence) {
+	if len(occurrences) == 0 {
+		m.Object.Paths = nil
+		m.Occurrences = nil
+		return
+	}
+
+	sorted := make([]MetadataRequestResponseOccurrence, 0, len(occurrences))
+	seen := make(map[string]bool, len(occurrences))
+	for _, o := range occurrences {
+		key := o.Commit.ID + ResultSeparatorUID + o.Path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		sorted = append(sorted, o)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Commit.Time != sorted[j].Commit.Time {
+			return sorted[i].Commit.Time < sorted[j].Commit.Time
+		}
+		if sorted[i].Commit.ID != sorted[j].Commit.ID {
+			return sorted[i].Commit.ID < sorted[j].Commit.ID
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	paths := make([]string, 0)
+	seen_paths := make(map[string]bool)
+	for _, o := range sorted {
+		if o.Path == "" || seen_paths[o.Path] {
+			continue
+		}
+		seen_paths[o.Path] = true
+		paths = append(paths, o.Path)
+	}
+	sort.Strings(paths)
+
+	m.Object.Paths = paths
+	m.Occurrences = sorted
+}
@@ -0,0 +1,61 @@
+package rrr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewMetadataCommit() unit test function tests the NewMetadataCommit()
+// function.
+func TestNewMetadataCommit(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	commit := &object.Commit{
+		Author:    object.Signature{Email: "author@example.com", Name: "Author", When: when.Add(-time.Hour)},
+		Committer: object.Signature{Email: "committer@example.com", Name: "Committer", When: when},
+		Hash:      plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"),
+		Message:   "Add contacts\n\nLonger description.\n",
+	}
+	assert.Equal(t, MetadataRequestResponseCommit{
+		AuthorEmail:    "author@example.com",
+		AuthorName:     "Author",
+		CommitterEmail: "committer@example.com",
+		CommitterName:  "Committer",
+		ID:             "0123456789abcdef0123456789abcdef01234567",
+		Message:        "Add contacts\n\nLonger description.",
+		Time:           when.Unix(),
+	}, NewMetadataCommit(commit))
+	assert.Equal(t, MetadataRequestResponseCommit{}, NewMetadataCommit(nil))
+}
+
+// TestMetadataRequestResponse_SetOccurrences() unit test function tests the
+// sorting and de-duplication of occurrences and paths.
+func TestMetadataRequestResponse_SetOccurrences(t *testing.T) {
+	t.Parallel()
+
+	commit_1 := MetadataRequestResponseCommit{ID: "commit-1", Time: 100}
+	commit_2 := MetadataRequestResponseCommit{ID: "commit-2", Time: 200}
+
+	var m MetadataRequestResponse
+	m.SetOccurrences([]MetadataRequestResponseOccurrence{
+		{Commit: commit_2, Path: "b.txt"},
+		{Commit: commit_2, Path: "a.txt"},
+		{Commit: commit_1, Path: "b.txt"},
+		{Commit: commit_2, Path: "b.txt"},
+	})
+	assert.Equal(t, []MetadataRequestResponseOccurrence{
+		{Commit: commit_1, Path: "b.txt"},
+		{Commit: commit_2, Path: "a.txt"},
+		{Commit: commit_2, Path: "b.txt"},
+	}, m.Occurrences)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, m.Object.Paths)
+
+	m.SetOccurrences(nil)
+	assert.Nil(t, m.Occurrences)
+	assert.Nil(t, m.Object.Paths)
+}
@@ -0,0 +1,111 @@
+package rrr
+
+import "github.com/pkg/errors"
+
+// ResultRecordFilter struct contains the (optional) conditions used to query
+// result records from a ResultRecordIO store, where each empty/zero value
+// matches all result records. Pagination is applied after filtering, in the
+// (stable) order of the store.
+type ResultRecordFilter struct {
+	// Category matches the Category of the result.
+	Category string `json:"category,omitempty"`
+	// CommitID matches the ID of the associated commit.
+	CommitID string `json:"commitId,omitempty"`
+	// Limit is the maximum number of result records to return, where 0 means
+	// that all matching result records are returned.
+	Limit int `json:"limit,omitempty"`
+	// MinConfidenceScore matches results with a ConfidenceScore greater than
+	// or equal to this value.
+	MinConfidenceScore float64 `json:"minConfidenceScore,omitempty"`
+	// ObjectID matches the ID of the associated object (e.g. file).
+	ObjectID string `json:"objectId,omitempty"`
+	// Offset is the number of matching result records to skip.
+	Offset int `json:"offset,omitempty"`
+	// RepositoryID matches the ID of the associated repository, which is
+	// either the URL or the "<org>/<repo>" name of the repository, such that
+	// the filter matches the results of every scan of the repository.
+	RepositoryID string `json:"repositoryId,omitempty"`
+	// Subcategory matches the Subcategory of the result.
+	Subcategory string `json:"subcategory,omitempty"`
+	// TimeStart matches result records of requests started at or after this
+	// timestamp (in nanoseconds).
+	TimeStart int64 `json:"timeStart,omitempty"`
+	// TimeStop matches result records of requests completed at or before this
+	// timestamp (in nanoseconds).
+	TimeStop int64 `json:"timeStop,omitempty"`
+}
+
+// Match() method returns true if the result record matches all conditions
+// of the filter, ignoring the pagination fields.
+func (f ResultRecordFilter) Match(r ResultRecord) bool {
+	switch {
+	case f.Category != "" && f.Category != r.Category:
+		return false
+	case f.CommitID != "" && f.CommitID != r.Commit.ID:
+		return false
+	case r.ConfidenceScore < f.MinConfidenceScore:
+		return false
+	case f.ObjectID != "" && f.ObjectID != r.Object.ID:
+		return false
+	case f.RepositoryID != "" && RepositoryID(f.RepositoryID) != r.Repository.ID:
+		return false
+	case f.Subcategory != "" && f.Subcategory != r.Subcategory:
+		return false
+	case f.TimeStart != 0 && r.Time.Start < f.TimeStart:
+		return false
+	case f.TimeStop != 0 && r.Time.Stop > f.TimeStop:
+		return false
+	}
+	return true
+}
+
+// Validate() method returns a non-nil error if the filter is invalid.
+func (f ResultRecordFilter) Validate() error {
+	switch {
+	case f.Limit < 0:
+		return errors.Wrapf(ErrResultRecordFilterInvalid, "limit = %d", f.Limit)
+	case f.MinConfidenceScore < 0 || f.MinConfidenceScore > 1:
+		return errors.Wrapf(ErrResultRecordFilterInvalid, "min confidence score = %f", f.MinConfidenceScore)
+	case f.Offset < 0:
+		return errors.Wrapf(ErrResultRecordFilterInvalid, "offset = %d", f.Offset)
+	case f.TimeStart != 0 && f.TimeStop != 0 && f.TimeStart > f.TimeStop:
+		return errors.Wrapf(ErrResultRecordFilterInvalid, "time start %d is after time stop %d", f.TimeStart, f.TimeStop)
+	}
+	return nil
+}
+
+// ResultRecordPage struct contains a single page of the result records that
+// match a ResultRecordFilter.
+type ResultRecordPage struct {
+	// Records are the matching result records within the requested page.
+	Records []ResultRecord `json:"records"`
+	// Total is the number of matching result records across all pages.
+	Total int `json:"total"`
+}
+
+// HasMore() method returns true if more matching result records exist after
+// this page, which was returned for the given filter.
+func (p ResultRecordPage) HasMore(f ResultRecordFilter) bool {
+	return f.Offset+len(p.Records) < p.Total
+}
+
+// QueryResultRecords() function applies the filter to the slice of result
+// records, which must already be in the stable order of the store, and
+// returns the requested page. Useful for stores that keep all result records
+// in memory.
+func QueryResultRecords(records []ResultRecord, f ResultRecordFilter) (ResultRecordPage, error) {
+	if err := f.Validate(); err != nil {
+		return ResultRecordPage{}, err
+	}
+	page := ResultRecordPage{Records: make([]ResultRecord, 0)}
+	for _, r := range records {
+		if !f.Match(r) {
+			continue
+		}
+		if page.Total >= f.Offset && (f.Limit == 0 || len(page.Records) < f.Limit) {
+			page.Records = append(page.Records, r)
+		}
+		page.Total++
+	}
+	return page, nil
+}
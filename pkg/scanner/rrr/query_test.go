@@ -0,0 +1,108 @@
+package rrr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testQueryResultRecords() helper function returns result records with
+// varied metadata for use in query tests.
+func testQueryResultRecords() []ResultRecord {
+	record := func(hash, repo_id, commit_id, object_id, category string, score float64, start, stop int64) ResultRecord {
+		return ResultRecord{
+			Hash: hash,
+			MetadataRequestResponse: MetadataRequestResponse{
+				Commit:     MetadataRequestResponseCommit{ID: commit_id},
+				Object:     MetadataRequestResponseObject{ID: object_id},
+				Repository: MetadataRequestResponseRepository{ID: repo_id},
+				Time:       MetadataRequestResponseTime{Start: start, Stop: stop},
+			},
+			Result: Result{Category: category, ConfidenceScore: score, Subcategory: "sub-" + category},
+		}
+	}
+	return []ResultRecord{
+		record("hash-1", "repo-a", "commit-1", "object-1", "Email", 0.9, 100, 110),
+		record("hash-2", "repo-a", "commit-1", "object-2", "Person", 0.6, 200, 210),
+		record("hash-3", "repo-a", "commit-2", "object-1", "Email", 0.8, 300, 310),
+		record("hash-4", "repo-b", "commit-3", "object-3", "Email", 0.99, 400, 410),
+	}
+}
+
+// TestQueryResultRecords() unit test function tests the filtering and
+// pagination of the QueryResultRecords() function.
+func TestQueryResultRecords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected_hashes []string
+		expected_total  int
+		filter          ResultRecordFilter
+		name            string
+	}{
+		{[]string{"hash-1", "hash-2", "hash-3", "hash-4"}, 4, ResultRecordFilter{}, "empty filter"},
+		{[]string{"hash-1", "hash-3", "hash-4"}, 3, ResultRecordFilter{Category: "Email"}, "category"},
+		{[]string{"hash-1", "hash-2"}, 2, ResultRecordFilter{CommitID: "commit-1"}, "commit"},
+		{[]string{"hash-1", "hash-4"}, 2, ResultRecordFilter{MinConfidenceScore: 0.9}, "min confidence"},
+		{[]string{"hash-1", "hash-3"}, 2, ResultRecordFilter{ObjectID: "object-1"}, "object"},
+		{[]string{"hash-4"}, 1, ResultRecordFilter{RepositoryID: "repo-b"}, "repository"},
+		{[]string{"hash-2"}, 1, ResultRecordFilter{Subcategory: "sub-Person"}, "subcategory"},
+		{[]string{"hash-2", "hash-3"}, 2, ResultRecordFilter{TimeStart: 200, TimeStop: 310}, "time range"},
+		{[]string{"hash-2", "hash-3"}, 4, ResultRecordFilter{Limit: 2, Offset: 1}, "page"},
+		{[]string{"hash-4"}, 3, ResultRecordFilter{Category: "Email", Limit: 2, Offset: 2}, "last page"},
+		{[]string{}, 4, ResultRecordFilter{Offset: 10}, "offset past end"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := QueryResultRecords(testQueryResultRecords(), test.filter)
+			if !assert.NoError(t, err) {
+				return
+			}
+			hashes := make([]string, 0)
+			for _, r := range page.Records {
+				hashes = append(hashes, r.Hash)
+			}
+			assert.Equal(t, test.expected_hashes, hashes)
+			assert.Equal(t, test.expected_total, page.Total)
+		})
+	}
+}
+
+// TestResultRecordPage_HasMore() unit test function tests the HasMore()
+// method of the ResultRecordPage struct.
+func TestResultRecordPage_HasMore(t *testing.T) {
+	t.Parallel()
+
+	filter := ResultRecordFilter{Limit: 3}
+	page, err := QueryResultRecords(testQueryResultRecords(), filter)
+	assert.NoError(t, err)
+	assert.True(t, page.HasMore(filter))
+
+	filter.Offset = 3
+	page, err = QueryResultRecords(testQueryResultRecords(), filter)
+	assert.NoError(t, err)
+	assert.False(t, page.HasMore(filter))
+}
+
+// TestResultRecordFilter_Validate() unit test function tests the Validate()
+// method of the ResultRecordFilter struct.
+func TestResultRecordFilter_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ResultRecordFilter{}.Validate())
+	assert.NoError(t, ResultRecordFilter{Limit: 10, MinConfidenceScore: 1, Offset: 5, TimeStart: 1, TimeStop: 1}.Validate())
+
+	invalid := []ResultRecordFilter{
+		{Limit: -1},
+		{MinConfidenceScore: -0.1},
+		{MinConfidenceScore: 1.1},
+		{Offset: -1},
+		{TimeStart: 2, TimeStop: 1},
+	}
+	for _, filter := range invalid {
+		assert.ErrorIs(t, filter.Validate(), ErrResultRecordFilterInvalid)
+		_, err := QueryResultRecords(nil, filter)
+		assert.ErrorIs(t, err, ErrResultRecordFilterInvalid)
+	}
+}
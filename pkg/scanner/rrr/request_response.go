@@ -5,6 +5,8 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"strings"
+
+	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
 )
 
 // MetadataRequestResponse struct contains metadata specific to a request and
@@ -17,6 +19,10 @@ type MetadataRequestResponse struct {
 	Commit MetadataRequestResponseCommit `json:"commit"`
 	// Object struct contains information about the associated object (e.g. file).
 	Object MetadataRequestResponseObject `json:"object"`
+	// Occurrences contains every commit and path at which the associated
+	// object (e.g. file blob) was found, sorted by commit time such that the
+	// first occurrence is the commit that introduced the object.
+	Occurrences []MetadataRequestResponseOccurrence `json:"occurrences,omitempty"`
 	// Repository struct contains information about the associated repository.
 	Repository MetadataRequestResponseRepository `json:"repository"`
 	// Time struct contains timestamps set during request processing.
@@ -24,18 +30,51 @@ type MetadataRequestResponse struct {
 }
 
 type MetadataRequestResponseCommit struct {
+	// AuthorEmail is the email address of the author of the commit.
+	AuthorEmail string `json:"authorEmail,omitempty"`
+	// AuthorName is the name of the author of the commit.
+	AuthorName string `json:"authorName,omitempty"`
+	// CommitterEmail is the email address of the committer of the commit.
+	CommitterEmail string `json:"committerEmail,omitempty"`
+	// CommitterName is the name of the committer of the commit.
+	CommitterName string `json:"committerName,omitempty"`
+	// ID is the string version of the commit's SHA1 hash.
 	ID string `json:"id"`
+	// Message is the commit message.
+	Message string `json:"message,omitempty"`
+	// Time is the commit time as a Unix timestamp in seconds.
+	Time int64 `json:"time,omitempty"`
 }
 
 type MetadataRequestResponseObject struct {
+	// Column is the (1-based) column of the first character of the source
+	// text within its line of the file, counted in characters (code points).
+	Column int `json:"column"`
 	// ID is the string version of the file's SHA1 hash, which is unique
 	// to the file's content and context (e.g. repository, commit, etc.)
 	ID string `json:"id"`
-	// Length is the number of characters in the source text.
+	// Length is the number of characters (code points) in the source text.
 	Length int `json:"length"`
-	// Offset is the starting character position of the source text within its
-	// original context (e.g. offset fromn start of file).
+	// Line is the (1-based) line number of the first character of the source
+	// text within the file, or 0 if the line is unknown.
+	Line int `json:"line"`
+	// Offset is the starting character (code point) position of the source
+	// text within its original context (e.g. offset from start of file),
+	// where line breaks are included in the count.
 	Offset int `json:"offset"`
+	// Path is the path of the file within the repository.
+	Path string `json:"path"`
+	// Paths are all paths at which the file was found within the repository,
+	// which are set from the Occurrences of the object.
+	Paths []string `json:"paths,omitempty"`
+}
+
+type MetadataRequestResponseOccurrence struct {
+	// Commit struct contains information about the commit in which the
+	// object was found.
+	Commit MetadataRequestResponseCommit `json:"commit"`
+	// Path is the path of the object within the tree of the commit.
+	Path string `json:"path"`
 }
 
 type MetadataRequestResponseRepository struct {
@@ -60,6 +99,10 @@ type Request struct {
 	// embed the MetadataRequestResponse struct
 	MetadataRequestResponse
 
+	// LineBreaks are the offsets of the characters within Text that follow a
+	// line break, which are used to locate results within the file.
+	LineBreaks []int `json:"-"`
+
 	// Text is the source text to be scanned for PHI/PII data and is only
 	// included in the Request (not the Response) object in order to limit the
 	// size of the response and the exposure of the source text.
@@ -69,14 +112,33 @@ type Request struct {
 // NewRequestInput struct contains the input parameters required for the
 // NewRequest() function.
 type NewRequestInput struct {
+	Column   int
 	CommitID string
 	Length   int
+	Line     int
 	ObjectID string
 	Offset   int
+	Path     string
 	RepoID   string
 	Text     string
 }
 
+// RepositoryID() function returns the ID of the repository at repo_url, in
+// the format "<org>/<repo>" in lower case, such that the IDs of the requests
+// and the hashes of the results of a repository are the same across scans
+// and across the different URLs of the repository. Returns repo_url itself
+// if the org and repo names cannot be parsed from the URL. The ID of a
+// repository is returned unchanged, i.e. RepositoryID(RepositoryID(url)) is
+// the same as RepositoryID(url).
+func RepositoryID(repo_url string) string {
+	org_name, org_err := nogit.ParseOrgNameFromURL(repo_url)
+	repo_name, repo_err := nogit.ParseRepoNameFromURL(repo_url)
+	if org_err != nil || repo_err != nil {
+		return repo_url
+	}
+	return strings.ToLower(org_name + "/" + repo_name)
+}
+
 // NewRequest() function initializes a new Request object.
 func NewRequest(in NewRequestInput) (Request, error) {
 	if in.RepoID == "" {
@@ -93,8 +155,10 @@ func NewRequest(in NewRequestInput) (Request, error) {
 	}
 
 	// create a unique ID by taking the SHA1 hash of the input strings
-	// separated by the ResultSeparatorUID
-	elements := []string{in.RepoID, in.CommitID, in.ObjectID, in.Text}
+	// separated by the ResultSeparatorUID, where the commit is left out
+	// such that the request for the same object has the same ID whichever
+	// commit the object is scanned in, e.g. when a scan is resumed
+	elements := []string{in.RepoID, in.ObjectID, in.Text}
 	sum := sha1.Sum([]byte(strings.Join(elements, ResultSeparatorUID)))
 
 	return Request{
@@ -104,9 +168,12 @@ func NewRequest(in NewRequestInput) (Request, error) {
 				ID: in.CommitID,
 			},
 			Object: MetadataRequestResponseObject{
+				Column: in.Column,
 				ID:     in.ObjectID,
 				Length: in.Length,
+				Line:   in.Line,
 				Offset: in.Offset,
+				Path:   in.Path,
 			},
 			Repository: MetadataRequestResponseRepository{
 				ID: in.RepoID,
@@ -116,7 +183,8 @@ func NewRequest(in NewRequestInput) (Request, error) {
 				Stop:  0,
 			},
 		},
-		Text: in.Text,
+		LineBreaks: lineBreaks(in.Text),
+		Text:       in.Text,
 	}, nil
 }
 
@@ -126,19 +194,46 @@ func NewRequest(in NewRequestInput) (Request, error) {
 type Response struct {
 	// embed the MetadataRequestResponse struct
 	MetadataRequestResponse
+	// Error is set when the detection service failed to process the
+	// associated Request, in which case Results is empty.
+	Error *ResponseError `json:"error,omitempty"`
+	// LineBreaks are copied from the associated Request.
+	LineBreaks []int `json:"-"`
 	// Results is a slice of detection results from the detection services.
 	Results []Result `json:"results"`
 }
 
+// ResponseError struct describes why the detection service failed to
+// process the Request associated with a Response.
+type ResponseError struct {
+	// Code is a short, machine-readable code for the error, e.g. the error
+	// code returned by the detection service for the document.
+	Code string `json:"code"`
+	// Message is a human-readable description of the error.
+	Message string `json:"message"`
+}
+
 // NewResponse() function initializes a new Response object from the provided
 // Request.
 func NewResponse(request *Request) Response {
 	return Response{
+		LineBreaks:              request.LineBreaks,
 		MetadataRequestResponse: request.MetadataRequestResponse,
 		Results:                 make([]Result, 0),
 	}
 }
 
+// NewErrorResponse() function initializes a new Response object from the
+// provided Request, with an Error set from the provided code and message.
+func NewErrorResponse(request *Request, code string, message string) Response {
+	response := NewResponse(request)
+	response.Error = &ResponseError{
+		Code:    code,
+		Message: message,
+	}
+	return response
+}
+
 // RequestResponsePhiDetector interface defines the inputs of the
 // Run() method, which is used to process Requests sent from
 // the Scanner and to send Responses back to the Scanner.
@@ -14,7 +14,7 @@ func TestNewRequest(t *testing.T) {
 	commitID := "test-commit"
 	objectID := "test-object"
 	text := "test-text"
-	expected_hash := "0be325f9ba1df76ddfcf60fe972f3b0f06781ac1"
+	expected_hash := "fbb17c86339ec662fd2fd6c72353fe6bb9aaf762"
 
 	t.Run("ValidInput", func(t *testing.T) {
 		request, err := NewRequest(NewRequestInput{
@@ -35,6 +35,17 @@ func TestNewRequest(t *testing.T) {
 		assert.NotZero(t, request.Time.Start)
 		assert.Zero(t, request.Time.Stop)
 		assert.Equal(t, text, request.Text)
+
+		// the ID of the request does not depend on the commit
+		other, err := NewRequest(NewRequestInput{
+			CommitID: "other-commit",
+			Length:   len(text),
+			ObjectID: objectID,
+			RepoID:   repoID,
+			Text:     text,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, request.ID, other.ID)
 	})
 
 	t.Run("EmptyRepositoryID", func(t *testing.T) {
@@ -133,5 +144,36 @@ func TestNewResponse(t *testing.T) {
 
 		assert.Equal(t, request.MetadataRequestResponse, response.MetadataRequestResponse)
 		assert.Equal(t, []Result{result}, response.Results)
+		assert.Nil(t, response.Error)
 	})
+
+	t.Run("Error", func(t *testing.T) {
+		response := NewErrorResponse(request, "InvalidDocument", "test-message")
+
+		assert.Equal(t, request.MetadataRequestResponse, response.MetadataRequestResponse)
+		assert.Empty(t, response.Results)
+		if assert.NotNil(t, response.Error) {
+			assert.Equal(t, "InvalidDocument", response.Error.Code)
+			assert.Equal(t, "test-message", response.Error.Message)
+		}
+	})
+}
+
+// TestRepositoryID() unit test function tests that the RepositoryID()
+// function returns the same ID for each URL of a repository.
+func TestRepositoryID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected string
+		repo_url string
+	}{
+		{expected: "blackiq/learn", repo_url: "git@github.com:BlackIQ/learn.git"},
+		{expected: "blackiq/learn", repo_url: "https://github.com/BlackIQ/learn"},
+		{expected: "blackiq/learn", repo_url: "https://github.com/blackiq/learn.git"},
+		{expected: "not-a-url", repo_url: "not-a-url"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, RepositoryID(test.repo_url), test.repo_url)
+	}
 }
@@ -65,22 +65,36 @@ type ResultRecord struct {
 	// Hash is the unique identifier of the result record, which is a
 	// sha1 hash of the associated IDs and stringified result data.
 	Hash string `json:"hash"`
+	// Head is the classification of the result by whether it is still
+	// present at the tip of the scanned history, which is set after a scan.
+	Head *ResultRecordHead `json:"head,omitempty"`
+	// Location is the absolute position of the result within the file.
+	Location Location `json:"location"`
 	// embed the MetadataRequestResponse struct
 	MetadataRequestResponse
 	// embed the Result struct
 	Result
 }
 
-// ResultRecordIO interface defines the methods for reading and writing
-// detection result records from/to some store (e.g. memory, file,
+// ResultRecordIO interface defines the methods for reading, writing, and
+// querying detection result records from/to some store (e.g. memory, file,
 // database).
 type ResultRecordIO interface {
 	Delete(id string) error
 	List() ([]ResultRecord, error)
+	Query(filter ResultRecordFilter) (ResultRecordPage, error)
 	Read(id string) (ResultRecord, error)
 	Write(results []ResultRecord) error
 }
 
+// PersistentResultRecordIO interface is implemented by ResultRecordIO stores
+// that persist result records across runs of the app (e.g. file, database),
+// where GetPath() returns the location of the store.
+type PersistentResultRecordIO interface {
+	ResultRecordIO
+	GetPath() string
+}
+
 // ResultRecordsFromResponse() function converts a Response object into a
 // slice of ResultRecord objects, where each ResultRecord contains the
 // metadata from the response and a single result from the response.
@@ -90,6 +104,7 @@ func ResultRecordsFromResponse(resp *Response) []ResultRecord {
 		records = append(records, ResultRecord{
 			// create a unique (hash) identifier for the result record
 			Hash:                    result.Hash(resp.Repository.ID, resp.Commit.ID, resp.Object.ID),
+			Location:                resp.Locate(result),
 			MetadataRequestResponse: resp.MetadataRequestResponse,
 			Result:                  result,
 		})
@@ -313,6 +313,7 @@ func TestResultRecordsFromResponse(t *testing.T) {
 	expectedRecords := []ResultRecord{
 		{
 			Hash:                    "da6544f2c9819e324b61b1e8de214dfe302fb969",
+			Location:                Location{Offset: 500},
 			MetadataRequestResponse: meta_req_resp,
 			Result: Result{
 				Category:        "test_category",
@@ -326,6 +327,7 @@ func TestResultRecordsFromResponse(t *testing.T) {
 		},
 		{
 			Hash:                    "b41daeb80879a4e20de48a4cff1be86da8818919",
+			Location:                Location{Offset: 20},
 			MetadataRequestResponse: meta_req_resp,
 			Result: Result{
 				Category:        "test_category",
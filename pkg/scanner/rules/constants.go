@@ -0,0 +1,21 @@
+package rules
+
+const CategoryAddress string = "Address"
+const CategoryCreditCardNumber string = "CreditCardNumber"
+const CategoryDateTime string = "DateTime"
+const CategoryDrugEnforcementAgencyNumber string = "DrugEnforcementAgencyNumber"
+const CategoryEmail string = "Email"
+const CategoryIPAddress string = "IPAddress"
+const CategoryMedicalRecordNumber string = "MedicalRecordNumber"
+const CategoryNationalProviderIdentifier string = "NationalProviderIdentifier"
+const CategoryPhoneNumber string = "PhoneNumber"
+const CategoryUSSocialSecurityNumber string = "USSocialSecurityNumber"
+
+// KeywordWindow is the maximum number of bytes preceding a match that are
+// searched for the keywords of a Rule.
+const KeywordWindow int = 32
+
+const LocalRulesService string = "local-rules"
+
+const SubcategoryDateOfBirth string = "DateOfBirth"
+const SubcategoryZipPlus4 string = "ZipPlus4"
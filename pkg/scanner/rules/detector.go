@@ -0,0 +1,166 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// LocalRulesPhiDetector struct type detects PHI/PII using regular expressions
+// and checksums, without sending the scanned text over the network.
+type LocalRulesPhiDetector struct {
+	rules []Rule
+}
+
+// NewLocalRulesPhiDetector() function returns a new LocalRulesPhiDetector
+// instance that applies the built-in rules plus any user-defined patterns
+// from the config, or returns an error if a user-defined pattern is invalid.
+func NewLocalRulesPhiDetector(config *cfg.Config) (*LocalRulesPhiDetector, error) {
+	rules := BuiltinRules()
+	for i, pattern := range config.Rules.Patterns {
+		rule, err := newPatternRule(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid config value: rules.patterns[%d]", i)
+		}
+		rules = append(rules, rule)
+	}
+	return &LocalRulesPhiDetector{rules: rules}, nil
+}
+
+// Detect() method applies all rules to the text and returns a result for each
+// match, sorted by offset. The offset and length of each result are measured
+// in Unicode code points, consistent with the results of the Azure AI Language
+// service.
+func (detector *LocalRulesPhiDetector) Detect(text string) []rrr.Result {
+	results := make([]rrr.Result, 0)
+	seen := make(map[string]bool)
+	for _, rule := range detector.rules {
+		for _, match := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := match[0], match[1]
+			// use the first capturing group as the result, if it matched
+			if len(match) >= 4 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			if start == end {
+				continue
+			}
+			match_text := text[start:end]
+			if rule.Validate != nil && !rule.Validate(match_text) {
+				continue
+			}
+			if rule.Keywords != nil {
+				window_start := match[0] - KeywordWindow
+				if window_start < 0 {
+					window_start = 0
+				}
+				if !rule.Keywords.MatchString(text[window_start:match[0]]) {
+					continue
+				}
+			}
+			offset := utf8.RuneCountInString(text[:start])
+			length := utf8.RuneCountInString(match_text)
+			// skip duplicate results from rules with the same category
+			key := fmt.Sprintf("%d:%d:%s", offset, length, rule.Category)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			results = append(results, rrr.Result{
+				Category:        rule.Category,
+				ConfidenceScore: rule.ConfidenceScore,
+				Length:          length,
+				Offset:          offset,
+				Service:         LocalRulesService,
+				Subcategory:     rule.Subcategory,
+				Text:            match_text,
+			})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Offset < results[j].Offset
+	})
+	return results
+}
+
+// Run() method listens for requests, applies the rules to the text of each
+// request, and sends a response containing any results using the provided
+// channels.
+func (detector *LocalRulesPhiDetector) Run(
+	ctx context.Context,
+	chan_requests_in <-chan rrr.Request,
+	chan_responses_out chan<- rrr.Response,
+) {
+	defer close(chan_responses_out)
+
+	logger := zerolog.Ctx(ctx)
+	logger.Info().Msg("started local rules detector")
+	defer logger.Info().Msg("finished local rules detector")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Warn().Msg("stopping local rules detector : context done")
+			// exit the function when the context is done
+			return
+		case request, ok := <-chan_requests_in:
+			if !ok {
+				return
+			}
+			response := rrr.NewResponse(&request)
+			response.Results = append(response.Results, detector.Detect(request.Text)...)
+
+			select {
+			case <-ctx.Done():
+				return
+			case chan_responses_out <- response:
+			}
+			logger.Debug().Msgf(
+				"local rules detector processed request ID = %s with %d results",
+				request.ID,
+				len(response.Results),
+			)
+		}
+	}
+}
+
+// newPatternRule() function converts a user-defined pattern from the config
+// to a Rule.
+func newPatternRule(pattern cfg.RulePatternConfig) (rule Rule, e error) {
+	if pattern.Category == "" {
+		e = ErrRuleCategoryEmpty
+		return
+	}
+	if pattern.ConfidenceScore < 0 || pattern.ConfidenceScore > 1 {
+		e = ErrRuleConfidenceScoreInvalid
+		return
+	}
+	if pattern.Pattern == "" {
+		e = ErrRulePatternEmpty
+		return
+	}
+	compiled, err := regexp.Compile(pattern.Pattern)
+	if err != nil {
+		e = errors.Wrap(err, ErrMsgRulePatternCompile)
+		return
+	}
+
+	rule = Rule{
+		Category:        pattern.Category,
+		ConfidenceScore: pattern.ConfidenceScore,
+		Pattern:         compiled,
+		Subcategory:     pattern.Subcategory,
+	}
+	if rule.ConfidenceScore == 0 {
+		rule.ConfidenceScore = cfg.DefaultRuleConfidenceScore
+	}
+	return
+}
@@ -0,0 +1,158 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestLocalRulesPhiDetector_Detect() unit test function tests the Detect()
+// method of the LocalRulesPhiDetector for each of the built-in rules.
+func TestLocalRulesPhiDetector_Detect(t *testing.T) {
+	t.Parallel()
+
+	config := cfg.NewDefaultConfig()
+	config.Rules.Patterns = []cfg.RulePatternConfig{
+		{Category: "EmployeeID", Pattern: `\bEMP-(\d{6})\b`},
+	}
+	detector, err := NewLocalRulesPhiDetector(config)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		category      string
+		expected_text string
+		name          string
+		text          string
+	}{
+		{CategoryAddress, "12345-6789", "zip+4", "Springfield, IL 12345-6789"},
+		{CategoryCreditCardNumber, "4111 1111 1111 1111", "credit card", "card 4111 1111 1111 1111 exp"},
+		{CategoryCreditCardNumber, "", "credit card bad luhn", "card 4111 1111 1111 1112 exp"},
+		{CategoryDateTime, "01/02/1980", "dob", "Patient DOB: 01/02/1980"},
+		{CategoryDateTime, "", "date without keyword", "Released on 01/02/1980"},
+		{CategoryDrugEnforcementAgencyNumber, "AB1234563", "dea", "DEA AB1234563"},
+		{CategoryDrugEnforcementAgencyNumber, "", "dea bad checksum", "DEA AB1234564"},
+		{CategoryEmail, "jane.doe@example.com", "email", "contact jane.doe@example.com today"},
+		{CategoryIPAddress, "192.168.1.10", "ipv4", "host 192.168.1.10 is up"},
+		{CategoryIPAddress, "", "ipv4 loopback", "host 127.0.0.1 is up"},
+		{CategoryIPAddress, "", "ipv4 invalid", "version 999.1.1.1"},
+		{CategoryIPAddress, "2001:db8::1", "ipv6", "host 2001:db8::1 is up"},
+		{CategoryIPAddress, "", "not ipv6", "std::vector at 12:30:45"},
+		{CategoryMedicalRecordNumber, "12345678", "mrn", "MRN: 12345678"},
+		{CategoryNationalProviderIdentifier, "1234567893", "npi", "NPI 1234567893"},
+		{CategoryNationalProviderIdentifier, "", "npi bad luhn", "NPI 1234567890"},
+		{CategoryNationalProviderIdentifier, "1234567893", "npi provider", "Referring provider ID: 1234567893"},
+		{CategoryNationalProviderIdentifier, "", "npi without keyword", "order 1234567893 shipped"},
+		{CategoryNationalProviderIdentifier, "", "npi timestamp", "created_at=1234567893"},
+		{CategoryNationalProviderIdentifier, "", "npi keyword too far", "NPI lookup failed for the order that was 1234567893"},
+		{CategoryPhoneNumber, "(555) 123-4567", "phone parens", "call (555) 123-4567"},
+		{CategoryPhoneNumber, "+1 555.123.4567", "phone country code", "call +1 555.123.4567"},
+		{CategoryUSSocialSecurityNumber, "123-45-6789", "ssn", "SSN 123-45-6789"},
+		{CategoryUSSocialSecurityNumber, "", "ssn invalid area", "SSN 666-45-6789"},
+		{"EmployeeID", "123456", "user pattern", "badge EMP-123456"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var found *rrr.Result
+			results := detector.Detect(test.text)
+			for i := range results {
+				if results[i].Category == test.category {
+					found = &results[i]
+					break
+				}
+			}
+			if test.expected_text == "" {
+				assert.Nil(t, found)
+				return
+			}
+			if !assert.NotNil(t, found) {
+				return
+			}
+			assert.Equal(t, test.expected_text, found.Text)
+			assert.Equal(t, LocalRulesService, found.Service)
+			assert.Greater(t, found.ConfidenceScore, 0.0)
+		})
+	}
+}
+
+// TestLocalRulesPhiDetector_Detect_Offsets() unit test function tests that
+// the offset and length of each result are measured in code points.
+func TestLocalRulesPhiDetector_Detect_Offsets(t *testing.T) {
+	t.Parallel()
+
+	detector, err := NewLocalRulesPhiDetector(cfg.NewDefaultConfig())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results := detector.Detect("Zoë : 123-45-6789")
+	if !assert.Len(t, results, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, 6, results[0].Offset)
+	assert.Equal(t, 11, results[0].Length)
+}
+
+// TestNewLocalRulesPhiDetector() unit test function tests the validation of
+// user-defined patterns by the NewLocalRulesPhiDetector() function.
+func TestNewLocalRulesPhiDetector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pattern cfg.RulePatternConfig
+	}{
+		{"empty category", cfg.RulePatternConfig{Pattern: `\d+`}},
+		{"empty pattern", cfg.RulePatternConfig{Category: "Test"}},
+		{"invalid pattern", cfg.RulePatternConfig{Category: "Test", Pattern: `(\d+`}},
+		{"invalid confidence score", cfg.RulePatternConfig{Category: "Test", ConfidenceScore: 1.5, Pattern: `\d+`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := cfg.NewDefaultConfig()
+			config.Rules.Patterns = []cfg.RulePatternConfig{test.pattern}
+			detector, err := NewLocalRulesPhiDetector(config)
+			assert.Error(t, err)
+			assert.Nil(t, detector)
+		})
+	}
+}
+
+// TestLocalRulesPhiDetector_Run() unit test function tests the Run() method
+// of the LocalRulesPhiDetector.
+func TestLocalRulesPhiDetector_Run(t *testing.T) {
+	t.Parallel()
+
+	detector, err := NewLocalRulesPhiDetector(cfg.NewDefaultConfig())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chan_requests_in := make(chan rrr.Request)
+	chan_responses_out := make(chan rrr.Response)
+
+	go detector.Run(ctx, chan_requests_in, chan_responses_out)
+
+	request := rrr.Request{
+		MetadataRequestResponse: rrr.MetadataRequestResponse{ID: "request-1"},
+		Text:                    "email jane.doe@example.com",
+	}
+	chan_requests_in <- request
+	response := <-chan_responses_out
+	assert.Equal(t, "request-1", response.ID)
+	assert.Len(t, response.Results, 1)
+
+	// closing the request channel stops the detector and closes the
+	// response channel
+	close(chan_requests_in)
+	_, ok := <-chan_responses_out
+	assert.False(t, ok)
+}
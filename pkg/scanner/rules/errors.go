@@ -0,0 +1,13 @@
+package rules
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgRulePatternCompile = "failed to compile rule pattern"
+)
+
+var (
+	ErrRuleCategoryEmpty          = errors.New("rule category cannot be empty")
+	ErrRuleConfidenceScoreInvalid = errors.New("rule confidence score must be between 0 and 1")
+	ErrRulePatternEmpty           = errors.New("rule pattern cannot be empty")
+)
@@ -0,0 +1,193 @@
+package rules
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Rule struct defines a single pattern used by the LocalRulesPhiDetector to
+// detect PHI/PII, along with the category and confidence score of the results.
+type Rule struct {
+	// Category is the category of the results that match the rule, where the
+	// built-in rules use the same category names as the Azure AI Language
+	// service wherever possible.
+	Category        string
+	ConfidenceScore float64
+	// Keywords is an (optional) pattern that must match the text preceding a
+	// match of Pattern, within KeywordWindow bytes, e.g. "DOB" before a date.
+	Keywords *regexp.Regexp
+	// Pattern is the pattern to match. If the pattern contains a capturing
+	// group, then only the text matched by the first group is the result.
+	Pattern     *regexp.Regexp
+	Subcategory string
+	// Validate is an (optional) function that returns false if the matched
+	// text should be ignored, e.g. because it fails a checksum.
+	Validate func(match string) bool
+}
+
+// BuiltinRules() function returns the built-in rules of the local rules
+// detector. A new slice is returned on each call so that callers can safely
+// append their own rules.
+func BuiltinRules() []Rule {
+	return []Rule{
+		{
+			Category:        CategoryCreditCardNumber,
+			ConfidenceScore: 0.85,
+			Pattern:         regexp.MustCompile(`\b[3-6]\d{3}(?:[ -]?\d{2,4}){3,4}\b`),
+			Validate:        isValidCreditCardNumber,
+		},
+		{
+			Category:        CategoryDateTime,
+			ConfidenceScore: 0.8,
+			Keywords:        regexp.MustCompile(`(?i)\b(?:DOB|D\.O\.B\.?|date of birth|birth ?date|born(?: on)?)\W{0,3}$`),
+			Pattern:         regexp.MustCompile(`\b(?:\d{1,2}[/.-]\d{1,2}[/.-](?:\d{4}|\d{2})|\d{4}-\d{2}-\d{2})\b`),
+			Subcategory:     SubcategoryDateOfBirth,
+		},
+		{
+			Category:        CategoryDrugEnforcementAgencyNumber,
+			ConfidenceScore: 0.85,
+			Pattern:         regexp.MustCompile(`\b[ABCDEFGHJKLMPRSTUX][A-Z9]\d{7}\b`),
+			Validate:        isValidDEANumber,
+		},
+		{
+			Category:        CategoryEmail,
+			ConfidenceScore: 0.8,
+			Pattern:         regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@(?:[A-Za-z0-9-]+\.)+[A-Za-z]{2,}\b`),
+		},
+		{
+			Category:        CategoryIPAddress,
+			ConfidenceScore: 0.7,
+			Pattern:         regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+			Validate:        isValidIPAddress,
+		},
+		{
+			Category:        CategoryIPAddress,
+			ConfidenceScore: 0.7,
+			Pattern:         regexp.MustCompile(`(?i)\b[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}\b`),
+			Validate:        isValidIPAddress,
+		},
+		{
+			Category:        CategoryMedicalRecordNumber,
+			ConfidenceScore: 0.75,
+			Pattern:         regexp.MustCompile(`(?i)\b(?:MRN|medical record(?: number| no\.?| #)?)\s*[:#]?\s*([A-Z]{0,3}-?\d{5,12})\b`),
+		},
+		// one in ten of any 10-digit numbers starting with 1 or 2 (e.g. Unix
+		// timestamps) passes the check digit of an NPI, so require a keyword
+		{
+			Category:        CategoryNationalProviderIdentifier,
+			ConfidenceScore: 0.75,
+			Keywords:        regexp.MustCompile(`(?i)\b(?:NPI|provider)`),
+			Pattern:         regexp.MustCompile(`\b[12]\d{9}\b`),
+			Validate:        isValidNPI,
+		},
+		{
+			Category:        CategoryPhoneNumber,
+			ConfidenceScore: 0.8,
+			Pattern:         regexp.MustCompile(`(?:\+1[ .-]?)?(?:\(\d{3}\) ?|\b\d{3}[ .-])\d{3}[ .-]\d{4}\b`),
+		},
+		{
+			Category:        CategoryAddress,
+			ConfidenceScore: 0.65,
+			Pattern:         regexp.MustCompile(`\b\d{5}-\d{4}\b`),
+			Subcategory:     SubcategoryZipPlus4,
+		},
+		{
+			Category:        CategoryUSSocialSecurityNumber,
+			ConfidenceScore: 0.85,
+			Pattern:         regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+			Validate:        isValidSSN,
+		},
+	}
+}
+
+// digitsOnly() function returns the digits of the input string, ignoring any
+// other characters such as spaces and dashes.
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isValidCreditCardNumber() function returns true if the input contains 13 to
+// 19 digits that pass the Luhn check.
+func isValidCreditCardNumber(s string) bool {
+	digits := digitsOnly(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	return luhn(digits)
+}
+
+// isValidDEANumber() function returns true if the input is a DEA registration
+// number with a valid check digit.
+//
+// ref: https://en.wikipedia.org/wiki/DEA_number
+func isValidDEANumber(s string) bool {
+	digits := s[2:]
+	if len(digits) != 7 {
+		return false
+	}
+	d := make([]int, 7)
+	for i, r := range digits {
+		d[i] = int(r - '0')
+	}
+	sum := d[0] + d[2] + d[4] + 2*(d[1]+d[3]+d[5])
+	return sum%10 == d[6]
+}
+
+// isValidIPAddress() function returns true if the input is a valid IPv4 or
+// IPv6 address, excluding unspecified and loopback addresses.
+func isValidIPAddress(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && !ip.IsUnspecified() && !ip.IsLoopback()
+}
+
+// isValidNPI() function returns true if the input is a National Provider
+// Identifier that passes the Luhn check with the "80840" prefix.
+//
+// ref: https://www.cms.gov/Regulations-and-Guidance/Administrative-Simplification/NationalProvIdentStand/Downloads/NPIcheckdigit.pdf
+func isValidNPI(s string) bool {
+	return len(s) == 10 && luhn("80840"+s)
+}
+
+// isValidSSN() function returns true if the input is formatted as a US Social
+// Security Number that could have been issued, i.e. the area number is not
+// 000, 666 or 9xx, the group number is not 00 and the serial is not 0000.
+func isValidSSN(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) != 3 {
+		return false
+	}
+	area, group, serial := parts[0], parts[1], parts[2]
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+	return group != "00" && serial != "0000"
+}
+
+// luhn() function returns true if the input string of digits passes the
+// Luhn (mod 10) check.
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,17 @@
+package s3
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgS3CheckpointStoreCreate = "failed to create s3 checkpoint store"
+	ErrMsgS3CheckpointStoreDelete = "failed to delete from s3 checkpoint store"
+	ErrMsgS3CheckpointStoreRead   = "failed to read from s3 checkpoint store"
+	ErrMsgS3CheckpointStoreWrite  = "failed to write to s3 checkpoint store"
+)
+
+var (
+	ErrS3CheckpointStoreBucketEmpty      = errors.New("s3 checkpoint store bucket is empty")
+	ErrS3CheckpointStoreCredentialsEmpty = errors.New("s3 checkpoint store credentials are empty and anonymous access is not allowed")
+	ErrS3CheckpointStoreEndpoint         = errors.New("s3 checkpoint store endpoint is invalid")
+	ErrS3CheckpointStoreRequestFailed    = errors.New("s3 checkpoint store request failed")
+)
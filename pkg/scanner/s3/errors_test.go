@@ -0,0 +1,40 @@
+package s3
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		name string
+	}{
+		{
+			err:  ErrS3CheckpointStoreBucketEmpty,
+			name: "ErrS3CheckpointStoreBucketEmpty",
+		},
+		{
+			err:  ErrS3CheckpointStoreCredentialsEmpty,
+			name: "ErrS3CheckpointStoreCredentialsEmpty",
+		},
+		{
+			err:  ErrS3CheckpointStoreEndpoint,
+			name: "ErrS3CheckpointStoreEndpoint",
+		},
+		{
+			err:  ErrS3CheckpointStoreRequestFailed,
+			name: "ErrS3CheckpointStoreRequestFailed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			new_err := errors.New(test.err.Error())
+			assert.Error(t, test.err)
+			assert.Equal(t, test.err.Error(), new_err.Error())
+		})
+	}
+}
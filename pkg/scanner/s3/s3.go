@@ -0,0 +1,204 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+)
+
+const ClientTimeout time.Duration = 30 * time.Second
+const Service string = "s3"
+
+// S3CheckpointStore struct provides an implementation of the
+// scanner.CheckpointStore interface that stores each checkpoint as an object
+// in a bucket of an S3-compatible object storage service (e.g. AWS S3 or
+// MinIO), such that checkpoints outlive the (ephemeral) disk of the app.
+// Requests are signed with AWS Signature Version 4 by the signer of the AWS
+// SDK, and each checkpoint object is encrypted by the service.
+type S3CheckpointStore struct {
+	scanner.CheckpointStore
+
+	anonymous              bool
+	bucket                 string
+	client                 *http.Client
+	credentials            aws.Credentials
+	ctx                    context.Context
+	endpoint               *url.URL
+	logger                 *zerolog.Logger
+	prefix                 string
+	region                 string
+	server_side_encryption string
+	signer                 *v4.Signer
+}
+
+// NewS3CheckpointStore() function initializes a new S3CheckpointStore object
+// for the bucket of the config. If the endpoint of the config is empty, then
+// the AWS S3 endpoint of the region is used. Returns an error if the access
+// key ID or secret access key of the config is empty, unless the Anonymous
+// value of the config allows requests to be sent without signing them.
+func NewS3CheckpointStore(ctx context.Context, config cfg.CheckpointS3Config) (*S3CheckpointStore, error) {
+	if config.Bucket == "" {
+		return nil, errors.Wrap(ErrS3CheckpointStoreBucketEmpty, ErrMsgS3CheckpointStoreCreate)
+	}
+	if (config.AccessKeyID == "" || config.SecretAccessKey == "") && !config.Anonymous {
+		return nil, errors.Wrap(ErrS3CheckpointStoreCredentialsEmpty, ErrMsgS3CheckpointStoreCreate)
+	}
+	region := config.Region
+	if region == "" {
+		region = cfg.DefaultCheckpointS3Region
+	}
+	server_side_encryption := config.ServerSideEncryption
+	if server_side_encryption == "" {
+		server_side_encryption = cfg.DefaultCheckpointS3ServerSideEncryption
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	endpoint_url, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || endpoint_url.Scheme == "" || endpoint_url.Host == "" {
+		return nil, errors.Wrapf(ErrS3CheckpointStoreEndpoint, "%s : endpoint = %s", ErrMsgS3CheckpointStoreCreate, endpoint)
+	}
+	return &S3CheckpointStore{
+		anonymous:              config.AccessKeyID == "" || config.SecretAccessKey == "",
+		bucket:                 config.Bucket,
+		client:                 &http.Client{Timeout: ClientTimeout},
+		credentials:            aws.Credentials{AccessKeyID: config.AccessKeyID, SecretAccessKey: config.SecretAccessKey},
+		ctx:                    ctx,
+		endpoint:               endpoint_url,
+		logger:                 zerolog.Ctx(ctx),
+		prefix:                 config.Prefix,
+		region:                 region,
+		server_side_encryption: server_side_encryption,
+		// the path of each object is signed as it is sent, as required by
+		// the S3 API, instead of being escaped again by the signer
+		signer: v4.NewSigner(func(options *v4.SignerOptions) {
+			options.DisableURIPathEscaping = true
+		}),
+	}, nil
+}
+
+// Delete() method deletes the checkpoint object with matching key from the
+// bucket, where deleting an object that does not exist is not an error.
+func (cs *S3CheckpointStore) Delete(key string) error {
+	cs.logger.Debug().Msgf("deleting checkpoint key=%s from s3 store", key)
+	resp, err := cs.do(http.MethodDelete, key, nil)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgS3CheckpointStoreDelete)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound && !isSuccess(resp.StatusCode) {
+		return errors.Wrap(responseError(resp), ErrMsgS3CheckpointStoreDelete)
+	}
+	return nil
+}
+
+// Get() method returns the checkpoint object with matching key from the
+// bucket. Returns scanner.ErrCheckpointNotFound if no object exists for the
+// key.
+func (cs *S3CheckpointStore) Get(key string) (*scanner.Checkpoint, error) {
+	cs.logger.Debug().Msgf("reading checkpoint key=%s from s3 store", key)
+	resp, err := cs.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgS3CheckpointStoreRead)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, scanner.ErrCheckpointNotFound
+	}
+	if !isSuccess(resp.StatusCode) {
+		return nil, errors.Wrap(responseError(resp), ErrMsgS3CheckpointStoreRead)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgS3CheckpointStoreRead)
+	}
+	return scanner.DecodeCheckpoint(data)
+}
+
+// GetURL() method returns the URL of the checkpoint object with matching key.
+func (cs *S3CheckpointStore) GetURL(key string) string {
+	return cs.objectURL(key).String()
+}
+
+// Set() method writes the checkpoint object with matching key to the bucket,
+// replacing any object that already exists with the same key.
+func (cs *S3CheckpointStore) Set(key string, c *scanner.Checkpoint) error {
+	cs.logger.Debug().Msgf("writing checkpoint key=%s to s3 store", key)
+	var data bytes.Buffer
+	if err := scanner.EncodeCheckpoint(&data, c); err != nil {
+		return errors.Wrap(err, ErrMsgS3CheckpointStoreWrite)
+	}
+	resp, err := cs.do(http.MethodPut, key, data.Bytes())
+	if err != nil {
+		return errors.Wrap(err, ErrMsgS3CheckpointStoreWrite)
+	}
+	defer resp.Body.Close()
+	if !isSuccess(resp.StatusCode) {
+		return errors.Wrap(responseError(resp), ErrMsgS3CheckpointStoreWrite)
+	}
+	return nil
+}
+
+// do() method sends a (signed) request with the method and body for the
+// checkpoint object with matching key, and returns the response, whose body
+// must be closed by the caller. Every object that is written is encrypted
+// with the server-side encryption of the config.
+func (cs *S3CheckpointStore) do(method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(cs.ctx, method, cs.GetURL(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/gzip")
+		req.Header.Set("X-Amz-Server-Side-Encryption", cs.server_side_encryption)
+	}
+	sum := sha256.Sum256(body)
+	if err := cs.sign(req, hex.EncodeToString(sum[:]), time.Now()); err != nil {
+		return nil, err
+	}
+	return cs.client.Do(req)
+}
+
+// isSuccess() function returns true if the HTTP status code is a 2xx code.
+func isSuccess(status_code int) bool {
+	return status_code >= 200 && status_code < 300
+}
+
+// objectURL() method returns the path-style URL of the checkpoint object with
+// matching key.
+func (cs *S3CheckpointStore) objectURL(key string) *url.URL {
+	return cs.endpoint.JoinPath(cs.bucket, cs.prefix+key+scanner.CheckpointFileExtension)
+}
+
+// responseError() function returns an error that describes the status and
+// (truncated) body of a failed response.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Wrapf(ErrS3CheckpointStoreRequestFailed, "%s : %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// sign() method signs the request with AWS Signature Version 4 at the given
+// time, where payload_hash is the hex-encoded SHA256 hash of the body. The
+// request is not signed if the store is anonymous.
+func (cs *S3CheckpointStore) sign(req *http.Request, payload_hash string, now time.Time) error {
+	req.Header.Set("X-Amz-Content-Sha256", payload_hash)
+	if cs.anonymous {
+		return nil
+	}
+	return cs.signer.SignHTTP(cs.ctx, cs.credentials, req, payload_hash, Service, cs.region, now)
+}
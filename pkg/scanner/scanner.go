@@ -2,16 +2,17 @@ package scanner
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"time"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/head"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
 )
@@ -20,24 +21,36 @@ import (
 // for running a scan of a git repository for PHI/PII data, recursively scanning
 // the contents of files committed to each repository.
 type Scanner struct {
+	// ID is the rrr.RepositoryID() of the scanned repository, which is set when
+	// the scan is started and used as the repository ID of every request.
 	ID string `json:"id"`
 	// URL associated with the object, such as the repository URL
 	URL string `json:"url"`
 
-	TrackerCommits  *tracker.KeyTracker
-	TrackerFiles    *tracker.KeyTracker
-	TrackerRequests *tracker.KeyTracker
+	TrackerCommits  tracker.Tracker
+	TrackerFiles    tracker.Tracker
+	TrackerRequests tracker.Tracker
 
 	chan_commits     chan *object.Commit
 	chan_requests    chan rrr.Request
 	chan_errors      chan error
+	checkpoint_store CheckpointStore
 	ctx              context.Context
+	error_policy     ErrorPolicy
+	failed           *failedChunks
+	fingerprint      string
 	git_config       *cfg.GitConfig
+	head             string
+	is_retry         bool
 	is_scan_complete bool
 	logger           *zerolog.Logger
+	occurrences      *occurrenceIndex
 	repository       *git.Repository
+	request_slots    chan struct{}
 	result_io        rrr.ResultRecordIO
+	scan_errors      *scanErrors
 	scan_mutex       *sync.RWMutex
+	tracker_store    tracker.KeyStore
 }
 
 // NewScanner() function initializes a new Scanner object.
@@ -68,19 +81,40 @@ func NewScanner(
 		return nil, errors.Wrap(tr_err, ErrMsgScannerCreate)
 	}
 
+	// determine whether the scan continues after recoverable errors
+	error_policy, ep_err := NewErrorPolicy(git_config.Scan.ErrorPolicy)
+	if ep_err != nil {
+		return nil, errors.Wrap(ep_err, ErrMsgScannerCreate)
+	}
+
+	// limit the number of requests that are sent without receiving a response
+	max_requests_outstanding := git_config.Scan.Limits.MaxRequestsOutstanding
+	if max_requests_outstanding < 1 {
+		max_requests_outstanding = cfg.DefaultMaxRequestsOutstanding
+	}
+
+	// store checkpoints as files in the work dir, unless another store is set
+	// with the SetCheckpointStore() method
+	checkpoint_store := NewFileCheckpointStore(ctx, git_config.WorkDir)
+
 	return &Scanner{
-		ID:              uuid.NewString(),
-		TrackerCommits:  tracker_commits,
-		TrackerFiles:    tracker_files,
-		TrackerRequests: tracker_requests,
-		chan_commits:    make(chan *object.Commit),
-		chan_errors:     make(chan error),
-		chan_requests:   make(chan rrr.Request),
-		ctx:             ctx,
-		git_config:      git_config,
-		logger:          logger,
-		result_io:       result_io,
-		scan_mutex:      &sync.RWMutex{},
+		TrackerCommits:   tracker_commits,
+		TrackerFiles:     tracker_files,
+		TrackerRequests:  tracker_requests,
+		chan_commits:     make(chan *object.Commit),
+		chan_errors:      make(chan error),
+		chan_requests:    make(chan rrr.Request),
+		checkpoint_store: checkpoint_store,
+		ctx:              ctx,
+		error_policy:     error_policy,
+		failed:           newFailedChunks(),
+		git_config:       git_config,
+		logger:           logger,
+		occurrences:      newOccurrenceIndex(),
+		request_slots:    make(chan struct{}, max_requests_outstanding),
+		result_io:        result_io,
+		scan_errors:      newScanErrors(),
+		scan_mutex:       &sync.RWMutex{},
 	}, nil
 }
 
@@ -90,8 +124,14 @@ type ScanInput struct {
 	ChanErrorsSend      chan<- error
 	ChanRequestSend     chan<- rrr.Request
 	ChanResponseReceive <-chan rrr.Response
-	RepoID              string
-	Repository          *git.Repository
+	// Config is the (optional) config of the app, whose config of the
+	// detector is part of the fingerprint of the Checkpoint of the scan.
+	Config *cfg.Config
+	// Detector is the name of the detector that responds to the requests,
+	// which is part of the fingerprint of the Checkpoint of the scan.
+	Detector   string
+	RepoID     string
+	Repository *git.Repository
 }
 
 // Scan() method uses channels and goroutines to coordinate the scanning of
@@ -100,54 +140,283 @@ func (s *Scanner) Scan(in ScanInput) {
 	s.logger.Debug().Msg("started Scanner run")
 	defer s.logger.Debug().Msg("finished Scanner run")
 
+	s.ID = rrr.RepositoryID(in.RepoID)
+
+	// identify the config and the tip commit of the scan, which are saved in
+	// each Checkpoint of the scan
+	s.fingerprint = CheckpointFingerprint(s.git_config, in.Detector, in.Config)
+	s.head = s.resolveHead(in.Repository)
+
 	// check if a previous scan created a Checkpoint file from which to resume
-	cpoint, cpoint_err := CheckpointGet(s.ctx, s.git_config.WorkDir, in.RepoID, "")
-	if cpoint_err != nil {
+	cpoint, cpoint_err := CheckpointGet(s.ctx, s.checkpoint_store, in.RepoID, "")
+	if errors.Is(cpoint_err, ErrCheckpointNotFound) {
+		s.logger.Debug().Msg("no checkpoint data from a previous scan")
+	} else if cpoint_err != nil {
 		s.logger.Error().Err(cpoint_err).Msg("failed to initialize scan tracker with checkpoint data")
 	}
 	if cpoint != nil {
-		// use the Checkpoint data to restore state from a previous scan
-		s.TrackerCommits.Restore(cpoint.TrackerCommitsData)
-		s.TrackerFiles.Restore(cpoint.TrackerFilesData)
-		s.TrackerRequests.Restore(cpoint.TrackerRequestsData)
+		resume, resume_err := s.checkResume(cpoint)
+		if resume_err != nil {
+			s.sendError(in.ChanErrorsSend, resume_err)
+			close(in.ChanErrorsSend)
+			return
+		}
+		if !resume {
+			cpoint = nil
+		}
+	}
+	// use the Checkpoint data to restore state from a previous scan, or else
+	// reset the state of the trackers
+	if err := s.restoreTrackers(cpoint); err != nil {
+		s.sendError(in.ChanErrorsSend, errors.Wrap(err, ErrMsgCheckpointTrackers))
+		close(in.ChanErrorsSend)
+		return
+	}
+	if cpoint != nil {
+		// restore the results of the completed requests, which are only in
+		// the Checkpoint when the scan does not use a persistent result store
+		if len(cpoint.Results) > 0 {
+			if err := restoreResultsText(in.Repository, cpoint.Results); err != nil {
+				s.sendError(in.ChanErrorsSend, errors.Wrap(err, ErrMsgCheckpointResults))
+				close(in.ChanErrorsSend)
+				return
+			}
+			if err := s.result_io.Write(cpoint.Results); err != nil {
+				s.sendError(in.ChanErrorsSend, errors.Wrap(err, ErrMsgCheckpointResults))
+				close(in.ChanErrorsSend)
+				return
+			}
+			s.logger.Info().Msgf("restored %d result(s) from checkpoint", len(cpoint.Results))
+		}
+		// rebuild the occurrences of the objects with results, and of the
+		// objects that were not done, from the results and the Checkpoint
+		records, err := s.result_io.List()
+		if err != nil {
+			s.sendError(in.ChanErrorsSend, errors.Wrap(err, ErrMsgCheckpointResults))
+			close(in.ChanErrorsSend)
+			return
+		}
+		s.occurrences.Restore(cpoint.Occurrences, records)
+	}
+
+	s.run(in, func(chan_scan_done chan struct{}) {
+		s.scanRepository(
+			in.RepoID,
+			in.Repository,
+			s.chan_errors,
+			chan_scan_done,
+		)
+	})
+}
+
+// SetCheckpointStore() method sets the store in which the Checkpoints of the
+// scan are saved, which must be called before the scan is started.
+func (s *Scanner) SetCheckpointStore(store CheckpointStore) {
+	s.checkpoint_store = store
+}
+
+// SetTrackerStore() method replaces the trackers of the scan with trackers
+// that keep their keys in the KeyStore instead of memory, such that each
+// Checkpoint of the scan only writes the keys that changed since the previous
+// Checkpoint to the store. Must be called before the scan is started.
+func (s *Scanner) SetTrackerStore(store tracker.KeyStore) error {
+	tracker_commits, tc_err := tracker.NewDiskKeyTracker(tracker.ScanObjectTypeCommit, s.logger, store)
+	if tc_err != nil {
+		return errors.Wrap(tc_err, ErrMsgScannerTrackerStore)
+	}
+	tracker_files, tf_err := tracker.NewDiskKeyTracker(tracker.ScanObjectTypeFile, s.logger, store)
+	if tf_err != nil {
+		return errors.Wrap(tf_err, ErrMsgScannerTrackerStore)
 	}
+	tracker_requests, tr_err := tracker.NewDiskKeyTracker(tracker.ScanObjectTypeRequestResponse, s.logger, store)
+	if tr_err != nil {
+		return errors.Wrap(tr_err, ErrMsgScannerTrackerStore)
+	}
+	s.TrackerCommits = tracker_commits
+	s.TrackerFiles = tracker_files
+	s.TrackerRequests = tracker_requests
+	s.tracker_store = store
+	return nil
+}
+
+// FailedChunks() method returns the chunks for which the detector returned an
+// error response during the scan, sorted by commit ID, path, and offset.
+func (s *Scanner) FailedChunks() []FailedChunk {
+	return s.failed.List()
+}
 
+// Summary() method returns the ScanSummary of the scan, which includes the
+// counts of each tracker and the errors of the scan.
+func (s *Scanner) Summary() ScanSummary {
+	summary := ScanSummary{
+		Commits:      s.TrackerCommits.GetCounts(),
+		FailedChunks: len(s.FailedChunks()),
+		Files:        s.TrackerFiles.GetCounts(),
+		Requests:     s.TrackerRequests.GetCounts(),
+	}
+	s.scan_errors.summarize(&summary)
+	return summary
+}
+
+// RetryFailed() method uses the same channels and goroutines as the Scan()
+// method to scan only the provided chunks, i.e. the failed chunks of a
+// previous scan of the repository, instead of the history of the repository.
+func (s *Scanner) RetryFailed(in ScanInput, chunks []FailedChunk) {
+	s.logger.Debug().Msg("started Scanner retry")
+	defer s.logger.Debug().Msg("finished Scanner retry")
+
+	s.ID = rrr.RepositoryID(in.RepoID)
+	s.is_retry = true
+	s.run(in, func(chan_scan_done chan struct{}) {
+		s.retryChunks(
+			in.RepoID,
+			in.Repository,
+			chunks,
+			s.chan_errors,
+			chan_scan_done,
+		)
+	})
+}
+
+// run() method starts the goroutines that process the errors, requests, and
+// responses of the scan, then runs the scan function in a goroutine, which
+// must close chan_scan_done when done, and waits for the scan to complete.
+// When the context of the Scanner is done, e.g. because the scan was stopped
+// by an error, the goroutines are stopped, and run() returns once all of the
+// goroutines have returned.
+func (s *Scanner) run(in ScanInput, scan func(chan_scan_done chan struct{})) {
 	// create channels for coordinating between goroutines
 	chan_scan_done := make(chan struct{})
 	chan_quit := make(chan struct{})
 
+	wg := &sync.WaitGroup{}
+	goWait := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
 	// track the progress of the scan
-	go s.trackScanProgress(chan_scan_done, chan_quit)
+	goWait(func() { s.trackScanProgress(chan_scan_done, chan_quit) })
 	// listen for errors generated by the scan
-	go s.processErrors(chan_quit, s.chan_errors, in.ChanErrorsSend)
+	goWait(func() { s.processErrors(chan_quit, s.chan_errors, in.ChanErrorsSend) })
 	// process requests generated by the scan
-	go s.processRequests(
-		chan_quit,
-		s.chan_requests,
-		in.ChanRequestSend,
-		s.chan_errors,
-	)
+	goWait(func() {
+		s.processRequests(
+			chan_quit,
+			s.chan_requests,
+			in.ChanRequestSend,
+			s.chan_errors,
+		)
+	})
 	// process responses for requests
-	go s.processResponses(
-		chan_quit,
-		in.ChanResponseReceive,
-		s.chan_errors,
-	)
+	goWait(func() {
+		s.processResponses(
+			chan_quit,
+			in.ChanResponseReceive,
+			s.chan_errors,
+		)
+	})
 	// scan the repository
-	go s.scanRepository(
-		in.RepoID,
-		in.Repository,
-		s.chan_errors,
-		chan_scan_done,
-	)
+	goWait(func() { scan(chan_scan_done) })
 
-	// listen for quit signal
+	// listen for quit signal, then wait for the goroutines to return
 	// TODO : replace with `go s.processResults()`
 	<-chan_quit
+	wg.Wait()
+}
+
+// checkResume() method checks whether the scan can resume from the Checkpoint
+// of a previous scan, which requires the same config and tip commit, unless
+// the resume config value forces or discards the Checkpoint on a mismatch.
+// Returns true if the scan should resume from the Checkpoint, or a non-nil
+// error if the scan should fail.
+func (s *Scanner) checkResume(cpoint *Checkpoint) (bool, error) {
+	var result_store string
+	if persistent, ok := s.result_io.(rrr.PersistentResultRecordIO); ok {
+		result_store = persistent.GetPath()
+	}
+	var tracker_store string
+	var tracker_generation int64
+	if s.tracker_store != nil {
+		tracker_store = s.tracker_store.GetPath()
+		generation, err := s.tracker_store.Generation()
+		if err != nil {
+			return false, errors.Wrap(err, ErrMsgCheckpointTrackers)
+		}
+		tracker_generation = generation
+	}
+	mismatch := cpoint.Mismatch(s.fingerprint, s.head, result_store, tracker_store, tracker_generation)
+	if mismatch == "" {
+		return true, nil
+	}
+	switch s.git_config.Scan.Resume {
+	case cfg.ResumeForce:
+		s.logger.Warn().Msgf("resuming from checkpoint anyway : %s", mismatch)
+		return true, nil
+	case cfg.ResumeDiscard:
+		s.logger.Warn().Msgf("discarding checkpoint and starting the scan over : %s", mismatch)
+		return false, nil
+	default:
+		return false, errors.Wrapf(
+			ErrCheckpointMismatch,
+			"%s : resume with --resume=%s or start over with --resume=%s",
+			mismatch,
+			cfg.ResumeForce,
+			cfg.ResumeDiscard,
+		)
+	}
+}
+
+// restoreTrackers() method restores the state of the trackers from the tracker
+// data of the Checkpoint, unless the tracker data is in the tracker store of
+// the scan, which already holds the state. The pending keys of the restored
+// trackers are then re-queued with the resetPending() method. If the
+// Checkpoint is nil, then the trackers are reset, such that a tracker store
+// does not keep the keys of a previous scan.
+func (s *Scanner) restoreTrackers(cpoint *Checkpoint) error {
+	if cpoint == nil {
+		cpoint = &Checkpoint{}
+	} else if cpoint.TrackerStore != "" && s.tracker_store != nil {
+		s.logger.Info().Msgf("resuming from tracker store %s", cpoint.TrackerStore)
+		return s.resetPending()
+	}
+	if err := s.TrackerCommits.Restore(cpoint.TrackerCommitsData); err != nil {
+		return err
+	}
+	if err := s.TrackerFiles.Restore(cpoint.TrackerFilesData); err != nil {
+		return err
+	}
+	if err := s.TrackerRequests.Restore(cpoint.TrackerRequestsData); err != nil {
+		return err
+	}
+	return s.resetPending()
+}
+
+// resetPending() method moves the pending keys of each tracker back to the
+// init state, which re-queues the work that was in flight when the Checkpoint
+// was saved, i.e. the commits and files that were not done are scanned again,
+// and their requests without a response are sent again, since the responses
+// to those requests are never received by the resumed scan.
+func (s *Scanner) resetPending() error {
+	for _, t := range []tracker.Tracker{s.TrackerCommits, s.TrackerFiles, s.TrackerRequests} {
+		count, err := t.ResetPending()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			s.logger.Info().Msgf("KIND=%s : re-queued %d pending key(s) from checkpoint", t.GetKind(), count)
+		}
+	}
+	return nil
 }
 
 // checkpointScan() method is intended to be run as a separate goroutine
-// to periodically checkpoint the progress of the scan.
+// to periodically checkpoint the progress of the scan. The Checkpoint holds
+// the keys that are pending at the time, e.g. requests without a response,
+// which are re-queued by restoreTrackers() when the scan is resumed.
 func (s *Scanner) checkpointScan(
 	repo_id string,
 	commit_id string,
@@ -159,16 +428,39 @@ func (s *Scanner) checkpointScan(
 
 	setNewCheckpoint := func() (e error) {
 		// store the scan progress in a Checkpoint file
+		var cpoint *Checkpoint
+		if s.tracker_store != nil {
+			// only write the keys that changed since the previous Checkpoint
+			// to the tracker store, which the Checkpoint then references
+			var generation int64
+			generation, e = tracker.Flush(s.tracker_store, s.TrackerCommits, s.TrackerFiles, s.TrackerRequests)
+			if e != nil {
+				return
+			}
+			cpoint = NewCheckpoint(tracker.KeyDataMap{}, tracker.KeyDataMap{}, tracker.KeyDataMap{})
+			cpoint.TrackerGeneration = generation
+			cpoint.TrackerStore = s.tracker_store.GetPath()
+		} else {
+			cpoint = NewCheckpoint(
+				s.TrackerCommits.GetKeysData(),
+				s.TrackerFiles.GetKeysData(),
+				s.TrackerRequests.GetKeysData(),
+			)
+		}
+		cpoint.Fingerprint = s.fingerprint
+		cpoint.Head = s.head
+		// copy the occurrences after the tracker data, such that the
+		// occurrences found at every commit that is complete are included
+		cpoint.Occurrences = s.occurrences.GetAll()
+		if e = cpoint.SetResults(s.result_io); e != nil {
+			return
+		}
 		e = CheckpointSet(
 			s.ctx,
-			s.git_config.WorkDir,
+			s.checkpoint_store,
 			repo_id,
 			commit_id,
-			NewCheckpoint(
-				s.TrackerCommits.GetKeysData(),
-				s.TrackerFiles.GetKeysData(),
-				s.TrackerRequests.GetKeysData(),
-			),
+			cpoint,
 		)
 		if e != nil {
 			return
@@ -176,9 +468,10 @@ func (s *Scanner) checkpointScan(
 		return
 	}
 
-	// set the first Checkpoint before starting (and waiting for) the ticker
+	// set the first Checkpoint before starting (and waiting for) the ticker,
+	// where a failed Checkpoint does not affect the results of the scan
 	if err := setNewCheckpoint(); err != nil {
-		chan_errors_out <- errors.Wrap(err, ErrMsgCheckpointScanProgress)
+		s.sendError(chan_errors_out, NewRecoverableError(errors.Wrap(err, ErrMsgCheckpointScanProgress)))
 	}
 
 	// create a ticker to periodically trigger a refresh of the scan checkpoint
@@ -189,10 +482,12 @@ func (s *Scanner) checkpointScan(
 		case <-timer.C:
 			// store the scan progress in a Checkpoint file
 			if err := setNewCheckpoint(); err != nil {
-				chan_errors_out <- errors.Wrap(err, ErrMsgCheckpointScanProgress)
+				s.sendError(chan_errors_out, NewRecoverableError(errors.Wrap(err, ErrMsgCheckpointScanProgress)))
 			}
 		case <-chan_quit_in:
 			return
+		case <-s.ctx.Done():
+			return
 		}
 	}
 }
@@ -212,20 +507,30 @@ func (s *Scanner) processCommits(wg_main *sync.WaitGroup) {
 			commit.Hash.String(),
 		)
 
-		// get the tree of objects associated with the commit
+		// add the commit itself as a child of the commit while its tree is
+		// scanned, such that the responses for the files that were already
+		// scanned cannot complete the commit before every file of the tree
+		// has been added as a child of the commit
+		s.TrackerCommits.Update(
+			commit.Hash.String(),
+			tracker.KeyCodePending,
+			"",
+			[]string{commit.Hash.String()},
+		)
+
+		// get the tree of objects associated with the commit, where a commit
+		// whose tree cannot be read does not stop the scan of other commits
 		tree, err := commit.Tree()
 		if err != nil {
-			_, err = s.TrackerCommits.Update(
+			err = errors.Wrapf(err, ErrMsgScanCommitTree, commit.Hash.String())
+			s.TrackerCommits.Update(
 				commit.Hash.String(),
 				tracker.KeyCodeError,
 				err.Error(),
 				[]string{},
 			)
-			if err != nil {
-				err = errors.Wrapf(err, ErrMsgTrackerUpdateCommit, commit.Hash.String())
-				s.chan_errors <- err
-				return
-			}
+			s.sendError(s.chan_errors, NewRecoverableError(err))
+			return
 		}
 
 		// iterate through the files in the commit tree
@@ -238,7 +543,7 @@ func (s *Scanner) processCommits(wg_main *sync.WaitGroup) {
 				err.Error(),
 				[]string{},
 			)
-			s.chan_errors <- err
+			s.sendError(s.chan_errors, NewRecoverableError(err))
 			return
 		}
 
@@ -249,7 +554,7 @@ func (s *Scanner) processCommits(wg_main *sync.WaitGroup) {
 			commit.Hash.String(),
 			tracker.KeyCodeComplete,
 			"",
-			[]string{},
+			[]string{commit.Hash.String()},
 		)
 	}
 	for commit := range s.chan_commits {
@@ -259,7 +564,10 @@ func (s *Scanner) processCommits(wg_main *sync.WaitGroup) {
 	wg_loop.Wait()
 }
 
-// processErrors() method processes errors generated by the scan.
+// processErrors() method processes errors generated by the scan, where each
+// error is recorded in the summary of the scan, and the ErrorPolicy of the
+// Scanner determines whether the scan continues after the error. An error
+// that stops the scan is sent to chan_errors_out before the channel is closed.
 func (s *Scanner) processErrors(
 	chan_quit_in <-chan struct{},
 	chan_errors_in <-chan error,
@@ -275,45 +583,109 @@ func (s *Scanner) processErrors(
 			close(chan_errors_out)
 			return
 		case e := <-chan_errors_in:
-			if e != nil {
-				err_wrap_msg := "error running scanner"
-				s.logger.Error().Err(e).Msg(err_wrap_msg)
-				// handle error to determine if the scanner should continue
-				// TODO
-				chan_errors_out <- e
-				close(chan_errors_out)
-				return
+			if e == nil {
+				continue
+			}
+			recoverable := s.scan_errors.Add(e)
+			if !s.error_policy.Stop(e, recoverable) {
+				s.logger.Warn().Err(e).Msgf("scanner continuing after recoverable error %d", recoverable)
+				continue
 			}
+			if IsRecoverable(e) {
+				e = errors.Wrapf(e, ErrMsgErrorPolicyExceeded, recoverable)
+			}
+			err_wrap_msg := "error running scanner"
+			s.logger.Error().Err(e).Msg(err_wrap_msg)
+			s.scan_errors.Stop(e)
+			s.sendError(chan_errors_out, e)
+			close(chan_errors_out)
 			return
 		}
 	}
 }
 
+// RequestsOutstanding() method returns the number of requests that have been
+// sent for external processing without receiving a response.
+func (s *Scanner) RequestsOutstanding() int {
+	return len(s.request_slots)
+}
+
+// acquireRequestSlot() method blocks until fewer than the configured maximum
+// number of requests are outstanding, then acquires a slot for one request.
+// Returns false if the quit signal is received before a slot is acquired.
+func (s *Scanner) acquireRequestSlot(chan_quit_in <-chan struct{}) bool {
+	select {
+	case s.request_slots <- struct{}{}:
+		return true
+	default:
+	}
+	s.logger.Trace().Msgf("waiting for a request slot : %d requests outstanding", len(s.request_slots))
+	select {
+	case <-chan_quit_in:
+		return false
+	case s.request_slots <- struct{}{}:
+		return true
+	}
+}
+
+// releaseRequestSlot() method releases the slot held by an outstanding
+// request without blocking.
+func (s *Scanner) releaseRequestSlot() {
+	select {
+	case <-s.request_slots:
+	default:
+	}
+}
+
+// sendError() method sends the error to chan_errors_out, unless the context
+// of the Scanner is done first, such that a goroutine of a scan that was
+// stopped is not blocked forever on sending an error nobody receives.
+func (s *Scanner) sendError(chan_errors_out chan<- error, err error) {
+	select {
+	case <-s.ctx.Done():
+	case chan_errors_out <- err:
+	}
+}
+
 // processRequest() method processes a single request for internal tracking
-// purposes before sending the request for external processing.
+// purposes before sending the request for external processing, where the
+// request is only sent once a request slot is available.
 func (s *Scanner) processRequest(
 	r rrr.Request,
+	chan_quit_in <-chan struct{},
 	chan_requests_out chan<- rrr.Request,
 	chan_errors_out chan<- error,
 ) {
 	// validate the request
 	if r.ID == "" {
-		chan_errors_out <- ErrProcessRequestNoID
+		s.sendError(chan_errors_out, ErrProcessRequestNoID)
 		return
 	}
-	// check if the request is already being tracked
-	if _, exists := s.TrackerRequests.Get(r.ID); exists {
+	// check if the request is already being tracked, where a request in the
+	// init state is sent again, e.g. a request that was re-queued when the
+	// scan was resumed from a Checkpoint
+	if request_data, exists := s.TrackerRequests.Get(r.ID); exists && request_data.Code > tracker.KeyCodeInit {
 		s.logger.Debug().Msgf("skipping processing for existing request ID=%s", r.ID)
 		return
 	}
+	// wait until the number of outstanding requests is below the limit,
+	// which blocks the scan from generating more requests in the meantime
+	if !s.acquireRequestSlot(chan_quit_in) {
+		return
+	}
 	// update TrackerRequests to track the ID of the pending request
 	_, err := s.TrackerRequests.Update(r.ID, tracker.KeyCodePending, "", []string{})
 	if err != nil {
-		chan_errors_out <- err
+		s.releaseRequestSlot()
+		s.sendError(chan_errors_out, NewRecoverableError(err))
 		return
 	}
 	// send the request for external processing
-	chan_requests_out <- r
+	select {
+	case <-chan_quit_in:
+		s.releaseRequestSlot()
+	case chan_requests_out <- r:
+	}
 }
 
 // processRequests() method processes requests for documents generated by
@@ -333,10 +705,10 @@ func (s *Scanner) processRequests(
 		case <-chan_quit_in:
 			return
 		case r := <-chan_requests_in:
-			// keep the input channel clear by processing the request in the
-			// background via a separate goroutine, which sends any errors to
-			// chan_errors_out
-			s.processRequest(r, chan_requests_out, chan_errors_out)
+			// process the request before reading the next request, such that
+			// the scan is blocked from generating more requests while the
+			// limit of outstanding requests is reached
+			s.processRequest(r, chan_quit_in, chan_requests_out, chan_errors_out)
 		}
 	}
 }
@@ -348,7 +720,7 @@ func (s *Scanner) processResponse(
 ) {
 	// validate the response
 	if r.ID == "" {
-		chan_errors_out <- ErrProcessResponseNoID
+		s.sendError(chan_errors_out, ErrProcessResponseNoID)
 		return
 	}
 	// log the response
@@ -362,20 +734,49 @@ func (s *Scanner) processResponse(
 	)
 	// write the result(s) to the result_io store
 	if len(r.Results) > 0 {
+		// record every commit and path at which the object has been found
+		// so far, which is updated when the scan is complete
+		s.occurrences.SetFindings(r.Object.ID)
+		if occurrences := s.occurrences.Get(r.Object.ID); len(occurrences) > 0 {
+			r.SetOccurrences(occurrences)
+		}
 		// convert the response to a slice of rrr.ResultRecords, where
 		// each rrr.ResultRecord is uniquely identified by its SHA1 hash
 		result_records := rrr.ResultRecordsFromResponse(&r)
 		if err := s.result_io.Write(result_records); err != nil {
-			chan_errors_out <- errors.Wrap(err, ErrMsgResultWriteFailed)
+			s.sendError(chan_errors_out, errors.Wrap(err, ErrMsgResultWriteFailed))
 		}
 	}
-	// update TrackerRequests to mark the associated request (ID) as complete
-	s.TrackerRequests.Update(r.ID, tracker.KeyCodeComplete, "", []string{})
+	// release the request slot held by the associated request, unless the
+	// request is no longer pending, e.g. due to a duplicate response
+	request_data, request_exists := s.TrackerRequests.Get(r.ID)
+	if request_exists && request_data.Code == tracker.KeyCodePending {
+		s.releaseRequestSlot()
+	}
+	// ignore the error of a duplicate response for a completed request
+	if r.Error != nil && !(request_exists && request_data.Code == tracker.KeyCodeComplete) {
+		// mark the associated request (ID) as failed when the detector
+		// reports an error for the request, e.g. due to an API error
+		s.logger.Warn().Msgf(
+			"detector failed request ID = %s : Commit.ID = %s : Object.ID = %s : %s : %s",
+			r.ID,
+			r.Commit.ID,
+			r.Object.ID,
+			r.Error.Code,
+			r.Error.Message,
+		)
+		s.TrackerRequests.Fail(r.ID, r.Error.Code+" : "+r.Error.Message)
+		s.failed.Add(NewFailedChunk(&r))
+	} else {
+		// update TrackerRequests to mark the associated request (ID) as complete
+		s.TrackerRequests.Update(r.ID, tracker.KeyCodeComplete, "", []string{})
+	}
 
 	// update the tracker for the associated File object to mark this
-	// request/response as complete. if all requests/responses for a
-	// File object are complete, the File object should be marked as
-	// tracker.KeyCodeComplete.
+	// request/response as done. if all requests/responses for a File
+	// object are done, the File object should be marked as
+	// tracker.KeyCodeComplete, or as tracker.KeyCodeError if any of
+	// its requests failed.
 	var file_update_code int
 	var update_err error
 	file_update_code, update_err = s.TrackerFiles.Update(
@@ -385,24 +786,39 @@ func (s *Scanner) processResponse(
 		[]string{r.ID},
 	)
 	if update_err != nil {
-		chan_errors_out <- update_err
+		s.sendError(chan_errors_out, NewRecoverableError(update_err))
 	}
-	// only update the associated commit if file_update_code is tracker.KeyCodeComplete.
 	if file_update_code == tracker.KeyCodeComplete {
-		_, update_err = s.TrackerCommits.Update(
+		file_update_code = propagateChildErrors(s.TrackerFiles, r.Object.ID, s.TrackerRequests)
+	}
+	// only update the associated commit if the File object is done
+	if file_update_code == tracker.KeyCodeComplete || file_update_code == tracker.KeyCodeError {
+		// the occurrences of a File object without results are not needed
+		// after the File object is done
+		s.occurrences.Done(r.Object.ID)
+		var commit_update_code int
+		commit_update_code, update_err = s.TrackerCommits.Update(
 			r.Commit.ID,
 			tracker.KeyCodeComplete,
 			"",
 			[]string{r.Object.ID},
 		)
 		if update_err != nil {
-			chan_errors_out <- update_err
+			s.sendError(chan_errors_out, NewRecoverableError(update_err))
+		}
+		if commit_update_code == tracker.KeyCodeComplete {
+			propagateChildErrors(s.TrackerCommits, r.Commit.ID, s.TrackerFiles)
 		}
 	}
 }
 
 // processResponses() method processes all responses for requests generated by
-// the scan.
+// the scan, one at a time and in the order in which they are received, until
+// the quit signal is received. Each response is processed by processResponse(),
+// which writes the results of the response, releases the request slot of the
+// request, and updates the trackers of the request, file, and commit. Any error
+// of a response is sent to chan_errors_out, i.e. to processErrors(), where the
+// ErrorPolicy of the Scanner determines whether the scan is stopped.
 func (s *Scanner) processResponses(
 	chan_quit_in <-chan struct{},
 	chan_responses_in <-chan rrr.Response,
@@ -417,9 +833,8 @@ func (s *Scanner) processResponses(
 		case <-chan_quit_in:
 			return
 		case r := <-chan_responses_in:
-			// keep the input channel clear by processing the response in the
-			// background via a separate goroutine, which sends any errors to
-			// chan_errors_out
+			// process the response before receiving the next response, such
+			// that the trackers are updated in the order of the responses
 			s.processResponse(r, chan_errors_out)
 		}
 	}
@@ -454,13 +869,13 @@ func (s *Scanner) reconcilePending() {
 				s.logger.Error().Msgf("error getting request ID=%s", request_id)
 				continue
 			}
-			if request_data.Code == tracker.KeyCodeComplete {
+			if request_data.Code == tracker.KeyCodeComplete || request_data.Code == tracker.KeyCodeError {
 				requests_complete = append(requests_complete, request_id)
 			}
 		}
 		if len(requests_complete) > 0 {
 			// update the tracker for the file to mark the requests as complete
-			_, update_err := s.TrackerFiles.Update(
+			update_code, update_err := s.TrackerFiles.Update(
 				file_key,
 				tracker.KeyCodeComplete,
 				"",
@@ -469,6 +884,9 @@ func (s *Scanner) reconcilePending() {
 			if update_err != nil {
 				s.logger.Error().Err(update_err).Msg("error updating file tracker")
 			}
+			if update_code == tracker.KeyCodeComplete {
+				propagateChildErrors(s.TrackerFiles, file_key, s.TrackerRequests)
+			}
 		}
 	}
 
@@ -484,7 +902,9 @@ func (s *Scanner) reconcilePending() {
 		var files_complete []string
 		// iterate over the file children of the commit
 		for file_key, is_complete := range commit_key_data.Children {
-			if is_complete {
+			// skip the commit itself, i.e. the tree of the commit is still
+			// being scanned
+			if is_complete || file_key == commit_key {
 				continue
 			}
 			// get the tracker.KeyData for the pending file ID/key
@@ -493,13 +913,13 @@ func (s *Scanner) reconcilePending() {
 				s.logger.Error().Msgf("error getting file ID=%s", file_key)
 				continue
 			}
-			if file_data.Code == tracker.KeyCodeComplete {
+			if file_data.Code == tracker.KeyCodeComplete || file_data.Code == tracker.KeyCodeError {
 				files_complete = append(files_complete, file_key)
 			}
 		}
 		if len(files_complete) > 0 {
 			// update the tracker for the commit to mark the files as complete
-			_, update_err := s.TrackerCommits.Update(
+			update_code, update_err := s.TrackerCommits.Update(
 				commit_key,
 				tracker.KeyCodeComplete,
 				"",
@@ -508,6 +928,9 @@ func (s *Scanner) reconcilePending() {
 			if update_err != nil {
 				s.logger.Error().Err(update_err).Msg("error updating commit tracker")
 			}
+			if update_code == tracker.KeyCodeComplete {
+				propagateChildErrors(s.TrackerCommits, commit_key, s.TrackerFiles)
+			}
 		}
 	}
 }
@@ -535,8 +958,13 @@ func (s *Scanner) scanCommit(commit *object.Commit) error {
 		return nil
 	}
 
-	// send the commit to the channel for processing
-	s.chan_commits <- commit
+	// send the commit to the channel for processing, unless the scan was
+	// stopped, which stops the iteration of the commits
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case s.chan_commits <- commit:
+	}
 
 	return nil
 }
@@ -544,6 +972,7 @@ func (s *Scanner) scanCommit(commit *object.Commit) error {
 // scanFile() method returns an anonymous function that can be used to iterate through
 // the files in the associated commit tree and scan each file for PHI/PII entities.
 func (s *Scanner) scanFile(commit *object.Commit) func(*object.File) error {
+	commit_metadata := rrr.NewMetadataCommit(commit)
 	return func(file *object.File) error {
 		code, err := s.TrackerFiles.Update(
 			file.Hash.String(),
@@ -554,6 +983,15 @@ func (s *Scanner) scanFile(commit *object.Commit) func(*object.File) error {
 		if err != nil {
 			return errors.Wrapf(err, ErrMsgScanTrackerUpdateFile, file.Hash.String())
 		}
+		// record the occurrence of the file before skipping files that have
+		// already been scanned, such that results can list all occurrences,
+		// unless the scan of the file is done without results
+		if code == tracker.KeyCodeInit || code == tracker.KeyCodePending || s.occurrences.HasFindings(file.Hash.String()) {
+			s.occurrences.Add(file.Hash.String(), rrr.MetadataRequestResponseOccurrence{
+				Commit: commit_metadata,
+				Path:   file.Name,
+			})
+		}
 		// skip files that have already been scanned
 		if code > tracker.KeyCodeInit {
 			s.logger.Trace().Msgf(
@@ -609,23 +1047,11 @@ func (s *Scanner) scanFile(commit *object.Commit) func(*object.File) error {
 		})
 		if r_err != nil {
 			s.logger.Error().Err(r_err).Msgf("commit %s : failed to generate requests for file %s", commit.Hash.String(), file.Hash.String())
-			s.TrackerFiles.Update(
-				file.Hash.String(),
-				tracker.KeyCodeError,
-				r_err.Error(),
-				[]string{},
-			)
-			return r_err
+			return s.failFile(commit, file, r_err)
 		}
 		// any zero-size file should have been ignored by the IgnoreFileObject() function
 		if len(requests) == 0 && file.Size > 0 {
 			err = errors.New("no requests generated for file ID=" + file.Hash.String())
-			s.TrackerFiles.Update(
-				file.Hash.String(),
-				tracker.KeyCodeError,
-				err.Error(),
-				[]string{},
-			)
 			s.logger.Warn().Msgf(
 				"commit %s : %s : Name=%s : size=%d",
 				commit.Hash.String(),
@@ -633,13 +1059,18 @@ func (s *Scanner) scanFile(commit *object.Commit) func(*object.File) error {
 				file.Name,
 				file.Size,
 			)
-			return err
+			return s.failFile(commit, file, err)
 		}
 		var child_keys []string
 		// send each request to the channel for processing
 		for _, req := range requests {
+			req.Commit = commit_metadata
 			child_keys = append(child_keys, req.ID)
-			s.chan_requests <- req
+			select {
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			case s.chan_requests <- req:
+			}
 		}
 		// update tracker to mark the scan of this file as "pending"
 		_, err = s.TrackerFiles.Update(
@@ -666,6 +1097,34 @@ func (s *Scanner) scanFile(commit *object.Commit) func(*object.File) error {
 	}
 }
 
+// failFile() method marks the file as failed, and adds the file to the
+// children of the commit such that the commit is marked as failed once the
+// scan of the commit is done. The error is sent to the errors channel as a
+// recoverable error, which does not stop the scan of the other files in the
+// tree of the commit.
+func (s *Scanner) failFile(commit *object.Commit, file *object.File, err error) error {
+	_, update_err := s.TrackerFiles.Update(
+		file.Hash.String(),
+		tracker.KeyCodeError,
+		err.Error(),
+		[]string{},
+	)
+	if update_err != nil {
+		return errors.Wrapf(update_err, ErrMsgScanTrackerUpdateFile, file.Hash.String())
+	}
+	_, update_err = s.TrackerCommits.Update(
+		commit.Hash.String(),
+		tracker.KeyCodePending,
+		"",
+		[]string{file.Hash.String()},
+	)
+	if update_err != nil {
+		return errors.Wrapf(update_err, ErrMsgTrackerUpdateCommit, commit.Hash.String())
+	}
+	s.sendError(s.chan_errors, NewRecoverableError(errors.Wrapf(err, ErrMsgScanFile, file.Name, file.Hash.String())))
+	return nil
+}
+
 // scanRepository() method scans the repositories defined in the git config
 // and sends the results to the requests channel. If an error occurs during
 // the scan, the error is sent to the error channel.
@@ -683,7 +1142,8 @@ func (s *Scanner) scanRepository(
 		s.logger.Panic().Msg(ErrMsgErrorChannelNil)
 	}
 	if repository == nil {
-		errors_out <- ErrScannerRepositoryNil
+		s.sendError(errors_out, ErrScannerRepositoryNil)
+		return
 	}
 
 	s.scan_mutex.Lock()
@@ -695,13 +1155,14 @@ func (s *Scanner) scanRepository(
 	go s.checkpointScan(repo_url, "", done, s.chan_errors)
 
 	var e error
-	// get an iterator for the commits in the repository
+	// get an iterator for the commits to scan in the configured scan mode
 	var commit_iterator object.CommitIter
-	commit_iterator, e = s.repository.CommitObjects()
+	commit_iterator, e = s.commitIterator()
 	if e != nil {
 		if commit_iterator != nil {
 			commit_iterator.Close()
 		}
+		s.sendError(errors_out, errors.Wrapf(e, "failed to get commits to scan in repository %s", s.URL))
 		return
 	}
 	defer commit_iterator.Close()
@@ -715,7 +1176,7 @@ func (s *Scanner) scanRepository(
 	e = commit_iterator.ForEach(s.scanCommit)
 	if e != nil {
 		// wrap the error and send it to the errors channel
-		errors_out <- errors.Wrapf(e, "failed to iterate through commits in repository %s", s.URL)
+		s.sendError(errors_out, errors.Wrapf(e, "failed to iterate through commits in repository %s", s.URL))
 		//return // TODO: should we return here?
 	}
 	// close the channel for commits to signal the processCommits goroutine
@@ -728,6 +1189,74 @@ func (s *Scanner) scanRepository(
 	s.is_scan_complete = true
 }
 
+// classifyResults() method classifies each result record by whether the
+// result is still present in the tree of the tip commit of the scanned
+// history, and writes the classified result records to the result_io store.
+func (s *Scanner) classifyResults() error {
+	records, err := s.result_io.List()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgResultClassify)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	classifier, err := head.NewClassifier(s.repository, s.tipRevision())
+	if err != nil {
+		return errors.Wrap(err, ErrMsgResultClassify)
+	}
+	updates := make([]rrr.ResultRecord, 0, len(records))
+	for _, record := range records {
+		record_head, err := classifier.Classify(record)
+		if err != nil {
+			s.logger.Warn().Err(err).Msgf("failed to classify result record %s", record.Hash)
+			continue
+		}
+		if reflect.DeepEqual(record.Head, record_head) {
+			continue
+		}
+		record.Head = record_head
+		updates = append(updates, record)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	s.logger.Debug().Msgf("classified %d result records", len(updates))
+	if err := s.result_io.Write(updates); err != nil {
+		return errors.Wrap(err, ErrMsgResultClassify)
+	}
+	return nil
+}
+
+// updateResultOccurrences() method updates the occurrences of result records
+// for objects that were found at additional commits or paths after the
+// results of the object were written to the result_io store.
+func (s *Scanner) updateResultOccurrences() error {
+	records, err := s.result_io.List()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgResultOccurrencesUpdate)
+	}
+	updates := make([]rrr.ResultRecord, 0)
+	for _, record := range records {
+		occurrences := s.occurrences.Get(record.Object.ID)
+		if len(occurrences) == 0 {
+			continue
+		}
+		before := len(record.Occurrences)
+		record.SetOccurrences(append(occurrences, record.Occurrences...))
+		if len(record.Occurrences) != before {
+			updates = append(updates, record)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	s.logger.Debug().Msgf("updating occurrences of %d result records", len(updates))
+	if err := s.result_io.Write(updates); err != nil {
+		return errors.Wrap(err, ErrMsgResultOccurrencesUpdate)
+	}
+	return nil
+}
+
 // trackScanProgress() method tracks the progress of the scan by periodically
 // checking if all requests have been completed. If the scan is complete, the
 // method returns. If the scan is not complete, the method continues to track
@@ -778,9 +1307,27 @@ func (s *Scanner) trackScanProgress(
 		}
 		s.logger.Debug().Msgf("tracking scan : cleaning up scan for repository %s", s.URL)
 
-		// remove the checkpoint file when tracking indicates the scan is complete
-		if err := CheckpointDelete(s.ctx, s.git_config.WorkDir, s.URL, ""); err != nil {
-			s.logger.Error().Err(err).Msg("Scanner failed to delete Checkpoint file")
+		// record occurrences found after the results of an object were written
+		if err := s.updateResultOccurrences(); err != nil {
+			s.logger.Error().Err(err).Msg("Scanner failed to update result occurrences")
+		}
+		// classify results by whether they are still present at the tip
+		if err := s.classifyResults(); err != nil {
+			s.logger.Error().Err(err).Msg("Scanner failed to classify results")
+		}
+
+		// save the failed chunks of the scan, if any, such that the chunks can
+		// be retried, or else remove the failed chunks of a previous scan
+		if err := s.saveFailedChunks(); err != nil {
+			s.logger.Error().Err(err).Msg("Scanner failed to save failed chunks")
+		}
+
+		// remove the checkpoint file when tracking indicates the scan is complete,
+		// unless only the failed chunks of a previous scan were retried
+		if !s.is_retry {
+			if err := CheckpointDelete(s.ctx, s.checkpoint_store, s.URL, ""); err != nil {
+				s.logger.Error().Err(err).Msg("Scanner failed to delete Checkpoint file")
+			}
 		}
 
 		// print the scan counts again before actually cleaning up
@@ -799,6 +1346,11 @@ func (s *Scanner) trackScanProgress(
 
 	for {
 		select {
+		case <-s.ctx.Done():
+			// stop the other goroutines of the scan when the scan is stopped
+			s.logger.Warn().Msgf("tracking scan : context done for repository %s", s.URL)
+			close(quit_out)
+			return
 		case <-timer.C:
 			// print tracker counts, then wait for the next tick
 			if trackScanCounts() {
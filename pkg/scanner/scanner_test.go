@@ -5,11 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-git/go-billy/v5/memfs"
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	gitmemory "github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/dryrun"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
 	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
@@ -77,6 +80,16 @@ func TestNewScanner(t *testing.T) {
 			err_expected: false,
 			name:         "Config_Missing_AzureAIAuthKey",
 		},
+		{
+			config_func: func() *cfg.GitConfig {
+				c := test_valid_git_config_func()
+				c.Scan.ErrorPolicy = "max-errors=-1"
+				return c
+			},
+			ctx:          test_context,
+			err_expected: true,
+			name:         "Config_Invalid_ErrorPolicy",
+		},
 		{
 			config_func:  test_valid_git_config_func,
 			ctx:          test_context,
@@ -103,7 +116,8 @@ func TestNewScanner(t *testing.T) {
 			if !assert.NotNilf(t, s, test_failed_msg, test.name) {
 				assert.FailNowf(t, "failed to create scanner : %s", err.Error())
 			}
-			assert.NotEqualf(t, "", s.ID, test_failed_msg, test.name)
+			// the ID of the repository is only set when the scan is started
+			assert.Equalf(t, "", s.ID, test_failed_msg, test.name)
 			if !assert.NotNil(t, s.chan_commits, "Scanner.chan_commits should not be nil") {
 				assert.FailNow(t, "Scanner.chan_commits should not be nil")
 			}
@@ -227,6 +241,64 @@ func TestScanner_processRequests(t *testing.T) {
 	time.Sleep(time.Millisecond) // Sleep for a short duration to allow goroutine to exit
 }
 
+// TestScanner_processRequests_MaxRequestsOutstanding() unit test function
+// tests that the processRequests method of the Scanner object type blocks new
+// requests while the limit of outstanding requests is reached, and that each
+// response releases a slot for another request.
+func TestScanner_processRequests_MaxRequestsOutstanding(t *testing.T) {
+	t.Parallel()
+
+	const max_outstanding = 2
+	config := test_valid_git_config_func()
+	config.Scan.Limits.MaxRequestsOutstanding = max_outstanding
+	s, s_err := NewScanner(
+		test_context,
+		config,
+		memory.NewMemoryResultRecordIO(test_context),
+	)
+	if !assert.NoErrorf(t, s_err, test_failed_msg, "ProcessRequests_MaxRequestsOutstanding") {
+		assert.FailNowf(t, "failed to create scanner : %s", s_err.Error())
+	}
+
+	chan_quit := make(chan struct{})
+	defer close(chan_quit)
+	chan_requests_in := make(chan rrr.Request)
+	chan_requests_out := make(chan rrr.Request, max_outstanding+1)
+	chan_errors_out := make(chan error, 10)
+
+	go s.processRequests(chan_quit, chan_requests_in, chan_requests_out, chan_errors_out)
+
+	newRequest := func(id string) rrr.Request {
+		return rrr.Request{
+			MetadataRequestResponse: rrr.MetadataRequestResponse{
+				ID:     id,
+				Commit: rrr.MetadataRequestResponseCommit{ID: "commit-1"},
+				Object: rrr.MetadataRequestResponseObject{ID: "object-1"},
+			},
+		}
+	}
+	for _, id := range []string{"request-1", "request-2", "request-3"} {
+		chan_requests_in <- newRequest(id)
+	}
+	// only the first requests are sent while the limit is reached
+	assert.Eventually(t, func() bool { return len(chan_requests_out) == max_outstanding }, time.Second, time.Millisecond)
+	assert.Never(t, func() bool { return len(chan_requests_out) > max_outstanding }, 50*time.Millisecond, time.Millisecond)
+	assert.Equal(t, max_outstanding, s.RequestsOutstanding())
+
+	// a response for an outstanding request releases a slot
+	first := <-chan_requests_out
+	second := <-chan_requests_out
+	assert.Equal(t, "request-2", second.ID)
+	s.processResponse(rrr.NewResponse(&first), chan_errors_out)
+	third := <-chan_requests_out
+	assert.Equal(t, "request-3", third.ID)
+	assert.Equal(t, max_outstanding, s.RequestsOutstanding())
+
+	// a duplicate response does not release another slot
+	s.processResponse(rrr.NewResponse(&first), chan_errors_out)
+	assert.Equal(t, max_outstanding, s.RequestsOutstanding())
+}
+
 // TestScanner_processResponse unit test function tests the processResponse method of the Scanner object type.
 func TestScanner_processResponse(t *testing.T) {
 	t.Parallel()
@@ -333,6 +405,50 @@ func TestScanner_processResponse(t *testing.T) {
 	}
 }
 
+// TestScanner_processResponse_Error() unit test function tests that the
+// processResponse() method marks the request of an error response as failed
+// without blocking the completion of the associated file.
+func TestScanner_processResponse_Error(t *testing.T) {
+	t.Parallel()
+
+	s, s_err := NewScanner(
+		test_context,
+		test_valid_git_config_func(),
+		memory.NewMemoryResultRecordIO(test_context),
+	)
+	if !assert.NoError(t, s_err) {
+		assert.FailNow(t, "failed to create scanner")
+	}
+
+	request, request_err := rrr.NewRequest(rrr.NewRequestInput{
+		CommitID: "commit_id",
+		Length:   len("test_text_example"),
+		ObjectID: "object_id",
+		Offset:   0,
+		RepoID:   test_repo_url,
+		Text:     "test_text_example",
+	})
+	if !assert.NoError(t, request_err) {
+		assert.FailNow(t, "failed to create test request")
+	}
+	s.TrackerFiles.Update(request.Object.ID, tracker.KeyCodePending, "", []string{request.ID})
+	s.TrackerRequests.Update(request.ID, tracker.KeyCodePending, "", []string{})
+
+	chan_errors_out := make(chan error, 10)
+	s.processResponse(rrr.NewErrorResponse(&request, "InvalidDocument", "test error"), chan_errors_out)
+	assert.Empty(t, chan_errors_out)
+
+	request_data, exists := s.TrackerRequests.Get(request.ID)
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeError, request_data.Code)
+	assert.Contains(t, request_data.Message, "InvalidDocument")
+
+	file_data, exists := s.TrackerFiles.Get(request.Object.ID)
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeError, file_data.Code)
+	assert.True(t, s.TrackerRequests.CheckAllComplete())
+}
+
 // TestScanner_processResponses() unit test function tests the
 // processResponses() method of the Scanner object type.
 func TestScanner_processResponses(t *testing.T) {
@@ -431,3 +547,147 @@ func TestScanner_scanRepository(t *testing.T) {
 		})
 	}
 }
+
+// TestScanner_Scan_ContextCanceled() unit test function tests that the Scan()
+// method returns, i.e. that the goroutines of the scan are stopped, when the
+// context of the Scanner is canceled while requests are still outstanding.
+func TestScanner_Scan_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(test_context)
+	defer cancel()
+	config := test_valid_git_config_func()
+	config.WorkDir = t.TempDir()
+	s, s_err := NewScanner(ctx, config, memory.NewMemoryResultRecordIO(test_context))
+	if !assert.NoError(t, s_err) {
+		t.FailNow()
+	}
+	repository, _ := testModeRepository(t)
+
+	// the detector receives the first request, then never responds
+	chan_requests := make(chan rrr.Request)
+	chan_scan_returned := make(chan struct{})
+	go func() {
+		defer close(chan_scan_returned)
+		s.Scan(ScanInput{
+			ChanErrorsSend:      make(chan error),
+			ChanRequestSend:     chan_requests,
+			ChanResponseReceive: make(chan rrr.Response),
+			RepoID:              test_repo_url,
+			Repository:          repository,
+		})
+	}()
+	select {
+	case <-chan_requests:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "timed out waiting for the first request of the scan")
+	}
+
+	cancel()
+	select {
+	case <-chan_scan_returned:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "timed out waiting for the scan to return after the context was canceled")
+	}
+}
+
+// testRunScan() helper function runs a scan of the repository with the dry
+// run detector, which returns one result for each request, and returns the
+// error of the scan once the scan is complete.
+func testRunScan(t *testing.T, s *Scanner, repo_url string, repository *git.Repository) error {
+	ctx, cancel := context.WithCancel(test_context)
+	defer cancel()
+
+	chan_errors := make(chan error)
+	chan_requests := make(chan rrr.Request)
+	chan_responses := make(chan rrr.Response)
+	go dryrun.NewDryRunPhiDetector().Run(ctx, chan_requests, chan_responses)
+	go s.Scan(ScanInput{
+		ChanErrorsSend:      chan_errors,
+		ChanRequestSend:     chan_requests,
+		ChanResponseReceive: chan_responses,
+		Detector:            cfg.DetectorDryRun,
+		RepoID:              repo_url,
+		Repository:          repository,
+	})
+	select {
+	case err := <-chan_errors:
+		return err
+	case <-time.After(30 * time.Second):
+		assert.FailNow(t, "timed out waiting for the scan to complete")
+		return nil
+	}
+}
+
+// TestScanner_Scan_RepositoryID() unit test function tests that two scans of
+// the same repository, via different URLs of the repository, produce the same
+// result records, which are matched by a RepositoryID filter with either the
+// URL or the "<org>/<repo>" name of the repository.
+func TestScanner_Scan_RepositoryID(t *testing.T) {
+	t.Parallel()
+
+	// use a repository with a single commit, such that each scan attributes
+	// each file to the same commit
+	repository, err := git.Init(gitmemory.NewStorage(), memfs.New())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	worktree, err := repository.Worktree()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for _, name := range []string{"a.md", "b.md"} {
+		file, err := worktree.Filesystem.Create(name)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		file.Write([]byte("file " + name))
+		file.Close()
+		_, err = worktree.Add(name)
+		assert.NoError(t, err)
+	}
+	_, err = worktree.Commit("add files", &git.CommitOptions{
+		Author: &object.Signature{Email: "dev@example.com", Name: "dev", When: time.Unix(1700000000, 0)},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	result_io := memory.NewMemoryResultRecordIO(test_context)
+	repo_urls := []string{test_repo_url, "https://github.com/" + test_repo_org + "/" + test_repo_name}
+	var hashes []string
+	for _, repo_url := range repo_urls {
+		config := test_valid_git_config_func()
+		config.WorkDir = t.TempDir()
+		s, err := NewScanner(test_context, config, result_io)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, testRunScan(t, s, repo_url, repository)) {
+			t.FailNow()
+		}
+		assert.Equal(t, "blackiq/learn", s.ID)
+
+		// the second scan writes the same result records as the first scan
+		records, err := result_io.List()
+		assert.NoError(t, err)
+		scan_hashes := make([]string, 0, len(records))
+		for _, record := range records {
+			scan_hashes = append(scan_hashes, record.Hash)
+		}
+		if hashes == nil {
+			hashes = scan_hashes
+			assert.Len(t, hashes, 2)
+		}
+		assert.ElementsMatch(t, hashes, scan_hashes)
+
+		for _, repository_id := range append(repo_urls, "blackiq/learn") {
+			page, err := result_io.Query(rrr.ResultRecordFilter{RepositoryID: repository_id})
+			assert.NoError(t, err)
+			assert.Equal(t, len(hashes), page.Total, repository_id)
+		}
+		page, err := result_io.Query(rrr.ResultRecordFilter{RepositoryID: "https://github.com/other/learn"})
+		assert.NoError(t, err)
+		assert.Zero(t, page.Total)
+	}
+}
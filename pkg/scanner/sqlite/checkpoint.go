@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+)
+
+const CheckpointFileName string = "checkpoints" + FileExtension
+
+// checkpoint_schema is the SQL used to create the checkpoints table, where
+// each checkpoint is stored in the same (compressed) format as the data of
+// a checkpoint file.
+const checkpoint_schema string = `
+CREATE TABLE IF NOT EXISTS checkpoints (
+	key        TEXT PRIMARY KEY,
+	created_at INTEGER NOT NULL,
+	data       BLOB NOT NULL
+);
+`
+
+// SQLiteCheckpointStore struct provides an implementation of the
+// scanner.CheckpointStore interface that stores the checkpoints of all
+// repositories in a SQLite database file, where each checkpoint is replaced
+// in a single transaction.
+type SQLiteCheckpointStore struct {
+	scanner.CheckpointStore
+
+	db     *sql.DB
+	logger *zerolog.Logger
+	path   string
+}
+
+// NewSQLiteCheckpointStore() function initializes a new SQLiteCheckpointStore
+// object backed by the database file at the given path, creating the file
+// and the checkpoints table if they do not already exist. If the path is
+// empty, then the file "<work_dir>/checkpoints/checkpoints.db" is used.
+func NewSQLiteCheckpointStore(ctx context.Context, work_dir, path string) (*SQLiteCheckpointStore, error) {
+	if path == "" {
+		path = filepath.Join(work_dir, cfg.WorkDirCheckpoints, CheckpointFileName)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteCheckpointStoreCreate)
+	}
+	db, err := sql.Open(DriverName, path)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteCheckpointStoreCreate)
+	}
+	// SQLite only supports a single writer at a time
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, checkpoint_schema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, ErrMsgSQLiteCheckpointStoreCreate)
+	}
+	return &SQLiteCheckpointStore{
+		db:     db,
+		logger: zerolog.Ctx(ctx),
+		path:   path,
+	}, nil
+}
+
+// Close() method closes the database of the sqlite checkpoint store.
+func (cs *SQLiteCheckpointStore) Close() error {
+	return cs.db.Close()
+}
+
+// Delete() method deletes the checkpoint with matching key from the sqlite
+// checkpoint store.
+func (cs *SQLiteCheckpointStore) Delete(key string) error {
+	cs.logger.Debug().Msgf("deleting checkpoint key=%s from sqlite store", key)
+	if _, err := cs.db.Exec(`DELETE FROM checkpoints WHERE key = ?`, key); err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteCheckpointStoreDelete)
+	}
+	return nil
+}
+
+// Get() method returns the checkpoint with matching key from the sqlite
+// checkpoint store. Returns scanner.ErrCheckpointNotFound if no checkpoint
+// exists for the key.
+func (cs *SQLiteCheckpointStore) Get(key string) (*scanner.Checkpoint, error) {
+	cs.logger.Debug().Msgf("reading checkpoint key=%s from sqlite store", key)
+	var data []byte
+	err := cs.db.QueryRow(`SELECT data FROM checkpoints WHERE key = ?`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, scanner.ErrCheckpointNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteCheckpointStoreRead)
+	}
+	return scanner.DecodeCheckpoint(data)
+}
+
+// GetPath() method returns the path of the database file of the sqlite
+// checkpoint store.
+func (cs *SQLiteCheckpointStore) GetPath() string {
+	return cs.path
+}
+
+// Set() method writes the checkpoint to the sqlite checkpoint store,
+// replacing any checkpoint that already exists with the same key.
+func (cs *SQLiteCheckpointStore) Set(key string, c *scanner.Checkpoint) error {
+	cs.logger.Debug().Msgf("writing checkpoint key=%s to sqlite store", key)
+	var data bytes.Buffer
+	if err := scanner.EncodeCheckpoint(&data, c); err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteCheckpointStoreWrite)
+	}
+	_, err := cs.db.Exec(
+		`INSERT INTO checkpoints (key, created_at, data) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			created_at = excluded.created_at,
+			data = excluded.data`,
+		key,
+		c.CreatedAt,
+		data.Bytes(),
+	)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteCheckpointStoreWrite)
+	}
+	return nil
+}
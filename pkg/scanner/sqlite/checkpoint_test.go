@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestSQLiteCheckpointStore() unit test function tests setting, getting, and
+// deleting checkpoints, and that checkpoints are persisted when the store is
+// reopened.
+func TestSQLiteCheckpointStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	work_dir := t.TempDir()
+	cs, err := NewSQLiteCheckpointStore(ctx, work_dir, "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, filepath.Join(work_dir, "checkpoints", CheckpointFileName), cs.GetPath())
+
+	_, err = cs.Get("org_repo")
+	assert.ErrorIs(t, err, scanner.ErrCheckpointNotFound)
+
+	for _, commit_id := range []string{"commit-1", "commit-2"} {
+		key_data, err := tracker.NewKeyData(tracker.KeyCodeComplete, "", []string{})
+		assert.NoError(t, err)
+		c := scanner.NewCheckpoint(
+			tracker.KeyDataMap{commit_id: key_data},
+			tracker.KeyDataMap{},
+			tracker.KeyDataMap{},
+		)
+		assert.NoError(t, cs.Set("org_repo", c))
+	}
+	assert.NoError(t, cs.Close())
+
+	// reopen the store to ensure the checkpoint was persisted
+	cs, err = NewSQLiteCheckpointStore(ctx, work_dir, "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer cs.Close()
+	c, err := cs.Get("org_repo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, scanner.CheckpointVersion, c.Version)
+		assert.Contains(t, c.TrackerCommitsData, "commit-2")
+		assert.NotContains(t, c.TrackerCommitsData, "commit-1")
+	}
+
+	assert.NoError(t, cs.Delete("org_repo"))
+	_, err = cs.Get("org_repo")
+	assert.ErrorIs(t, err, scanner.ErrCheckpointNotFound)
+	assert.NoError(t, cs.Delete("org_repo"))
+}
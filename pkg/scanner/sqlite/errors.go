@@ -0,0 +1,25 @@
+package sqlite
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgSQLiteCheckpointStoreCreate = "failed to create sqlite checkpoint store"
+	ErrMsgSQLiteCheckpointStoreDelete = "failed to delete from sqlite checkpoint store"
+	ErrMsgSQLiteCheckpointStoreRead   = "failed to read from sqlite checkpoint store"
+	ErrMsgSQLiteCheckpointStoreWrite  = "failed to write to sqlite checkpoint store"
+	ErrMsgSQLiteKeyStoreCreate        = "failed to create sqlite tracker store"
+	ErrMsgSQLiteKeyStoreRead          = "failed to read from sqlite tracker store"
+	ErrMsgSQLiteKeyStoreWrite         = "failed to write to sqlite tracker store"
+	ErrMsgSQLiteResultRecordIOCreate  = "failed to create sqlite store"
+	ErrMsgSQLiteResultRecordIODelete  = "failed to delete from sqlite store"
+	ErrMsgSQLiteResultRecordIOList    = "failed to list sqlite store"
+	ErrMsgSQLiteResultRecordIOQuery   = "failed to query sqlite store"
+	ErrMsgSQLiteResultRecordIORead    = "failed to read from sqlite store"
+	ErrMsgSQLiteResultRecordIOWrite   = "failed to write to sqlite store"
+)
+
+var (
+	ErrSQLiteResultRecordIODeleteEmptyID = errors.New("sqlite store failed to delete result record : empty ID")
+	ErrSQLiteResultRecordIOReadEmptyID   = errors.New("sqlite store failed to read result record : empty ID")
+	ErrSQLiteResultRecordIOReadFailed    = errors.New("sqlite store failed to read result record")
+)
@@ -0,0 +1,326 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	_ "modernc.org/sqlite"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+const DriverName string = "sqlite"
+const FileExtension string = ".db"
+
+// schema is the SQL used to create the results table, where the complete
+// result record is stored as JSON and the commonly filtered fields are
+// stored in their own (indexed) columns.
+const schema string = `
+CREATE TABLE IF NOT EXISTS results (
+	hash             TEXT PRIMARY KEY,
+	repository_id    TEXT NOT NULL,
+	commit_id        TEXT NOT NULL,
+	object_id        TEXT NOT NULL,
+	category         TEXT NOT NULL,
+	subcategory      TEXT NOT NULL,
+	confidence_score REAL NOT NULL,
+	service          TEXT NOT NULL,
+	time_start       INTEGER NOT NULL,
+	time_stop        INTEGER NOT NULL,
+	record           TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_category ON results (category);
+CREATE INDEX IF NOT EXISTS results_commit_id ON results (commit_id);
+CREATE INDEX IF NOT EXISTS results_object_id ON results (object_id);
+CREATE INDEX IF NOT EXISTS results_repository_id ON results (repository_id);
+`
+
+// SQLiteResultRecordIO struct provides an implementation of the
+// ResultRecordIO interface that stores result records in a SQLite database
+// file, such that results are persisted across runs of the app and can be
+// queried with standard SQL tools.
+type SQLiteResultRecordIO struct {
+	rrr.ResultRecordIO
+
+	db     *sql.DB
+	logger *zerolog.Logger
+	path   string
+}
+
+// NewSQLiteResultRecordIO() function initializes a new SQLiteResultRecordIO
+// object backed by the database file at the given path, creating the file
+// and the results table if they do not already exist.
+func NewSQLiteResultRecordIO(ctx context.Context, path string) (*SQLiteResultRecordIO, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOCreate)
+	}
+	db, err := sql.Open(DriverName, path)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOCreate)
+	}
+	// SQLite only supports a single writer at a time
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOCreate)
+	}
+	return &SQLiteResultRecordIO{
+		db:     db,
+		logger: zerolog.Ctx(ctx),
+		path:   path,
+	}, nil
+}
+
+// NewSQLiteResultRecordIOForRepo() function initializes a new
+// SQLiteResultRecordIO object backed by the database file
+// "<work_dir>/results/<org>_<repo>.db".
+func NewSQLiteResultRecordIOForRepo(ctx context.Context, work_dir, repo_url string) (*SQLiteResultRecordIO, error) {
+	name, err := nogit.ParseOrgRepoNameFromURL(repo_url)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOCreate)
+	}
+	return NewSQLiteResultRecordIO(ctx, filepath.Join(work_dir, cfg.WorkDirResults, name+FileExtension))
+}
+
+// Close() method closes the database of the sqlite store.
+func (io *SQLiteResultRecordIO) Close() error {
+	return io.db.Close()
+}
+
+// Delete() method deletes the result with matching id from the sqlite store.
+func (io *SQLiteResultRecordIO) Delete(id string) error {
+	if id == "" {
+		return ErrSQLiteResultRecordIODeleteEmptyID
+	}
+	io.logger.Debug().Msgf("deleting result id=%s from sqlite store", id)
+
+	if _, err := io.db.Exec(`DELETE FROM results WHERE hash = ?`, id); err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteResultRecordIODelete)
+	}
+	return nil
+}
+
+// GetPath() method returns the path of the database file of the sqlite store.
+func (io *SQLiteResultRecordIO) GetPath() string {
+	return io.path
+}
+
+// List() method returns a list of all results in the sqlite store, in the
+// order in which they were first written.
+func (io *SQLiteResultRecordIO) List() ([]rrr.ResultRecord, error) {
+	io.logger.Debug().Msg("listing results from sqlite store")
+
+	rows, err := io.db.Query(`SELECT record FROM results ORDER BY rowid`)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOList)
+	}
+	defer rows.Close()
+
+	out := make([]rrr.ResultRecord, 0)
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOList)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteResultRecordIOList)
+	}
+	return out, nil
+}
+
+// Query() method returns the page of results in the sqlite store that match
+// the filter, in the order in which they were first written.
+func (io *SQLiteResultRecordIO) Query(filter rrr.ResultRecordFilter) (rrr.ResultRecordPage, error) {
+	io.logger.Debug().Msgf("querying results from sqlite store : %+v", filter)
+	if err := filter.Validate(); err != nil {
+		return rrr.ResultRecordPage{}, err
+	}
+	where, args := whereClause(filter)
+
+	page := rrr.ResultRecordPage{Records: make([]rrr.ResultRecord, 0)}
+	if err := io.db.QueryRow(`SELECT COUNT(*) FROM results`+where, args...).Scan(&page.Total); err != nil {
+		return rrr.ResultRecordPage{}, errors.Wrap(err, ErrMsgSQLiteResultRecordIOQuery)
+	}
+
+	// a negative limit returns all remaining rows in SQLite
+	limit := filter.Limit
+	if limit == 0 {
+		limit = -1
+	}
+	rows, err := io.db.Query(
+		`SELECT record FROM results`+where+` ORDER BY rowid LIMIT ? OFFSET ?`,
+		append(args, limit, filter.Offset)...,
+	)
+	if err != nil {
+		return rrr.ResultRecordPage{}, errors.Wrap(err, ErrMsgSQLiteResultRecordIOQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return rrr.ResultRecordPage{}, errors.Wrap(err, ErrMsgSQLiteResultRecordIOQuery)
+		}
+		page.Records = append(page.Records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return rrr.ResultRecordPage{}, errors.Wrap(err, ErrMsgSQLiteResultRecordIOQuery)
+	}
+	return page, nil
+}
+
+// Read() method returns the result with matching id from the sqlite store.
+// Returns a non-nil error if unable to find a result with matching id.
+func (io *SQLiteResultRecordIO) Read(id string) (rrr.ResultRecord, error) {
+	if id == "" {
+		return rrr.ResultRecord{}, ErrSQLiteResultRecordIOReadEmptyID
+	}
+	io.logger.Debug().Msgf("reading result id=%s from sqlite store", id)
+
+	r, err := scanRecord(io.db.QueryRow(`SELECT record FROM results WHERE hash = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return rrr.ResultRecord{}, ErrSQLiteResultRecordIOReadFailed
+	}
+	if err != nil {
+		return rrr.ResultRecord{}, errors.Wrap(err, ErrMsgSQLiteResultRecordIORead)
+	}
+	return r, nil
+}
+
+// Write() method writes the slice of results to the sqlite store in a single
+// transaction, replacing any results that already exist with the same hash.
+// Returns a non-nil error if unable to write any result to the store.
+func (io *SQLiteResultRecordIO) Write(result_records []rrr.ResultRecord) (e error) {
+	io.logger.Debug().Msgf("writing %d result(s) to sqlite store", len(result_records))
+	if len(result_records) == 0 {
+		return nil
+	}
+
+	tx, err := io.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteResultRecordIOWrite)
+	}
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	statement, err := tx.Prepare(`
+		INSERT INTO results (
+			hash, repository_id, commit_id, object_id, category,
+			subcategory, confidence_score, service, time_start, time_stop,
+			record
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (hash) DO UPDATE SET
+			repository_id = excluded.repository_id,
+			commit_id = excluded.commit_id,
+			object_id = excluded.object_id,
+			category = excluded.category,
+			subcategory = excluded.subcategory,
+			confidence_score = excluded.confidence_score,
+			service = excluded.service,
+			time_start = excluded.time_start,
+			time_stop = excluded.time_stop,
+			record = excluded.record
+	`)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteResultRecordIOWrite)
+	}
+	defer statement.Close()
+
+	for _, r := range result_records {
+		record, err := json.Marshal(r)
+		if err != nil {
+			return errors.Wrap(err, ErrMsgSQLiteResultRecordIOWrite)
+		}
+		if _, err := statement.Exec(
+			r.Hash,
+			r.Repository.ID,
+			r.Commit.ID,
+			r.Object.ID,
+			r.Category,
+			r.Subcategory,
+			r.ConfidenceScore,
+			r.Service,
+			r.Time.Start,
+			r.Time.Stop,
+			string(record),
+		); err != nil {
+			return errors.Wrap(err, ErrMsgSQLiteResultRecordIOWrite)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteResultRecordIOWrite)
+	}
+	return nil
+}
+
+// whereClause() function returns the SQL WHERE clause and its arguments for
+// the conditions of the filter, or an empty clause if the filter matches all
+// result records.
+func whereClause(filter rrr.ResultRecordFilter) (string, []any) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+	add := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+	if filter.Category != "" {
+		add("category = ?", filter.Category)
+	}
+	if filter.CommitID != "" {
+		add("commit_id = ?", filter.CommitID)
+	}
+	if filter.MinConfidenceScore > 0 {
+		add("confidence_score >= ?", filter.MinConfidenceScore)
+	}
+	if filter.ObjectID != "" {
+		add("object_id = ?", filter.ObjectID)
+	}
+	if filter.RepositoryID != "" {
+		add("repository_id = ?", rrr.RepositoryID(filter.RepositoryID))
+	}
+	if filter.Subcategory != "" {
+		add("subcategory = ?", filter.Subcategory)
+	}
+	if filter.TimeStart != 0 {
+		add("time_start >= ?", filter.TimeStart)
+	}
+	if filter.TimeStop != 0 {
+		add("time_stop <= ?", filter.TimeStop)
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// rowScanner interface is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRecord() function decodes the JSON record column of the current row.
+func scanRecord(row rowScanner) (rrr.ResultRecord, error) {
+	var record string
+	if err := row.Scan(&record); err != nil {
+		return rrr.ResultRecord{}, err
+	}
+	var r rrr.ResultRecord
+	if err := json.Unmarshal([]byte(record), &r); err != nil {
+		return rrr.ResultRecord{}, err
+	}
+	return r, nil
+}
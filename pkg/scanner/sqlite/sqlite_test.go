@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testResultRecord() helper function returns a result record with the
+// given hash for use in tests.
+func testResultRecord(hash string) rrr.ResultRecord {
+	return rrr.ResultRecord{
+		Hash: hash,
+		MetadataRequestResponse: rrr.MetadataRequestResponse{
+			ID:         "request-" + hash,
+			Repository: rrr.MetadataRequestResponseRepository{ID: "https://github.com/org/repo.git"},
+		},
+		Result: rrr.Result{Category: "Email", ConfidenceScore: 0.9, Length: 5, Offset: 1},
+	}
+}
+
+// TestSQLiteResultRecordIO() unit test function tests writing, reading,
+// listing, and deleting result records, and that the result records are
+// persisted when the store is reopened.
+func TestSQLiteResultRecordIO(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "results", "org_repo.db")
+
+	io, err := NewSQLiteResultRecordIO(ctx, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, path, io.GetPath())
+	assert.Implements(t, (*rrr.PersistentResultRecordIO)(nil), io)
+
+	assert.NoError(t, io.Write([]rrr.ResultRecord{
+		testResultRecord("hash-1"),
+		testResultRecord("hash-2"),
+	}))
+	// writing an existing result record replaces it
+	assert.NoError(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-1")}))
+	assert.NoError(t, io.Write(nil))
+
+	r, err := io.Read("hash-2")
+	assert.NoError(t, err)
+	assert.Equal(t, testResultRecord("hash-2"), r)
+
+	_, err = io.Read("")
+	assert.ErrorIs(t, err, ErrSQLiteResultRecordIOReadEmptyID)
+	_, err = io.Read("missing")
+	assert.ErrorIs(t, err, ErrSQLiteResultRecordIOReadFailed)
+
+	assert.ErrorIs(t, io.Delete(""), ErrSQLiteResultRecordIODeleteEmptyID)
+	assert.NoError(t, io.Delete("hash-2"))
+	assert.NoError(t, io.Write([]rrr.ResultRecord{testResultRecord("hash-3")}))
+	assert.NoError(t, io.Close())
+
+	// reopen the store and check that the result records were persisted
+	reopened, err := NewSQLiteResultRecordIO(ctx, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer reopened.Close()
+
+	records, err := reopened.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []rrr.ResultRecord{
+		testResultRecord("hash-1"),
+		testResultRecord("hash-3"),
+	}, records)
+}
+
+// TestSQLiteResultRecordIO_Query() unit test function tests the Query() method of the
+// SQLiteResultRecordIO struct.
+func TestSQLiteResultRecordIO_Query(t *testing.T) {
+	t.Parallel()
+
+	io, err := NewSQLiteResultRecordIO(context.TODO(), filepath.Join(t.TempDir(), "org_repo.db"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer io.Close()
+
+	records := make([]rrr.ResultRecord, 0)
+	for i, category := range []string{"Email", "Person", "Email", "Email"} {
+		r := testResultRecord(fmt.Sprintf("hash-%d", i))
+		r.Category = category
+		r.Commit.ID = fmt.Sprintf("commit-%d", i%2)
+		r.ConfidenceScore = 0.5 + float64(i)/10
+		r.Time = rrr.MetadataRequestResponseTime{Start: int64(i * 100), Stop: int64(i*100 + 10)}
+		records = append(records, r)
+	}
+	if !assert.NoError(t, io.Write(records)) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		expected_hashes []string
+		expected_total  int
+		filter          rrr.ResultRecordFilter
+		name            string
+	}{
+		{[]string{"hash-0", "hash-1", "hash-2", "hash-3"}, 4, rrr.ResultRecordFilter{}, "empty filter"},
+		{[]string{"hash-0", "hash-2", "hash-3"}, 3, rrr.ResultRecordFilter{Category: "Email"}, "category"},
+		{[]string{"hash-1", "hash-3"}, 2, rrr.ResultRecordFilter{CommitID: "commit-1"}, "commit"},
+		{[]string{"hash-2", "hash-3"}, 2, rrr.ResultRecordFilter{MinConfidenceScore: 0.7}, "min confidence"},
+		{[]string{}, 0, rrr.ResultRecordFilter{RepositoryID: "other"}, "repository"},
+		{[]string{"hash-1", "hash-2"}, 2, rrr.ResultRecordFilter{TimeStart: 100, TimeStop: 210}, "time range"},
+		{[]string{"hash-2"}, 3, rrr.ResultRecordFilter{Category: "Email", Limit: 1, Offset: 1}, "page"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := io.Query(test.filter)
+			if !assert.NoError(t, err) {
+				return
+			}
+			hashes := make([]string, 0)
+			for _, r := range page.Records {
+				hashes = append(hashes, r.Hash)
+			}
+			assert.Equal(t, test.expected_hashes, hashes)
+			assert.Equal(t, test.expected_total, page.Total)
+		})
+	}
+
+	_, err = io.Query(rrr.ResultRecordFilter{Offset: -1})
+	assert.ErrorIs(t, err, rrr.ErrResultRecordFilterInvalid)
+}
@@ -0,0 +1,338 @@
+package sqlite
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	nogit "github.com/Pii-Hole-Engineering/no-phi-ai/pkg/client/no-git"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// tracker_schema is the SQL used to create the tables of the tracker store,
+// where the KeyData of each key is stored as JSON, and the generation table
+// holds a single row with the generation of the last write of the store.
+const tracker_schema string = `
+CREATE TABLE IF NOT EXISTS keys (
+	kind TEXT NOT NULL,
+	key  TEXT NOT NULL,
+	data TEXT NOT NULL,
+	PRIMARY KEY (kind, key)
+) WITHOUT ROWID;
+CREATE TABLE IF NOT EXISTS generation (
+	id         INTEGER PRIMARY KEY CHECK (id = 0),
+	generation INTEGER NOT NULL
+);
+`
+
+// TrackerCacheSize is the maximum number of keys of the sqlite tracker store
+// that are cached in memory, such that a key that is looked up repeatedly,
+// e.g. the commit of each file in the tree of the commit, only queries the
+// database once.
+const TrackerCacheSize int = 10000
+
+// SQLiteKeyStore struct provides an implementation of the tracker.KeyStore
+// interface that stores the keys of the trackers of a scan in a SQLite
+// database file, such that the keys do not need to fit in memory. The most
+// recently used keys are cached in memory, including keys that do not exist.
+type SQLiteKeyStore struct {
+	tracker.KeyStore
+
+	cache  *keyCache
+	db     *sql.DB
+	logger *zerolog.Logger
+	path   string
+}
+
+// keyCacheEntry struct is the cached JSON data of a key of a kind, where the
+// data is empty if the key does not exist.
+type keyCacheEntry struct {
+	data string
+	kind string
+	key  string
+}
+
+// keyCache struct is a least recently used cache of the keys of the sqlite
+// tracker store, which holds no more than size keys. The version of the
+// cache is incremented by each write of the store, such that a key that is
+// read from the database during a write is not cached.
+type keyCache struct {
+	entries map[string]*list.Element
+	mutex   *sync.Mutex
+	order   *list.List
+	size    int
+	version uint64
+}
+
+// newKeyCache() function initializes a new, empty keyCache that holds no
+// more than size keys.
+func newKeyCache(size int) *keyCache {
+	return &keyCache{
+		entries: make(map[string]*list.Element),
+		mutex:   &sync.Mutex{},
+		order:   list.New(),
+		size:    size,
+	}
+}
+
+// get() method returns the cached JSON data of the key of the kind, and false
+// if the key is not cached, along with the current version of the cache.
+func (c *keyCache) get(kind, key string) (string, bool, uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, cached := c.entries[keyCacheID(kind, key)]
+	if !cached {
+		return "", false, c.version
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*keyCacheEntry).data, true, c.version
+}
+
+// fill() method caches the JSON data of the key of the kind, as read from the
+// database at the given version of the cache, unless the store was written
+// since then.
+func (c *keyCache) fill(kind, key, data string, version uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.version == version {
+		c.put(kind, key, data)
+	}
+}
+
+// write() method removes every cached key of the kinds in reset, and then
+// caches the JSON data of the written keys of each kind, once the write of
+// the store is committed.
+func (c *keyCache) write(reset []string, written map[string]map[string]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.version++
+	for _, kind := range reset {
+		for element := c.order.Front(); element != nil; {
+			next := element.Next()
+			if entry := element.Value.(*keyCacheEntry); entry.kind == kind {
+				c.order.Remove(element)
+				delete(c.entries, keyCacheID(entry.kind, entry.key))
+			}
+			element = next
+		}
+	}
+	for kind, kdm := range written {
+		for key, data := range kdm {
+			c.put(kind, key, data)
+		}
+	}
+}
+
+// put() method caches the JSON data of the key of the kind, and removes the
+// least recently used key if the cache is full. The caller must hold the lock
+// of the cache.
+func (c *keyCache) put(kind, key, data string) {
+	id := keyCacheID(kind, key)
+	if element, cached := c.entries[id]; cached {
+		element.Value.(*keyCacheEntry).data = data
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[id] = c.order.PushFront(&keyCacheEntry{data: data, kind: kind, key: key})
+	if c.order.Len() > c.size {
+		entry := c.order.Remove(c.order.Back()).(*keyCacheEntry)
+		delete(c.entries, keyCacheID(entry.kind, entry.key))
+	}
+}
+
+// keyCacheID() function returns the ID of the key of the kind in the cache.
+func keyCacheID(kind, key string) string {
+	return kind + "\x00" + key
+}
+
+// NewSQLiteKeyStore() function initializes a new SQLiteKeyStore object backed
+// by the database file at the given path, creating the file and the tables
+// if they do not already exist.
+func NewSQLiteKeyStore(ctx context.Context, path string) (*SQLiteKeyStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteKeyStoreCreate)
+	}
+	db, err := sql.Open(DriverName, path)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteKeyStoreCreate)
+	}
+	// SQLite only supports a single writer at a time
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, tracker_schema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, ErrMsgSQLiteKeyStoreCreate)
+	}
+	return &SQLiteKeyStore{
+		cache:  newKeyCache(TrackerCacheSize),
+		db:     db,
+		logger: zerolog.Ctx(ctx),
+		path:   path,
+	}, nil
+}
+
+// NewSQLiteKeyStoreForRepo() function initializes a new SQLiteKeyStore object
+// backed by the database file "<work_dir>/trackers/<org>_<repo>.db".
+func NewSQLiteKeyStoreForRepo(ctx context.Context, work_dir, repo_url string) (*SQLiteKeyStore, error) {
+	name, err := nogit.ParseOrgRepoNameFromURL(repo_url)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgSQLiteKeyStoreCreate)
+	}
+	return NewSQLiteKeyStore(ctx, filepath.Join(work_dir, cfg.WorkDirTrackers, name+FileExtension))
+}
+
+// Close() method closes the database of the sqlite tracker store.
+func (ks *SQLiteKeyStore) Close() error {
+	return ks.db.Close()
+}
+
+// Generation() method returns the generation of the last write of the sqlite
+// tracker store, or 0 if the store was never written.
+func (ks *SQLiteKeyStore) Generation() (int64, error) {
+	var generation int64
+	err := ks.db.QueryRow(`SELECT generation FROM generation WHERE id = 0`).Scan(&generation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+	}
+	return generation, nil
+}
+
+// Get() method returns the KeyData of the key of the kind from the cache or
+// else the sqlite tracker store, and false if the key does not exist.
+func (ks *SQLiteKeyStore) Get(kind, key string) (key_data tracker.KeyData, exists bool, e error) {
+	data, cached, version := ks.cache.get(kind, key)
+	if !cached {
+		err := ks.db.QueryRow(`SELECT data FROM keys WHERE kind = ? AND key = ?`, kind, key).Scan(&data)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			e = errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+			return
+		}
+		ks.cache.fill(kind, key, data, version)
+	}
+	if data == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(data), &key_data); err != nil {
+		e = errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+		return
+	}
+	exists = true
+	return
+}
+
+// GetPath() method returns the path of the database file of the sqlite
+// tracker store.
+func (ks *SQLiteKeyStore) GetPath() string {
+	return ks.path
+}
+
+// Range() method calls fn for each key of the kind in the sqlite tracker
+// store, and stops at the first error returned by fn.
+func (ks *SQLiteKeyStore) Range(kind string, fn func(key string, key_data tracker.KeyData) error) error {
+	rows, err := ks.db.Query(`SELECT key, data FROM keys WHERE kind = ?`, kind)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, data string
+		if err := rows.Scan(&key, &data); err != nil {
+			return errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+		}
+		var key_data tracker.KeyData
+		if err := json.Unmarshal([]byte(data), &key_data); err != nil {
+			return errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+		}
+		if err := fn(key, key_data); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, ErrMsgSQLiteKeyStoreRead)
+	}
+	return nil
+}
+
+// Write() method deletes every key of the kinds in reset from the sqlite
+// tracker store, then writes the keys of every kind and sets the generation
+// of the store, in a single transaction, replacing any keys that already
+// exist.
+func (ks *SQLiteKeyStore) Write(data map[string]tracker.KeyDataMap, reset []string, generation int64) (e error) {
+	tx, err := ks.db.Begin()
+	if err != nil {
+		e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+		return
+	}
+	defer func() {
+		if e != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, kind := range reset {
+		ks.logger.Debug().Msgf("deleting keys of kind=%s from sqlite tracker store", kind)
+		if _, err := tx.Exec(`DELETE FROM keys WHERE kind = ?`, kind); err != nil {
+			e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+			return
+		}
+	}
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO keys (kind, key, data) VALUES (?, ?, ?)
+		ON CONFLICT (kind, key) DO UPDATE SET data = excluded.data`,
+	)
+	if err != nil {
+		e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+		return
+	}
+	defer stmt.Close()
+
+	written := make(map[string]map[string]string, len(data))
+	var count int
+	for kind, kdm := range data {
+		written[kind] = make(map[string]string, len(kdm))
+		for key, key_data := range kdm {
+			data_json, err := json.Marshal(key_data)
+			if err != nil {
+				e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+				return
+			}
+			if _, err := stmt.Exec(kind, key, string(data_json)); err != nil {
+				e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+				return
+			}
+			written[kind][key] = string(data_json)
+			count++
+		}
+	}
+	_, err = tx.Exec(
+		`INSERT INTO generation (id, generation) VALUES (0, ?)
+		ON CONFLICT (id) DO UPDATE SET generation = excluded.generation`,
+		generation,
+	)
+	if err != nil {
+		e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		e = errors.Wrap(err, ErrMsgSQLiteKeyStoreWrite)
+		return
+	}
+	// only update the cache once the transaction is committed
+	ks.cache.write(reset, written)
+	ks.logger.Debug().Msgf("wrote %d key(s) to sqlite tracker store at generation %d", count, generation)
+	return
+}
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestSQLiteKeyStore() unit test function tests that the keys of the trackers
+// that keep their keys in the store are written incrementally and persisted
+// when the store is reopened.
+func TestSQLiteKeyStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	work_dir := t.TempDir()
+	ks, err := NewSQLiteKeyStoreForRepo(ctx, work_dir, "https://github.com/org/repo.git")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Implements(t, (*tracker.KeyStore)(nil), ks)
+	assert.Equal(t, filepath.Join(work_dir, "trackers", "org_repo"+FileExtension), ks.GetPath())
+	generation, err := ks.Generation()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), generation)
+
+	dt, err := tracker.NewDiskKeyTracker(tracker.ScanObjectTypeFile, &logger, ks)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = dt.Update("file-1", tracker.KeyCodePending, "", []string{"request-1"})
+	assert.NoError(t, err)
+	_, err = dt.Update("file-2", tracker.KeyCodeInit, "", []string{})
+	assert.NoError(t, err)
+	generation, err = tracker.Flush(ks, dt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), generation)
+	_, err = dt.Update("file-1", tracker.KeyCodeComplete, "", []string{"request-1"})
+	assert.NoError(t, err)
+	_, err = tracker.Flush(ks, dt)
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Close())
+
+	// reopen the store to ensure the keys were persisted
+	ks, err = NewSQLiteKeyStoreForRepo(ctx, work_dir, "https://github.com/org/repo.git")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer ks.Close()
+	generation, err = ks.Generation()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), generation)
+	key_data, exists, err := ks.Get(tracker.ScanObjectTypeFile, "file-1")
+	if assert.NoError(t, err) && assert.True(t, exists) {
+		assert.Equal(t, tracker.KeyCodeComplete, key_data.Code)
+		assert.Equal(t, map[string]bool{"request-1": true}, key_data.Children)
+	}
+	_, exists, err = ks.Get(tracker.ScanObjectTypeCommit, "file-1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	dt, err = tracker.NewDiskKeyTracker(tracker.ScanObjectTypeFile, &logger, ks)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tracker.KeyDataCounts{Complete: 1, Init: 1}, dt.GetCounts())
+	}
+
+	// restoring the tracker replaces its keys and sets a new generation
+	key_data, _ = tracker.NewKeyData(tracker.KeyCodeComplete, "", []string{})
+	assert.NoError(t, dt.Restore(tracker.KeyDataMap{"file-3": key_data}))
+	generation, err = ks.Generation()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), generation)
+	_, exists, err = ks.Get(tracker.ScanObjectTypeFile, "file-1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	_, exists, err = ks.Get(tracker.ScanObjectTypeFile, "file-3")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+// TestSQLiteKeyStore_Get_Cache() unit test function tests that the keys read
+// from the sqlite tracker store are cached, including keys that do not exist,
+// and that the cache is updated by each write of the store.
+func TestSQLiteKeyStore_Get_Cache(t *testing.T) {
+	t.Parallel()
+
+	ks, err := NewSQLiteKeyStore(context.Background(), filepath.Join(t.TempDir(), "trackers"+FileExtension))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer ks.Close()
+
+	key_data, _ := tracker.NewKeyData(tracker.KeyCodeComplete, "", []string{})
+	assert.NoError(t, ks.Write(map[string]tracker.KeyDataMap{tracker.ScanObjectTypeFile: {"file-1": key_data}}, nil, 1))
+	_, exists, err := ks.Get(tracker.ScanObjectTypeFile, "file-2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the cached keys are read without querying the database
+	_, err = ks.db.Exec(`DELETE FROM keys`)
+	assert.NoError(t, err)
+	_, err = ks.db.Exec(`INSERT INTO keys (kind, key, data) VALUES (?, ?, ?)`, tracker.ScanObjectTypeFile, "file-2", `{"code":2}`)
+	assert.NoError(t, err)
+	_, exists, err = ks.Get(tracker.ScanObjectTypeFile, "file-1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	_, exists, err = ks.Get(tracker.ScanObjectTypeFile, "file-2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// a write that resets the kind removes the cached keys of the kind
+	assert.NoError(t, ks.Write(map[string]tracker.KeyDataMap{}, []string{tracker.ScanObjectTypeFile}, 2))
+	_, exists, err = ks.Get(tracker.ScanObjectTypeFile, "file-1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// Test_keyCache() unit test function tests that the keyCache removes the least
+// recently used key when full, and does not cache a key read from the database
+// during a write of the store.
+func Test_keyCache(t *testing.T) {
+	t.Parallel()
+
+	c := newKeyCache(2)
+	c.put("kind", "key-1", "data-1")
+	c.put("kind", "key-2", "data-2")
+	_, cached, version := c.get("kind", "key-1")
+	assert.True(t, cached)
+	c.put("kind", "key-3", "data-3")
+	_, cached, _ = c.get("kind", "key-2")
+	assert.False(t, cached)
+
+	c.write(nil, map[string]map[string]string{"kind": {"key-1": "data-4"}})
+	c.fill("kind", "key-2", "", version)
+	_, cached, _ = c.get("kind", "key-2")
+	assert.False(t, cached)
+	data, cached, _ := c.get("kind", "key-1")
+	assert.True(t, cached)
+	assert.Equal(t, "data-4", data)
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/s3"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/sqlite"
+)
+
+// NewCheckpointStore() function creates the scanner.CheckpointStore selected
+// by the Checkpoint.Store value of the config, which is shared by the scans of
+// all repositories and should be closed by the caller when it implements
+// io.Closer.
+func NewCheckpointStore(ctx context.Context, config *cfg.Config) (scanner.CheckpointStore, error) {
+	switch config.Checkpoint.Store {
+	case cfg.CheckpointStoreFile:
+		return scanner.NewFileCheckpointStore(ctx, config.Git.WorkDir), nil
+	case cfg.CheckpointStoreS3:
+		return s3.NewS3CheckpointStore(ctx, config.Checkpoint.S3)
+	case cfg.CheckpointStoreSQLite:
+		return sqlite.NewSQLiteCheckpointStore(ctx, config.Git.WorkDir, config.Checkpoint.Path)
+	default:
+		return nil, errors.Wrapf(ErrCheckpointStoreInvalid, "checkpoint.store = %s", config.Checkpoint.Store)
+	}
+}
@@ -0,0 +1,9 @@
+package store
+
+import "github.com/pkg/errors"
+
+var (
+	ErrCheckpointStoreInvalid = errors.New("invalid checkpoint store")
+	ErrResultStoreInvalid     = errors.New("invalid result store")
+	ErrTrackerStoreInvalid    = errors.New("invalid tracker store")
+)
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/jsonl"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/memory"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/sqlite"
+)
+
+// New() function creates the ResultRecordIO store selected by the
+// ResultStore value of the config for the repository with the given URL.
+// Persistent stores are created in the results directory of the work dir
+// and should be closed by the caller when they implement io.Closer.
+func New(ctx context.Context, config *cfg.Config, repo_url string) (rrr.ResultRecordIO, error) {
+	switch config.ResultStore {
+	case cfg.ResultStoreJSONL:
+		return jsonl.NewJSONLResultRecordIOForRepo(ctx, config.Git.WorkDir, repo_url)
+	case cfg.ResultStoreMemory:
+		return memory.NewMemoryResultRecordIO(ctx), nil
+	case cfg.ResultStoreSQLite:
+		return sqlite.NewSQLiteResultRecordIOForRepo(ctx, config.Git.WorkDir, repo_url)
+	default:
+		return nil, errors.Wrapf(ErrResultStoreInvalid, "result_store = %s", config.ResultStore)
+	}
+}
@@ -0,0 +1,193 @@
+package store
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/rrr"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+const test_repo_url = "https://github.com/org/repo.git"
+
+// testObjectServer() helper function starts a stand-in for an S3-compatible
+// object storage service, which stores objects in memory by path.
+func testObjectServer(t *testing.T) *httptest.Server {
+	mutex := &sync.Mutex{}
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.Method {
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			data, exists := objects[r.URL.Path]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			objects[r.URL.Path] = body
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testCheckpointStoreRoundTrip() helper function sets, gets, and deletes a
+// checkpoint in the scanner.CheckpointStore.
+func testCheckpointStoreRoundTrip(t *testing.T, store any) {
+	checkpoint_store, ok := store.(scanner.CheckpointStore)
+	require.True(t, ok)
+
+	_, err := checkpoint_store.Get("org_repo")
+	assert.ErrorIs(t, err, scanner.ErrCheckpointNotFound)
+
+	key_data, err := tracker.NewKeyData(tracker.KeyCodeComplete, "", []string{})
+	require.NoError(t, err)
+	c := scanner.NewCheckpoint(tracker.KeyDataMap{"commit-1": key_data}, tracker.KeyDataMap{}, tracker.KeyDataMap{})
+	require.NoError(t, checkpoint_store.Set("org_repo", c))
+	c, err = checkpoint_store.Get("org_repo")
+	if assert.NoError(t, err) {
+		assert.Contains(t, c.TrackerCommitsData, "commit-1")
+	}
+
+	require.NoError(t, checkpoint_store.Delete("org_repo"))
+	_, err = checkpoint_store.Get("org_repo")
+	assert.ErrorIs(t, err, scanner.ErrCheckpointNotFound)
+}
+
+// testKeyStoreRoundTrip() helper function writes, gets, and resets a key in
+// the tracker.KeyStore.
+func testKeyStoreRoundTrip(t *testing.T, store any) {
+	key_store, ok := store.(tracker.KeyStore)
+	require.True(t, ok)
+
+	key_data, err := tracker.NewKeyData(tracker.KeyCodeComplete, "", []string{})
+	require.NoError(t, err)
+	require.NoError(t, key_store.Write(map[string]tracker.KeyDataMap{"commits": {"commit-1": key_data}}, nil, 1))
+	got, exists, err := key_store.Get("commits", "commit-1")
+	if assert.NoError(t, err) && assert.True(t, exists) {
+		assert.Equal(t, tracker.KeyCodeComplete, got.Code)
+	}
+	generation, err := key_store.Generation()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), generation)
+
+	// resetting the kind deletes its keys
+	require.NoError(t, key_store.Write(map[string]tracker.KeyDataMap{}, []string{"commits"}, 2))
+	_, exists, err = key_store.Get("commits", "commit-1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// testResultStoreRoundTrip() helper function writes, reads, and deletes a
+// result record in the rrr.ResultRecordIO.
+func testResultStoreRoundTrip(t *testing.T, store any) {
+	result_io, ok := store.(rrr.ResultRecordIO)
+	require.True(t, ok)
+
+	record := rrr.ResultRecord{Hash: "hash-1", Result: rrr.Result{Category: "Person", Text: "John"}}
+	require.NoError(t, result_io.Write([]rrr.ResultRecord{record}))
+	got, err := result_io.Read("hash-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, record.Hash, got.Hash)
+		assert.Equal(t, record.Result, got.Result)
+	}
+
+	require.NoError(t, result_io.Delete("hash-1"))
+	_, err = result_io.Read("hash-1")
+	assert.Error(t, err)
+}
+
+// TestStores() unit test function tests that each of the New*() functions
+// creates a working checkpoint, result, or tracker store as selected by the
+// config, and returns an error for an invalid store.
+func TestStores(t *testing.T) {
+	t.Parallel()
+
+	server := testObjectServer(t)
+	ctx := context.Background()
+	checkpoint_store := func(name string) func(config *cfg.Config) (any, error) {
+		return func(config *cfg.Config) (any, error) {
+			config.Checkpoint.Store = name
+			config.Checkpoint.S3 = cfg.CheckpointS3Config{
+				AccessKeyID:     "test-key",
+				Bucket:          "bucket",
+				Endpoint:        server.URL,
+				SecretAccessKey: "test-secret",
+			}
+			return NewCheckpointStore(ctx, config)
+		}
+	}
+	key_store := func(name string) func(config *cfg.Config) (any, error) {
+		return func(config *cfg.Config) (any, error) {
+			config.TrackerStore = name
+			return NewKeyStore(ctx, config, test_repo_url)
+		}
+	}
+	result_store := func(name string) func(config *cfg.Config) (any, error) {
+		return func(config *cfg.Config) (any, error) {
+			config.ResultStore = name
+			return New(ctx, config, test_repo_url)
+		}
+	}
+
+	tests := []struct {
+		expect_err error
+		name       string
+		new        func(config *cfg.Config) (any, error)
+		// round_trip is nil if no store is expected, e.g. for the in-memory
+		// tracker store
+		round_trip func(t *testing.T, store any)
+	}{
+		{name: "checkpoint_file", new: checkpoint_store(cfg.CheckpointStoreFile), round_trip: testCheckpointStoreRoundTrip},
+		{name: "checkpoint_s3", new: checkpoint_store(cfg.CheckpointStoreS3), round_trip: testCheckpointStoreRoundTrip},
+		{name: "checkpoint_sqlite", new: checkpoint_store(cfg.CheckpointStoreSQLite), round_trip: testCheckpointStoreRoundTrip},
+		{name: "checkpoint_invalid", new: checkpoint_store("invalid"), expect_err: ErrCheckpointStoreInvalid},
+		{name: "result_jsonl", new: result_store(cfg.ResultStoreJSONL), round_trip: testResultStoreRoundTrip},
+		{name: "result_memory", new: result_store(cfg.ResultStoreMemory), round_trip: testResultStoreRoundTrip},
+		{name: "result_sqlite", new: result_store(cfg.ResultStoreSQLite), round_trip: testResultStoreRoundTrip},
+		{name: "result_invalid", new: result_store("invalid"), expect_err: ErrResultStoreInvalid},
+		{name: "tracker_memory", new: key_store(cfg.TrackerStoreMemory)},
+		{name: "tracker_sqlite", new: key_store(cfg.TrackerStoreSQLite), round_trip: testKeyStoreRoundTrip},
+		{name: "tracker_invalid", new: key_store("invalid"), expect_err: ErrTrackerStoreInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := cfg.NewDefaultConfig()
+			config.Git.WorkDir = t.TempDir()
+
+			store, err := test.new(config)
+			if test.expect_err != nil {
+				assert.ErrorIs(t, err, test.expect_err)
+				return
+			}
+			require.NoError(t, err)
+			if closer, ok := store.(io.Closer); ok {
+				t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+			}
+			if test.round_trip == nil {
+				assert.Nil(t, store)
+				return
+			}
+			test.round_trip(t, store)
+		})
+	}
+}
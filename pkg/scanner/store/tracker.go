@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/cfg"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/sqlite"
+	"github.com/Pii-Hole-Engineering/no-phi-ai/pkg/scanner/tracker"
+)
+
+// NewKeyStore() function creates the tracker.KeyStore selected by the
+// TrackerStore value of the config for the repository with the given URL.
+// Returns a nil KeyStore for TrackerStoreMemory, where the scanner keeps its
+// keys in memory. Persistent stores are created in the trackers directory of
+// the work dir and should be closed by the caller when they implement
+// io.Closer.
+func NewKeyStore(ctx context.Context, config *cfg.Config, repo_url string) (tracker.KeyStore, error) {
+	switch config.TrackerStore {
+	case cfg.TrackerStoreMemory:
+		return nil, nil
+	case cfg.TrackerStoreSQLite:
+		return sqlite.NewSQLiteKeyStoreForRepo(ctx, config.Git.WorkDir, repo_url)
+	default:
+		return nil, errors.Wrapf(ErrTrackerStoreInvalid, "tracker_store = %s", config.TrackerStore)
+	}
+}
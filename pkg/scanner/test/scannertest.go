@@ -68,6 +68,8 @@ func ScannerTestEndToEnd(ctx context.Context, repo_url string) (e error) {
 		ChanErrorsSend:      chan_scan_errors,
 		ChanRequestSend:     chan_requests,
 		ChanResponseReceive: chan_responses,
+		Config:              config,
+		Detector:            cfg.DetectorDryRun,
 		RepoID:              repo_url,
 		Repository:          repository,
 	})
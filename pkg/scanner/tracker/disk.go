@@ -0,0 +1,377 @@
+package tracker
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// KeyStore interface defines the methods of an embedded key-value store (e.g.
+// a SQLite database file) that holds the keys of the DiskKeyTracker objects
+// of a scan, where the keys of each tracker are separated by the kind of the
+// tracker. Each Write() of the store sets the generation of the store, which
+// identifies the state of the store that is referenced by a checkpoint.
+type KeyStore interface {
+	// Generation() method returns 0 if the store was never written.
+	Generation() (int64, error)
+	// Get() method returns false if the key of the kind does not exist.
+	Get(kind, key string) (KeyData, bool, error)
+	GetPath() string
+	Range(kind string, fn func(key string, key_data KeyData) error) error
+	// Write() method deletes every key of the kinds in reset, and then writes
+	// the keys of every kind of data, in a single transaction.
+	Write(data map[string]KeyDataMap, reset []string, generation int64) error
+}
+
+// DiskKeyTracker struct provides an implementation of the Tracker interface
+// that keeps its keys in a KeyStore instead of memory, such that the keys of
+// repositories with millions of objects do not need to fit in memory. Keys
+// that changed since the previous Flush() are kept in memory until they are
+// written to the store by the next Flush(), which is how each checkpoint of
+// a scan only writes the changed keys. The counts of each state are kept in
+// memory, such that GetCounts() and CheckAllComplete() do not read the store.
+type DiskKeyTracker struct {
+	Kind string `json:"kind"`
+
+	counts KeyDataCounts
+	dirty  KeyDataMap
+	// flushing holds the changed keys that are being written to the store by
+	// Flush(), which are read before the keys of the store until written.
+	flushing KeyDataMap
+	flush_mu *sync.Mutex
+	logger   *zerolog.Logger
+	mu       *sync.RWMutex
+	store    KeyStore
+}
+
+// NewDiskKeyTracker() function initializes a new DiskKeyTracker struct for the
+// given kind of object, which keeps its keys in the provided KeyStore, and
+// counts the keys that already exist in the store, e.g. the keys of a scan
+// that is resumed from a checkpoint.
+func NewDiskKeyTracker(kind string, logger *zerolog.Logger, store KeyStore) (*DiskKeyTracker, error) {
+	if err := checkKind(kind); err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, ErrKeyTrackerStoreNil
+	}
+
+	counts := NewKeyDataCounts()
+	err := store.Range(kind, func(key string, key_data KeyData) error {
+		countKeyData(&counts, key_data, 1)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to count keys of kind=%s in tracker store", kind)
+	}
+
+	return &DiskKeyTracker{
+		Kind:     kind,
+		counts:   counts,
+		dirty:    make(KeyDataMap),
+		flushing: make(KeyDataMap),
+		flush_mu: &sync.Mutex{},
+		logger:   logger,
+		mu:       &sync.RWMutex{},
+		store:    store,
+	}, nil
+}
+
+// CheckAllComplete() method checks if the state of all keys in the
+// DiskKeyTracker is KeyCodeComplete. Only returns true if all keys are
+// complete, otherwise returns false.
+func (dt *DiskKeyTracker) CheckAllComplete() bool {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	return dt.counts.Init == 0 && dt.counts.Pending == 0
+}
+
+// Fail() method marks the given key as KeyCodeError with the provided
+// message, with the same semantics as the KeyTracker.Fail() method.
+func (dt *DiskKeyTracker) Fail(key string, message string) (code_out int, e error) {
+	if key == "" {
+		e = ErrKeyUpdateKeyEmpty
+		return
+	}
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	key_data, exists, err := dt.get(key)
+	if err != nil {
+		e = errors.Wrapf(err, "failed to fail key=%s", key)
+		return
+	}
+	dt.set(key, key_data, exists, failKeyData(key_data, exists, message))
+	code_out = KeyCodeError
+
+	return
+}
+
+// Get() method gets the KeyData for the provided key, if it exists in the
+// DiskKeyTracker, and returns the KeyData and a boolean indicating whether
+// the key exists in the tracker. A key that cannot be read from the store
+// is logged and reported as not existing.
+func (dt *DiskKeyTracker) Get(key string) (key_data KeyData, exists bool) {
+	if key == "" {
+		return
+	}
+
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	key_data, exists, err := dt.get(key)
+	if err != nil {
+		dt.logger.Error().Err(err).Msgf("KIND=%s : failed to get key=%s from tracker store", dt.Kind, key)
+		return KeyData{}, false
+	}
+	return copyKeyData(key_data), exists
+}
+
+// GetCounts() method returns the number of keys in each state.
+func (dt *DiskKeyTracker) GetCounts() KeyDataCounts {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	return dt.counts
+}
+
+// GetKeysData() method gets a copy of the map of keys and their associated
+// KeyData, which reads every key from the store. A store that cannot be read
+// is logged, and the keys that were read are returned.
+func (dt *DiskKeyTracker) GetKeysData() KeyDataMap {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	out, err := dt.getKeysData(func(key_data KeyData) bool { return true })
+	if err != nil {
+		dt.logger.Error().Err(err).Msgf("KIND=%s : failed to get keys from tracker store", dt.Kind)
+	}
+	return out
+}
+
+// GetKeysDataForCode() method returns a filtered copy of the keys of the
+// DiskKeyTracker, where the only keys in the returned map are those with
+// the given code.
+func (dt *DiskKeyTracker) GetKeysDataForCode(code int) (KeyDataMap, error) {
+	if err := KeyCodeValidate(code); err != nil {
+		return nil, errors.Wrapf(err, "failed to get keys data for code %d", code)
+	}
+
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	out, err := dt.getKeysData(func(key_data KeyData) bool { return key_data.Code == code })
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get keys data for code %d", code)
+	}
+	return out, nil
+}
+
+// GetKind() method returns the kind of object tracked by the DiskKeyTracker.
+func (dt *DiskKeyTracker) GetKind() string {
+	return dt.Kind
+}
+
+func (dt *DiskKeyTracker) PrintCounts() KeyDataCounts {
+	counts := dt.GetCounts()
+	dt.logger.Info().Msgf(
+		"PrintCounts :: KIND=%s : INIT=%d : ERROR=%d : IGNORE=%d : PENDING=%d : COMPLETE=%d",
+		dt.Kind,
+		counts.Init,
+		counts.Error,
+		counts.Ignore,
+		counts.Pending,
+		counts.Complete,
+	)
+	return counts
+}
+
+// ResetPending() method moves every key of the DiskKeyTracker in the
+// KeyCodePending state back to KeyCodeInit, with the same semantics as the
+// KeyTracker.ResetPending() method. The reset keys are written to the store
+// by the next Flush().
+func (dt *DiskKeyTracker) ResetPending() (int, error) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	pending, err := dt.getKeysData(func(key_data KeyData) bool { return key_data.Code == KeyCodePending })
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to reset pending keys of kind=%s", dt.Kind)
+	}
+	for key, key_data := range pending {
+		dt.set(key, key_data, true, resetKeyData(key_data))
+	}
+	return len(pending), nil
+}
+
+// Restore() method restores the state of the DiskKeyTracker from the provided
+// KeyDataMap, which replaces all keys of the tracker in the store with the
+// keys of the map in a single transaction that sets a new generation of the
+// store, such that a Checkpoint never references a partly restored store.
+func (dt *DiskKeyTracker) Restore(kdm KeyDataMap) error {
+	dt.flush_mu.Lock()
+	defer dt.flush_mu.Unlock()
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	generation, err := dt.store.Generation()
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore keys of kind=%s in tracker store", dt.Kind)
+	}
+	if err := dt.store.Write(map[string]KeyDataMap{dt.Kind: kdm}, []string{dt.Kind}, generation+1); err != nil {
+		return errors.Wrapf(err, "failed to restore keys of kind=%s in tracker store", dt.Kind)
+	}
+	dt.counts = NewKeyDataCounts()
+	dt.dirty = make(KeyDataMap)
+	dt.flushing = make(KeyDataMap)
+	for _, key_data := range kdm {
+		countKeyData(&dt.counts, key_data, 1)
+	}
+	return nil
+}
+
+// Update() method updates the KeyData for the given key with the provided
+// code and message, with the same semantics as the KeyTracker.Update()
+// method. If the key does not exist in the DiskKeyTracker, then it will be
+// added.
+func (dt *DiskKeyTracker) Update(key string, code_in int, message string, child_keys []string) (code_out int, e error) {
+	if key == "" {
+		e = ErrKeyUpdateKeyEmpty
+		return
+	}
+	if e = KeyCodeValidate(code_in); e != nil {
+		e = errors.Wrapf(e, "failed to update data for key=%s", key)
+		return
+	}
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	key_data, exists, err := dt.get(key)
+	if err != nil {
+		e = errors.Wrapf(err, "failed to update data for key=%s", key)
+		return
+	}
+	previous := key_data
+	key_data, changed := updateKeyData(key_data, exists, code_in, message, child_keys)
+	if changed {
+		dt.set(key, previous, exists, key_data)
+	}
+	if !exists {
+		dt.logger.Trace().Msgf("KIND=%s : created new key=%s with code=%d", dt.Kind, key, key_data.Code)
+	}
+	code_out = key_data.Code
+
+	return
+}
+
+// get() method returns the KeyData of the key from the changed keys, or else
+// from the keys being flushed, or else from the store. The caller must hold
+// the lock of the tracker.
+func (dt *DiskKeyTracker) get(key string) (KeyData, bool, error) {
+	if key_data, exists := dt.dirty[key]; exists {
+		return key_data, true, nil
+	}
+	if key_data, exists := dt.flushing[key]; exists {
+		return key_data, true, nil
+	}
+	return dt.store.Get(dt.Kind, key)
+}
+
+// getKeysData() method returns the keys of the store, the keys being flushed,
+// and the changed keys for which include() returns true, where a changed key
+// replaces a key being flushed, which replaces the key of the store. The
+// caller must hold the lock of the tracker.
+func (dt *DiskKeyTracker) getKeysData(include func(key_data KeyData) bool) (KeyDataMap, error) {
+	out := make(KeyDataMap)
+	err := dt.store.Range(dt.Kind, func(key string, key_data KeyData) error {
+		_, changed := dt.dirty[key]
+		_, flushing := dt.flushing[key]
+		if !changed && !flushing && include(key_data) {
+			out[key] = key_data
+		}
+		return nil
+	})
+	for key, key_data := range dt.flushing {
+		if _, changed := dt.dirty[key]; !changed && include(key_data) {
+			out[key] = copyKeyData(key_data)
+		}
+	}
+	for key, key_data := range dt.dirty {
+		if include(key_data) {
+			out[key] = copyKeyData(key_data)
+		}
+	}
+	return out, err
+}
+
+// set() method sets the changed KeyData of the key and updates the counts of
+// each state, where previous is the KeyData of the key if exists is true. The
+// caller must hold the write lock of the tracker.
+func (dt *DiskKeyTracker) set(key string, previous KeyData, exists bool, key_data KeyData) {
+	if exists {
+		countKeyData(&dt.counts, previous, -1)
+	}
+	countKeyData(&dt.counts, key_data, 1)
+	dt.dirty[key] = key_data
+}
+
+// Flush() function writes the keys of each DiskKeyTracker that changed since
+// the previous Flush() to the KeyStore in a single transaction, and returns
+// the new generation of the store. The changed keys of every tracker are
+// swapped for an empty map while the trackers are locked, such that the store
+// holds a consistent state of every tracker, and the keys are then written
+// without holding the locks of the trackers. The swapped keys are merged back
+// into the changed keys if the write fails. Returns a non-nil error if any
+// tracker is not a DiskKeyTracker that keeps its keys in the store.
+func Flush(store KeyStore, trackers ...Tracker) (generation int64, e error) {
+	disk_trackers := make([]*DiskKeyTracker, 0, len(trackers))
+	for _, t := range trackers {
+		dt, ok := t.(*DiskKeyTracker)
+		if !ok || dt.store != store {
+			e = ErrKeyTrackerStoreMismatch
+			return
+		}
+		disk_trackers = append(disk_trackers, dt)
+	}
+	// only one Flush() of a tracker at a time
+	for _, dt := range disk_trackers {
+		dt.flush_mu.Lock()
+		defer dt.flush_mu.Unlock()
+	}
+
+	data := make(map[string]KeyDataMap, len(disk_trackers))
+	for _, dt := range disk_trackers {
+		dt.mu.Lock()
+	}
+	for _, dt := range disk_trackers {
+		dt.flushing = dt.dirty
+		dt.dirty = make(KeyDataMap)
+		data[dt.Kind] = dt.flushing
+	}
+	for _, dt := range disk_trackers {
+		dt.mu.Unlock()
+	}
+
+	generation, e = store.Generation()
+	if e == nil {
+		generation++
+		e = store.Write(data, nil, generation)
+	}
+	for _, dt := range disk_trackers {
+		dt.mu.Lock()
+		if e != nil {
+			// keep the keys that changed during the write
+			for key, key_data := range dt.flushing {
+				if _, changed := dt.dirty[key]; !changed {
+					dt.dirty[key] = key_data
+				}
+			}
+		}
+		dt.flushing = make(KeyDataMap)
+		dt.mu.Unlock()
+	}
+	if e != nil {
+		e = errors.Wrap(e, "failed to flush trackers")
+	}
+	return
+}
@@ -0,0 +1,305 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeyStore struct implements the KeyStore interface with maps, and counts
+// the keys written by each Write() of the store.
+type testKeyStore struct {
+	generation int64
+	keys       map[string]KeyDataMap
+	// on_write is called by Write() before the keys are written, and the
+	// keys are not written if it returns a non-nil error
+	on_write func() error
+	written  int
+}
+
+func newTestKeyStore() *testKeyStore {
+	return &testKeyStore{keys: make(map[string]KeyDataMap)}
+}
+
+func (ks *testKeyStore) Generation() (int64, error) {
+	return ks.generation, nil
+}
+
+func (ks *testKeyStore) Get(kind, key string) (KeyData, bool, error) {
+	key_data, exists := ks.keys[kind][key]
+	return key_data, exists, nil
+}
+
+func (ks *testKeyStore) GetPath() string {
+	return "test"
+}
+
+func (ks *testKeyStore) Range(kind string, fn func(key string, key_data KeyData) error) error {
+	for key, key_data := range ks.keys[kind] {
+		if err := fn(key, key_data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *testKeyStore) Write(data map[string]KeyDataMap, reset []string, generation int64) error {
+	if ks.on_write != nil {
+		if err := ks.on_write(); err != nil {
+			return err
+		}
+	}
+	ks.written = 0
+	for _, kind := range reset {
+		delete(ks.keys, kind)
+	}
+	for kind, kdm := range data {
+		if ks.keys[kind] == nil {
+			ks.keys[kind] = make(KeyDataMap)
+		}
+		for key, key_data := range kdm {
+			ks.keys[kind][key] = key_data
+			ks.written++
+		}
+	}
+	ks.generation = generation
+	return nil
+}
+
+// TestNewDiskKeyTracker() unit test function tests the validation of the
+// input and that the keys already in the store are counted.
+func TestNewDiskKeyTracker(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	store := newTestKeyStore()
+	_, err := NewDiskKeyTracker("invalid", &logger, store)
+	assert.ErrorIs(t, err, ErrKeyTrackerInvalidKind)
+	_, err = NewDiskKeyTracker(ScanObjectTypeFile, &logger, nil)
+	assert.ErrorIs(t, err, ErrKeyTrackerStoreNil)
+
+	key_data_complete, _ := NewKeyData(KeyCodeComplete, "", []string{})
+	key_data_pending, _ := NewKeyData(KeyCodePending, "", []string{"child"})
+	store.keys[ScanObjectTypeFile] = KeyDataMap{"file-1": key_data_complete, "file-2": key_data_pending}
+	dt, err := NewDiskKeyTracker(ScanObjectTypeFile, &logger, store)
+	require.NoError(t, err)
+	assert.Equal(t, KeyDataCounts{Complete: 1, Pending: 1}, dt.GetCounts())
+	assert.False(t, dt.CheckAllComplete())
+}
+
+// TestDiskKeyTracker() unit test function tests that the DiskKeyTracker has
+// the same state as the KeyTracker after the same sequence of updates, while
+// the keys of the DiskKeyTracker are flushed to the store between updates.
+func TestDiskKeyTracker(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	store := newTestKeyStore()
+	kt, err := NewKeyTracker(ScanObjectTypeFile, &logger)
+	require.NoError(t, err)
+	dt, err := NewDiskKeyTracker(ScanObjectTypeFile, &logger, store)
+	require.NoError(t, err)
+
+	steps := []struct {
+		key        string
+		code       int
+		child_keys []string
+		fail       bool
+		flush      bool
+	}{
+		{key: "file-1", code: KeyCodeInit},
+		{key: "file-2", code: KeyCodeInit, flush: true},
+		{key: "file-1", code: KeyCodePending, child_keys: []string{"request-1", "request-2"}},
+		{key: "file-2", code: KeyCodeIgnore, flush: true},
+		{key: "file-1", code: KeyCodeComplete, child_keys: []string{"request-1"}, flush: true},
+		// going back to a lower state is refused
+		{key: "file-2", code: KeyCodeInit},
+		{key: "file-3", code: KeyCodePending, child_keys: []string{"request-3"}, flush: true},
+		{key: "file-3", fail: true},
+		{key: "file-1", code: KeyCodeComplete, child_keys: []string{"request-2"}},
+	}
+	for _, step := range steps {
+		if step.fail {
+			kt_code, kt_err := kt.Fail(step.key, "failed")
+			dt_code, dt_err := dt.Fail(step.key, "failed")
+			assert.Equal(t, kt_code, dt_code)
+			assert.Equal(t, kt_err, dt_err)
+		} else {
+			kt_code, kt_err := kt.Update(step.key, step.code, "", step.child_keys)
+			dt_code, dt_err := dt.Update(step.key, step.code, "", step.child_keys)
+			assert.Equal(t, kt_code, dt_code, step.key)
+			assert.Equal(t, kt_err, dt_err)
+		}
+		if step.flush {
+			_, err := Flush(store, dt)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, kt.GetCounts(), dt.GetCounts())
+		assert.Equal(t, kt.CheckAllComplete(), dt.CheckAllComplete())
+	}
+	assert.True(t, dt.CheckAllComplete())
+
+	kt_keys_data := kt.GetKeysData()
+	dt_keys_data := dt.GetKeysData()
+	require.Len(t, dt_keys_data, len(kt_keys_data))
+	for key, key_data := range kt_keys_data {
+		assert.Equal(t, key_data.Code, dt_keys_data[key].Code, key)
+		assert.Equal(t, key_data.Children, dt_keys_data[key].Children, key)
+		dt_key_data, exists := dt.Get(key)
+		assert.True(t, exists)
+		assert.Equal(t, key_data.Code, dt_key_data.Code, key)
+	}
+	errors_data, err := dt.GetKeysDataForCode(KeyCodeError)
+	require.NoError(t, err)
+	assert.Len(t, errors_data, 1)
+	assert.Contains(t, errors_data, "file-3")
+	_, exists := dt.Get("file-4")
+	assert.False(t, exists)
+}
+
+// TestFlush() unit test function tests that Flush() only writes the keys that
+// changed since the previous Flush(), and increments the generation.
+func TestFlush(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	store := newTestKeyStore()
+	dt_commits, err := NewDiskKeyTracker(ScanObjectTypeCommit, &logger, store)
+	require.NoError(t, err)
+	dt_files, err := NewDiskKeyTracker(ScanObjectTypeFile, &logger, store)
+	require.NoError(t, err)
+
+	for _, key := range []string{"file-1", "file-2", "file-3"} {
+		_, err := dt_files.Update(key, KeyCodeInit, "", []string{})
+		require.NoError(t, err)
+	}
+	_, err = dt_commits.Update("commit-1", KeyCodeInit, "", []string{})
+	require.NoError(t, err)
+	generation, err := Flush(store, dt_commits, dt_files)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), generation)
+	assert.Equal(t, 4, store.written)
+	assert.Len(t, store.keys[ScanObjectTypeFile], 3)
+	assert.Len(t, store.keys[ScanObjectTypeCommit], 1)
+
+	// only the changed key is written by the next Flush()
+	_, err = dt_files.Update("file-2", KeyCodeComplete, "", []string{})
+	require.NoError(t, err)
+	generation, err = Flush(store, dt_commits, dt_files)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), generation)
+	assert.Equal(t, 1, store.written)
+	assert.Equal(t, KeyCodeComplete, store.keys[ScanObjectTypeFile]["file-2"].Code)
+
+	// a tracker that does not keep its keys in the store cannot be flushed
+	kt, err := NewKeyTracker(ScanObjectTypeRequestResponse, &logger)
+	require.NoError(t, err)
+	_, err = Flush(store, dt_files, kt)
+	assert.ErrorIs(t, err, ErrKeyTrackerStoreMismatch)
+	_, err = Flush(newTestKeyStore(), dt_files)
+	assert.ErrorIs(t, err, ErrKeyTrackerStoreMismatch)
+}
+
+// TestFlush_Unlocked() unit test function tests that the trackers can be read
+// and updated while Flush() writes the keys to the store, and that the keys
+// are written again by the next Flush() if the write fails.
+func TestFlush_Unlocked(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	store := newTestKeyStore()
+	dt, err := NewDiskKeyTracker(ScanObjectTypeFile, &logger, store)
+	require.NoError(t, err)
+	_, err = dt.Update("file-1", KeyCodePending, "", []string{"request-1"})
+	require.NoError(t, err)
+	_, err = dt.Update("file-2", KeyCodeInit, "", []string{})
+	require.NoError(t, err)
+
+	store.on_write = func() error {
+		// the keys being written are still read from the tracker
+		key_data, exists := dt.Get("file-1")
+		assert.True(t, exists)
+		assert.Equal(t, KeyCodePending, key_data.Code)
+		assert.Len(t, dt.GetKeysData(), 2)
+		_, err := dt.Update("file-1", KeyCodeComplete, "", []string{"request-1"})
+		assert.NoError(t, err)
+		return errors.New("write failed")
+	}
+	_, err = Flush(store, dt)
+	assert.Error(t, err)
+	assert.Empty(t, store.keys)
+	key_data, exists := dt.Get("file-1")
+	assert.True(t, exists)
+	assert.Equal(t, KeyCodeComplete, key_data.Code)
+
+	// the keys of the failed write, and the key updated during the write,
+	// are written by the next Flush()
+	store.on_write = nil
+	_, err = Flush(store, dt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.written)
+	assert.Equal(t, KeyCodeComplete, store.keys[ScanObjectTypeFile]["file-1"].Code)
+	assert.Equal(t, KeyCodeInit, store.keys[ScanObjectTypeFile]["file-2"].Code)
+	assert.Equal(t, KeyDataCounts{Complete: 1, Init: 1}, dt.GetCounts())
+}
+
+// TestDiskKeyTracker_ResetPending() unit test function tests that the
+// ResetPending() method resets the pending keys of the store, which are
+// written to the store by the next Flush().
+func TestDiskKeyTracker_ResetPending(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	store := newTestKeyStore()
+	dt, err := NewDiskKeyTracker(ScanObjectTypeRequestResponse, &logger, store)
+	require.NoError(t, err)
+	_, err = dt.Update("request-1", KeyCodePending, "", []string{})
+	require.NoError(t, err)
+	_, err = dt.Update("request-2", KeyCodeComplete, "", []string{})
+	require.NoError(t, err)
+	_, err = Flush(store, dt)
+	require.NoError(t, err)
+	_, err = dt.Update("request-3", KeyCodePending, "", []string{})
+	require.NoError(t, err)
+
+	count, err := dt.ResetPending()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, KeyDataCounts{Complete: 1, Init: 2}, dt.GetCounts())
+	_, err = Flush(store, dt)
+	require.NoError(t, err)
+	assert.Equal(t, KeyCodeInit, store.keys[ScanObjectTypeRequestResponse]["request-1"].Code)
+	assert.Equal(t, KeyCodeInit, store.keys[ScanObjectTypeRequestResponse]["request-3"].Code)
+	assert.Equal(t, KeyCodeComplete, store.keys[ScanObjectTypeRequestResponse]["request-2"].Code)
+}
+
+// TestDiskKeyTracker_Restore() unit test function tests that Restore()
+// replaces the keys of the tracker in the store with a new generation.
+func TestDiskKeyTracker_Restore(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	store := newTestKeyStore()
+	dt, err := NewDiskKeyTracker(ScanObjectTypeCommit, &logger, store)
+	require.NoError(t, err)
+	_, err = dt.Update("commit-1", KeyCodeInit, "", []string{})
+	require.NoError(t, err)
+	_, err = Flush(store, dt)
+	require.NoError(t, err)
+
+	key_data, _ := NewKeyData(KeyCodeComplete, "", []string{})
+	require.NoError(t, dt.Restore(KeyDataMap{"commit-2": key_data}))
+	assert.Equal(t, KeyDataCounts{Complete: 1}, dt.GetCounts())
+	_, exists := dt.Get("commit-1")
+	assert.False(t, exists)
+	assert.Equal(t, KeyDataMap{"commit-2": key_data}, store.keys[ScanObjectTypeCommit])
+	assert.Equal(t, int64(2), store.generation)
+
+	// restoring an empty map resets the tracker
+	require.NoError(t, dt.Restore(KeyDataMap{}))
+	assert.Equal(t, NewKeyDataCounts(), dt.GetCounts())
+	assert.Empty(t, dt.GetKeysData())
+}
@@ -28,6 +28,14 @@ func TestErrors(t *testing.T) {
 			err:  ErrKeyTrackerInvalidKind,
 			name: "ErrKeyTrackerInvalidKind",
 		},
+		{
+			err:  ErrKeyTrackerStoreMismatch,
+			name: "ErrKeyTrackerStoreMismatch",
+		},
+		{
+			err:  ErrKeyTrackerStoreNil,
+			name: "ErrKeyTrackerStoreNil",
+		},
 		{
 			err:  ErrKeyUpdateKeyEmpty,
 			name: "ErrKeyUpdateKeyEmpty",
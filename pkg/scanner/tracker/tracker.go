@@ -118,6 +118,24 @@ func NewKeyDataCounts() KeyDataCounts {
 // KeyDataMap type is used to represent a map of key strings to KeyData structs.
 type KeyDataMap map[string]KeyData
 
+// Tracker interface defines the methods used by the scanner to track the
+// state of objects as they are scanned, which are implemented by the
+// in-memory KeyTracker and by the DiskKeyTracker, which keeps its keys in a
+// KeyStore.
+type Tracker interface {
+	CheckAllComplete() bool
+	Fail(key string, message string) (int, error)
+	Get(key string) (KeyData, bool)
+	GetCounts() KeyDataCounts
+	GetKeysData() KeyDataMap
+	GetKeysDataForCode(code int) (KeyDataMap, error)
+	GetKind() string
+	PrintCounts() KeyDataCounts
+	ResetPending() (int, error)
+	Restore(kdm KeyDataMap) error
+	Update(key string, code_in int, message string, child_keys []string) (int, error)
+}
+
 // KeyTracker struct is used to track the state of objects as they are
 // scanned in order to prevent duplicate work and to provide a mechanism
 // for tracking the progress of the scan.
@@ -133,15 +151,8 @@ type KeyTracker struct {
 // returns a pointer to the struct. The kind parameter is used to
 // specify the type of object that the KeyTracker will be used to track.
 func NewKeyTracker(kind string, logger *zerolog.Logger) (*KeyTracker, error) {
-	switch kind {
-	case ScanObjectTypeCommit:
-		kind = ScanObjectTypeCommit
-	case ScanObjectTypeFile:
-		kind = ScanObjectTypeFile
-	case ScanObjectTypeRequestResponse:
-		kind = ScanObjectTypeRequestResponse
-	default:
-		return nil, ErrKeyTrackerInvalidKind
+	if err := checkKind(kind); err != nil {
+		return nil, err
 	}
 
 	return &KeyTracker{
@@ -168,6 +179,27 @@ func (kt *KeyTracker) CheckAllComplete() bool {
 	return true
 }
 
+// Fail() method marks the given key as KeyCodeError with the provided
+// message. Unlike Update(), Fail() moves a key to the error state from any
+// other state, which allows work that was already handed off (e.g. a request
+// sent to the detector) to be marked as failed, and allows a key to be marked
+// as failed after all of its children are done, if any of the children failed.
+// If the key does not exist in the KeyTracker, then it will be added.
+func (kt *KeyTracker) Fail(key string, message string) (code_out int, e error) {
+	if key == "" {
+		e = ErrKeyUpdateKeyEmpty
+		return
+	}
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+	key_data, exists := kt.Keys[key]
+	key_data = failKeyData(key_data, exists, message)
+	kt.Keys[key] = key_data
+	code_out = key_data.Code
+
+	return
+}
+
 // Get() method gets the KeyData for the provided key, if it exists in the
 // KeyTracker, and returns the KeyData and a boolean indicating whether
 // the key exists in the tracker.
@@ -182,6 +214,7 @@ func (kt *KeyTracker) Get(key string) (key_data KeyData, exists bool) {
 	defer kt.mu.RUnlock()
 
 	key_data, exists = kt.Keys[key]
+	key_data = copyKeyData(key_data)
 	return
 }
 
@@ -193,18 +226,7 @@ func (kt *KeyTracker) GetCounts() KeyDataCounts {
 
 	counts := NewKeyDataCounts()
 	for _, key_data := range kt.Keys {
-		switch key_data.Code {
-		case KeyCodeComplete:
-			counts.Complete++
-		case KeyCodeError:
-			counts.Error++
-		case KeyCodeIgnore:
-			counts.Ignore++
-		case KeyCodeInit:
-			counts.Init++
-		case KeyCodePending:
-			counts.Pending++
-		}
+		countKeyData(&counts, key_data, 1)
 	}
 
 	return counts
@@ -232,10 +254,11 @@ func (st *KeyTracker) GetKeysData() KeyDataMap {
 	// unlock the tracker after the function returns
 	defer st.mu.RUnlock()
 
-	// make a copy of the map to return
+	// make a copy of the map to return, including the children of each key,
+	// which are updated in place by the Update() method
 	out := make(KeyDataMap)
 	for key, key_data := range st.Keys {
-		out[key] = key_data
+		out[key] = copyKeyData(key_data)
 	}
 
 	return out
@@ -259,6 +282,11 @@ func (st *KeyTracker) GetKeysDataForCode(code int) (KeyDataMap, error) {
 	return out, nil
 }
 
+// GetKind() method returns the kind of object tracked by the KeyTracker.
+func (kt *KeyTracker) GetKind() string {
+	return kt.Kind
+}
+
 func (st *KeyTracker) PrintCodes() []int {
 	codes := make([]int, 0)
 	for key, key_data := range st.GetKeysData() {
@@ -288,10 +316,30 @@ func (kt *KeyTracker) PrintCounts() KeyDataCounts {
 	return counts
 }
 
+// ResetPending() method moves every key of the KeyTracker in the
+// KeyCodePending state back to KeyCodeInit, such that the work of the keys
+// is done again, e.g. the requests that were in flight when a scan was
+// stopped are sent again when the scan is resumed. Returns the number of
+// keys that were reset.
+func (kt *KeyTracker) ResetPending() (int, error) {
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+
+	var count int
+	for key, key_data := range kt.Keys {
+		if key_data.Code != KeyCodePending {
+			continue
+		}
+		kt.Keys[key] = resetKeyData(key_data)
+		count++
+	}
+	return count, nil
+}
+
 // Restore() method restores the state of the KeyTracker from the provided
 // KeyDataMap, which should be the result of a previous call to the
 // GetKeysData() method.
-func (kt *KeyTracker) Restore(kdm KeyDataMap) {
+func (kt *KeyTracker) Restore(kdm KeyDataMap) error {
 	kt.mu.Lock()
 	defer kt.mu.Unlock()
 	// remove all existing keys from the tracker
@@ -302,6 +350,7 @@ func (kt *KeyTracker) Restore(kdm KeyDataMap) {
 	for key, key_data := range kdm {
 		kt.Keys[key] = key_data
 	}
+	return nil
 }
 
 // Update() method updates the KeyData for the given key with the provided
@@ -321,20 +370,109 @@ func (kt *KeyTracker) Update(key string, code_in int, message string, child_keys
 	// release the lock after the function returns
 	defer kt.mu.Unlock()
 	key_data, exists := kt.Keys[key]
-	// check if the key already exists in the kt.Keys map
+	key_data, changed := updateKeyData(key_data, exists, code_in, message, child_keys)
+	if changed {
+		kt.Keys[key] = key_data
+	}
+	if !exists {
+		kt.logger.Trace().Msgf("KIND=%s : created new key=%s with code=%d", kt.Kind, key, key_data.Code)
+	}
+	code_out = key_data.Code
+
+	return
+}
+
+// checkKind() function returns ErrKeyTrackerInvalidKind if the kind is not
+// one of the types of object that can be tracked.
+func checkKind(kind string) error {
+	switch kind {
+	case ScanObjectTypeCommit, ScanObjectTypeFile, ScanObjectTypeRequestResponse:
+		return nil
+	default:
+		return ErrKeyTrackerInvalidKind
+	}
+}
+
+// copyKeyData() function returns a copy of the KeyData with its own map of
+// children, such that the copy can be read while the KeyData is updated.
+func copyKeyData(key_data KeyData) KeyData {
+	if key_data.Children == nil {
+		return key_data
+	}
+	children := make(map[string]bool, len(key_data.Children))
+	for child_key, is_complete := range key_data.Children {
+		children[child_key] = is_complete
+	}
+	key_data.Children = children
+	return key_data
+}
+
+// countKeyData() function adds delta to the count of the state of the
+// KeyData in the provided KeyDataCounts.
+func countKeyData(counts *KeyDataCounts, key_data KeyData, delta int) {
+	switch key_data.Code {
+	case KeyCodeComplete:
+		counts.Complete += delta
+	case KeyCodeError:
+		counts.Error += delta
+	case KeyCodeIgnore:
+		counts.Ignore += delta
+	case KeyCodeInit:
+		counts.Init += delta
+	case KeyCodePending:
+		counts.Pending += delta
+	}
+}
+
+// failKeyData() function returns the KeyData of a key after it is marked as
+// KeyCodeError with the provided message, where key_data is the current
+// KeyData of the key if exists is true.
+func failKeyData(key_data KeyData, exists bool, message string) KeyData {
+	if !exists {
+		key_data, _ = NewKeyData(KeyCodeError, message, []string{})
+		return key_data
+	}
+	key_data.Code = KeyCodeError
+	key_data.Message = message
+	key_data.State = KeyCodeToState(KeyCodeError)
+	key_data.TimestampLatest = rrr.TimestampNow()
+	return key_data
+}
+
+// resetKeyData() function returns the KeyData of a key after it is moved
+// back to KeyCodeInit, keeping only the children of the key that are already
+// complete, such that they are not done again, while the incomplete children
+// are added again when the work of the key is done again.
+func resetKeyData(key_data KeyData) KeyData {
+	children := make(map[string]bool, len(key_data.Children))
+	for child_key, is_complete := range key_data.Children {
+		if is_complete {
+			children[child_key] = true
+		}
+	}
+	key_data.Children = children
+	key_data.Code = KeyCodeInit
+	key_data.Message = ""
+	key_data.State = KeyCodeToState(KeyCodeInit)
+	key_data.TimestampLatest = rrr.TimestampNow()
+	return key_data
+}
+
+// updateKeyData() function returns the KeyData of a key after it is updated
+// with the provided code and message, where key_data is the current KeyData
+// of the key if exists is true, and whose Children map is updated in place.
+// Returns false if the KeyData is unchanged, because the code would go back
+// to a lower state. The code must already be validated.
+func updateKeyData(key_data KeyData, exists bool, code_in int, message string, child_keys []string) (KeyData, bool) {
 	if !exists {
 		// add the key if it does not exist; ignore error as KeyCodeValidate has
 		// already checked for any errors that NewKeyData() might return
 		k_data, _ := NewKeyData(code_in, message, child_keys)
-		kt.Keys[key] = k_data
-		code_out = k_data.Code
-		kt.logger.Trace().Msgf("KIND=%s : created new key=%s with code=%d", kt.Kind, key, k_data.Code)
-		return
+		return k_data, true
 	}
 	// refuse to go back to a lower state
 	if code_in < key_data.Code {
-		code_out = key_data.Code
-		return
+		return key_data, false
 	}
 	// overwrite the message of the existing key data
 	key_data.Message = message
@@ -368,10 +506,7 @@ func (kt *KeyTracker) Update(key string, code_in int, message string, child_keys
 			key_data.Code = code_in
 			key_data.State = KeyCodeToState(code_in)
 		}
-		// update the existing key data for the completed key state
-		kt.Keys[key] = key_data
-		code_out = key_data.Code
-		return
+		return key_data, true
 	}
 
 	if code_in != key_data.Code {
@@ -379,9 +514,5 @@ func (kt *KeyTracker) Update(key string, code_in int, message string, child_keys
 		key_data.State = KeyCodeToState(code_in)
 	}
 
-	// update the existing key data
-	kt.Keys[key] = key_data
-	code_out = key_data.Code
-
-	return
+	return key_data, true
 }
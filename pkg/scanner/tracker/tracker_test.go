@@ -543,6 +543,49 @@ func TestKeyTracker_CheckAllComplete(t *testing.T) {
 	}
 }
 
+// TestKeyTracker_Fail() unit test function tests the Fail() method of the
+// KeyTracker type.
+func TestKeyTracker_Fail(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(os.Stdout)
+	tracker, err := NewKeyTracker(ScanObjectTypeRequestResponse, &logger)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	_, err = tracker.Fail("", test_message_error)
+	assert.ErrorIs(t, err, ErrKeyUpdateKeyEmpty)
+
+	// a pending key moves to the error state
+	_, err = tracker.Update("pending", KeyCodePending, test_message_pending, []string{})
+	assert.NoError(t, err)
+	code, err := tracker.Fail("pending", test_message_error)
+	assert.NoError(t, err)
+	assert.Equal(t, KeyCodeError, code)
+	key_data, exists := tracker.Get("pending")
+	assert.True(t, exists)
+	assert.Equal(t, KeyStateError, key_data.State)
+	assert.Equal(t, test_message_error, key_data.Message)
+
+	// a complete key moves to the error state, e.g. when a child failed
+	_, err = tracker.Update("complete", KeyCodeComplete, test_message_complete, []string{"child"})
+	assert.NoError(t, err)
+	code, err = tracker.Fail("complete", test_message_error)
+	assert.NoError(t, err)
+	assert.Equal(t, KeyCodeError, code)
+	key_data, _ = tracker.Get("complete")
+	assert.Contains(t, key_data.Children, "child")
+
+	// an unknown key is added in the error state
+	code, err = tracker.Fail("unknown", test_message_error)
+	assert.NoError(t, err)
+	assert.Equal(t, KeyCodeError, code)
+
+	assert.True(t, tracker.CheckAllComplete())
+	assert.Equal(t, 3, tracker.GetCounts().Error)
+}
+
 // TestKeyTracker_Get() unit test function tests the Get() method of the
 // KeyTracker type.
 func TestKeyTracker_Get(t *testing.T) {
@@ -1185,6 +1228,39 @@ func TestKeyTracker_Restore(t *testing.T) {
 	assert.Equal(t, trckr.Keys, trckr_new.Keys)
 }
 
+// TestKeyTracker_ResetPending() unit test function tests that the
+// ResetPending() method only moves the pending keys back to the init state,
+// and keeps the children of the keys.
+func TestKeyTracker_ResetPending(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	trckr, err := NewKeyTracker(ScanObjectTypeFile, &logger)
+	assert.NoError(t, err)
+	trckr.Update("file-init", KeyCodeInit, "", []string{})
+	trckr.Update("file-pending", KeyCodePending, "", []string{"request-1", "request-2"})
+	trckr.Update("file-pending", KeyCodeComplete, "", []string{"request-1"})
+	trckr.Update("file-complete", KeyCodeComplete, "", []string{})
+
+	count, err := trckr.ResetPending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, KeyDataCounts{Complete: 1, Init: 2}, trckr.GetCounts())
+	key_data, _ := trckr.Get("file-pending")
+	assert.Equal(t, KeyCodeInit, key_data.Code)
+	assert.Equal(t, KeyStateInit, key_data.State)
+	// only the complete children are kept
+	assert.Equal(t, map[string]bool{"request-1": true}, key_data.Children)
+
+	// the reset key can be moved to the pending state again
+	code, err := trckr.Update("file-pending", KeyCodePending, "", []string{"request-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, KeyCodePending, code)
+	code, err = trckr.Update("file-pending", KeyCodeComplete, "", []string{"request-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, KeyCodeComplete, code)
+}
+
 // TestKeyTracker_Update() unit test function tests the Update() method
 // of the KeyTracker type.
 func TestKeyTracker_Update(t *testing.T) {